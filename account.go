@@ -0,0 +1,31 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultDeletionGracePeriod is a time between a deletion request and the purge of a Credential,
+// an administrator can restore the Credential during it.
+const DefaultDeletionGracePeriod = 30 * 24 * time.Hour
+
+// Export is the personal data of a Credential for a data-subject request.
+type Export struct {
+	Credential   Credential
+	Profile      Profile
+	APIKeys      []APIKey
+	Memberships  []Membership
+	AuditEntries []AuditEntry
+	CreatedAt    time.Time
+}
+
+// AccountService represents a service for data-subject requests of users.
+type AccountService interface {
+	// Export retrieves the personal data of a Credential.
+	Export(ctx context.Context, credID int) (Export, error)
+	// RequestDeletion confirms the password and schedules the purge of a Credential after a grace period.
+	RequestDeletion(ctx context.Context, credID int, plainPassword string) (Credential, error)
+	// Purge deletes Credentials whose grace period is over and anonymizes their audit entries,
+	// it returns the number of purged Credentials.
+	Purge(ctx context.Context) (int, error)
+}
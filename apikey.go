@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+//go:generate moq -pkg mock -out internal/mock/apikey.go . APIKeyRepository
+
+// APIKey authenticates a machine client on behalf of a Credential.
+type APIKey struct {
+	ID           int
+	CredentialID int
+	Name         string
+	// Prefix is a public part of the key identifying it.
+	Prefix string
+	// Hash is a hash of the whole key, the key itself isn't stored.
+	Hash string
+	// Scopes limit requests made with the key.
+	Scopes []Permission
+	// ExpiresAt is zero if the key doesn't expire.
+	ExpiresAt time.Time
+	// LastUsedAt is zero if the key was never used.
+	LastUsedAt time.Time
+	CreatedAt  time.Time
+}
+
+// APIKeyRepository is a storage for API keys.
+// All methods are scoped by the tenant of the context, see TenantFromContext.
+type APIKeyRepository interface {
+	// Create creates a new APIKey.
+	Create(ctx context.Context, k *APIKey) error
+	// ByPrefix retrieves an APIKey by prefix.
+	ByPrefix(ctx context.Context, prefix string) (APIKey, error)
+	// ByCredential retrieves APIKeys of a Credential ordered by id.
+	ByCredential(ctx context.Context, credID int) ([]APIKey, error)
+	// Touch sets the last usage time of an APIKey.
+	Touch(ctx context.Context, id int, at time.Time) error
+	// Delete deletes an APIKey of a Credential by id.
+	Delete(ctx context.Context, credID, id int) error
+}
+
+// APIKeyService represents a service for API keys.
+type APIKeyService interface {
+	// Create creates a new APIKey of the Credential and returns the key, it can't be retrieved later.
+	Create(ctx context.Context, credID int, k *APIKey) (string, error)
+	// APIKeys retrieves APIKeys of the Credential.
+	APIKeys(ctx context.Context, credID int) ([]APIKey, error)
+	// Delete deletes an APIKey of the Credential.
+	Delete(ctx context.Context, credID, id int) error
+	// Authenticate retrieves the active Credential and the APIKey by the key.
+	Authenticate(ctx context.Context, key string) (Credential, APIKey, error)
+}
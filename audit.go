@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+//go:generate moq -pkg mock -out internal/mock/audit.go . AuditLog
+
+// Events recorded in the AuditLog.
+const (
+	AuditRegister        = "register"
+	AuditLoginSuccess    = "login_success"
+	AuditLoginFailure    = "login_failure"
+	AuditTokenUse        = "token_use"
+	AuditPasswordChange  = "password_change"
+	AuditRevoke          = "revoke"
+	AuditEmailVerify     = "email_verify"
+	AuditDisable         = "disable"
+	AuditEnable          = "enable"
+	AuditSuspend         = "suspend"
+	AuditDeletionRequest = "deletion_request"
+	AuditDelete          = "delete"
+	AuditView            = "view"
+	AuditSearch          = "search"
+	AuditRoleCreate      = "role_create"
+	AuditRoleDelete      = "role_delete"
+	AuditRoleAssign      = "role_assign"
+	AuditRoleUnassign    = "role_unassign"
+	AuditTenantSave      = "tenant_save"
+	AuditOrgCreate       = "org_create"
+	AuditOrgSwitch       = "org_switch"
+	AuditInvite          = "invite"
+	AuditInviteAccept    = "invite_accept"
+	AuditAPIKeyCreate    = "api_key_create"
+	AuditAPIKeyDelete    = "api_key_delete"
+	AuditAPIKeyUse       = "api_key_use"
+	AuditProfileUpdate   = "profile_update"
+	AuditExport          = "export"
+	AuditPurge           = "purge"
+	AuditImport          = "import"
+)
+
+// Actors of the audit events other than the credential owner.
+const (
+	ActorAdmin = "admin"
+	// ActorSystem is a background job, e.g. the purge of deleted credentials.
+	ActorSystem = "system"
+	// ActorDeleted replaces the actor of an anonymized credential.
+	ActorDeleted = "deleted"
+)
+
+// ActorCredential returns the actor of a Credential acting on behalf of an administrator.
+func ActorCredential(id int) string {
+	return fmt.Sprintf("credential:%d", id)
+}
+
+// AuditEntry is a record of a security event.
+type AuditEntry struct {
+	ID int
+	// CredentialID is 0 if the credential is unknown, e.g. on login with a wrong email.
+	CredentialID int
+	Event        string
+	IP           string
+	UserAgent    string
+	// Reason is an Error code explaining a failure or a subject of the event, e.g. a role name.
+	Reason string
+	// Actor is empty if the event is caused by the credential owner.
+	Actor     string
+	CreatedAt time.Time
+}
+
+// AuditFilter filters entries of the AuditLog, zero fields are ignored.
+type AuditFilter struct {
+	CredentialID int
+	From         time.Time
+	To           time.Time
+	Limit        int
+}
+
+// AuditLog is an append-only storage of security events.
+// Entries are scoped by the tenant of the context, see TenantFromContext.
+type AuditLog interface {
+	// Append records a new entry.
+	Append(ctx context.Context, e *AuditEntry) error
+	// Find returns entries matching the filter, newest first.
+	Find(ctx context.Context, f AuditFilter) ([]AuditEntry, error)
+	// Anonymize removes the credential id, IP and user agent from entries of a Credential
+	// and replaces the Credential as the actor with ActorDeleted, the entries themselves are kept.
+	Anonymize(ctx context.Context, credID int) error
+}
@@ -10,18 +10,25 @@ import (
 // Credential is a user's credential.
 type Credential struct {
 	ID                       int
+	TenantID                 string
 	Password                 string
 	Token                    string
+	TokenExpiresAt           time.Time
 	Email                    string
 	EmailTmp                 string
 	EmailVerified            bool
 	VerificationCode         string
 	VerificationCodeAttempts uint8
+	Status                   string
+	StatusReason             string
+	StatusUntil              time.Time
+	ActiveOrganizationID     int
 	CreatedAt                time.Time
 	UpdatedAt                time.Time
 }
 
 // CredentialRepository is a storage for credentials.
+// All methods are scoped by the tenant of the context, see TenantFromContext.
 type CredentialRepository interface {
 	// ByToken retrieves a Credential by token.
 	ByToken(ctx context.Context, token string) (Credential, error)
@@ -31,6 +38,23 @@ type CredentialRepository interface {
 	ByEmail(ctx context.Context, email string) (Credential, error)
 	// Create creates a new Credential without verification.
 	Create(ctx context.Context, c *Credential) error
+	// Search retrieves Credentials matching the filter ordered by id.
+	Search(ctx context.Context, f CredentialFilter) ([]Credential, error)
+	// Update updates a Credential by id.
+	Update(ctx context.Context, c *Credential) error
+	// Delete deletes a Credential by id.
+	Delete(ctx context.Context, id int) error
+	// PendingDeletion retrieves Credentials of all tenants pending deletion with StatusUntil before the time
+	// ordered by StatusUntil, it is the only method not scoped by the tenant.
+	PendingDeletion(ctx context.Context, before time.Time, limit int) ([]Credential, error)
+}
+
+// CredentialFilter filters Credentials, zero fields are ignored.
+type CredentialFilter struct {
+	// Email matches a part of the email or the unverified email.
+	Email  string
+	Limit  int
+	Offset int
 }
 
 // CredentialService represents a service for credentials.
@@ -41,4 +65,47 @@ type CredentialService interface {
 	Register(ctx context.Context, c *Credential) error
 	// Auth makes an auth attempt.
 	Auth(ctx context.Context, email, plainPassword string) (Credential, error)
+	// Roles retrieves Roles assigned to a Credential.
+	Roles(ctx context.Context, credID int) ([]Role, error)
+	// Authorize checks if an active Credential is allowed to do the action on the resource.
+	Authorize(ctx context.Context, credID int, action, resource string) (bool, error)
+}
+
+// AdminService represents a service for administrators.
+type AdminService interface {
+	// AuditLog returns security events matching the filter.
+	AuditLog(ctx context.Context, f AuditFilter) ([]AuditEntry, error)
+	// Credentials searches Credentials matching the filter.
+	Credentials(ctx context.Context, f CredentialFilter) ([]Credential, error)
+	// Credential retrieves a Credential by id.
+	Credential(ctx context.Context, id int) (Credential, error)
+	// VerifyEmail marks the email of a Credential as verified without a verification code.
+	VerifyEmail(ctx context.Context, id int) (Credential, error)
+	// ResetPassword sets a new password and revokes the token of a Credential.
+	ResetPassword(ctx context.Context, id int, plainPassword string) (Credential, error)
+	// SetStatus changes the status of a Credential, until is the end of a suspension.
+	SetStatus(ctx context.Context, id int, status, reason string, until time.Time) (Credential, error)
+	// RevokeSessions replaces the token of a Credential.
+	RevokeSessions(ctx context.Context, id int) (Credential, error)
+	// Delete deletes a Credential.
+	Delete(ctx context.Context, id int) error
+	// Roles retrieves all Roles.
+	Roles(ctx context.Context) ([]Role, error)
+	// CreateRole creates a new Role.
+	CreateRole(ctx context.Context, r *Role) error
+	// DeleteRole deletes a Role with all its assignments.
+	DeleteRole(ctx context.Context, name string) error
+	// CredentialRoles retrieves Roles assigned to a Credential.
+	CredentialRoles(ctx context.Context, credID int) ([]Role, error)
+	// AssignRole assigns a Role to a Credential.
+	AssignRole(ctx context.Context, credID int, name string) error
+	// UnassignRole removes a Role from a Credential.
+	UnassignRole(ctx context.Context, credID int, name string) error
+	// Tenants retrieves all Tenants.
+	Tenants(ctx context.Context) ([]Tenant, error)
+	// SaveTenant creates or updates a Tenant.
+	SaveTenant(ctx context.Context, t *Tenant) error
+	// ImportCredentials creates Credentials of another system keeping their password hashes,
+	// which are upgraded on the first login. Bad records are skipped and reported in the result.
+	ImportCredentials(ctx context.Context, records []ImportRecord) (ImportResult, error)
 }
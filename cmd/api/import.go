@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/api"
+)
+
+const importUsage = "usage: import file.csv|file.jsonl [tenant]"
+
+// runImport executes the import subcommand, it creates credentials of the tenant from the file.
+func runImport(ctx context.Context, s auth.AdminService, tenants auth.TenantRepository, args []string, out io.Writer) error {
+	if len(args) == 0 || len(args) > 2 {
+		return errors.New(importUsage)
+	}
+
+	var format string
+
+	switch filepath.Ext(args[0]) {
+	case ".csv":
+		format = api.ImportCSV
+	case ".jsonl", ".ndjson":
+		format = api.ImportJSONL
+	default:
+		return fmt.Errorf("unknown format of %q: %s", args[0], importUsage)
+	}
+
+	tenantID := auth.DefaultTenantID
+	if len(args) > 1 {
+		tenantID = args[1]
+	}
+
+	tenant, err := tenants.ByID(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	records, err := api.ReadImportRecords(f, format)
+	if err != nil {
+		return err
+	}
+
+	ctx = api.ContextWithActor(auth.ContextWithTenant(ctx, tenant), auth.ActorAdmin)
+
+	result, err := s.ImportCredentials(ctx, records)
+
+	for _, e := range result.Errors {
+		fmt.Fprintf(out, "line %d %s: %s\n", e.Line, e.Email, auth.ErrorMsg(e.Err))
+	}
+
+	fmt.Fprintf(out, "imported %d of %d credentials\n", result.Created, len(records))
+
+	return err
+}
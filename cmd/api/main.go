@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
@@ -15,33 +14,87 @@ import (
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 
+	auth "github.com/kl09/auth-go"
 	"github.com/kl09/auth-go/internal/api"
+	"github.com/kl09/auth-go/internal/audit"
+	"github.com/kl09/auth-go/internal/cache"
 	"github.com/kl09/auth-go/internal/generator"
+	"github.com/kl09/auth-go/internal/health"
+	"github.com/kl09/auth-go/internal/logging"
+	"github.com/kl09/auth-go/internal/memory"
+	"github.com/kl09/auth-go/internal/metrics"
+	"github.com/kl09/auth-go/internal/password"
 	"github.com/kl09/auth-go/internal/pg"
+	"github.com/kl09/auth-go/internal/sqlite"
+	"github.com/kl09/auth-go/internal/tracing"
+)
+
+// Storages of credentials.
+const (
+	storageMemory   = "memory"
+	storageSQLite   = "sqlite"
+	storagePostgres = "postgres"
 )
 
 func main() {
 	var err error
 
-	logger := zerolog.New(os.Stderr).With().Timestamp().Logger()
+	logger := zerolog.New(logging.NewRedactWriter(os.Stderr)).With().Timestamp().Logger()
 	logger.Info().Msg("starting app")
 
 	fs := pflag.NewFlagSet(os.Args[0], pflag.ContinueOnError)
 	{
+		fs.String(
+			"storage",
+			storagePostgres,
+			"Storage of credentials: postgres, sqlite or memory, only the credentials API is available with sqlite and memory.",
+		)
 		fs.String(
 			"pg.conn-string",
 			"user=auth password=auth host=localhost port=5432 dbname=auth_test connect_timeout=3 sslmode=disable",
 			"Postgresql connection string",
 		)
 		fs.Int("pg.max-cons", 5, "Max connections to Postgres.")
-		fs.Int("pg.max-idle-cons", 2, "Max idle connections to Postgres.")
+		fs.Int("pg.min-idle-cons", 2, "Min idle connections to Postgres.")
 		fs.Duration("pg.connection-timeout", time.Minute, "Max connection timeout to Postgres.")
+		fs.Duration("pg.statement-timeout", 5*time.Second, "Default timeout of a query to Postgres.")
+
+		fs.String("sqlite.path", "auth.db", "Path of the SQLite database file, created if it doesn't exist.")
+		fs.Duration("sqlite.statement-timeout", 5*time.Second, "Default timeout of a query to SQLite.")
+
+		fs.Int("cache.size", 0, "Max number of credentials cached by token, the cache is disabled if 0.")
+		fs.Duration("cache.ttl", 30*time.Second, "Time credentials are cached by token.")
+		fs.Duration("cache.negative-ttl", 5*time.Second, "Time unknown tokens are cached.")
+
+		fs.Int("audit.batch-size", 100, "Max number of token and API key use entries appended to the audit log at once.")
+		fs.Duration("audit.flush-interval", time.Second, "Max time token and API key use entries wait to be appended to the audit log.")
 
 		fs.String("http-addr", ":8080", "Address to listen for System API")
+		fs.Duration("http-drain-period", 5*time.Second, "Time to keep serving after readiness starts failing on shutdown.")
+		fs.Duration("http-shutdown-timeout", 10*time.Second, "Max time to wait for in-flight requests on shutdown.")
+
+		fs.String("admin-addr", ":8081", "Address to listen for health probes.")
+		fs.String("admin-token", "", "Token for /admin/v1 API, only credentials with the manage permission on admin can use the API if empty.")
+		fs.String("invitation-key", "", "Key signing invitations to organizations, required with the postgres storage.")
+		fs.Duration("deletion-grace-period", auth.DefaultDeletionGracePeriod, "Time to restore a credential deleted by the user before it is purged.")
+		fs.Duration("purge-interval", time.Hour, "Interval of purging deleted credentials.")
+		fs.String("password.algorithm", password.Argon2id, "Password hashing algorithm: argon2id, bcrypt or scrypt, hashes of others are upgraded on login.")
+		fs.Int("password.cost", 0, "Cost of password hashing: argon2id iterations, bcrypt cost or scrypt log2 N, the default of the algorithm if 0.")
+		fs.Uint32("password.argon2-memory", 0, "Memory of argon2id in KiB, the default if 0.")
+		fs.String("password.pepper", "", "Secret mixed into password hashes, changing it invalidates hashes made with it.")
+		fs.Duration("readiness-timeout", 2*time.Second, "Max time to check dependencies on readiness probe.")
+
+		fs.String("tracing.exporter", tracing.ExporterNone, "Tracing exporter: none, otlp-grpc or otlp-http.")
+		fs.String("tracing.endpoint", "", "Tracing collector endpoint, OTEL_EXPORTER_OTLP_* env is used if empty.")
 
 		fs.String("log-lvl", "info", "Log level.")
 	}
 
+	if err = fs.Parse(os.Args[1:]); err != nil {
+		logger.Fatal().Err(err).Msg("failed parse flags")
+		os.Exit(1)
+	}
+
 	if err = viper.BindPFlags(fs); err != nil {
 		logger.Fatal().Err(err).Msg("failed bind pflags")
 		os.Exit(1)
@@ -55,40 +108,271 @@ func main() {
 		logger = logger.Level(logLvl)
 	}
 
-	pgClient := pg.NewClient(
-		pg.WithLogger(logger),
-		pg.WithMaxConnections(viper.GetInt("pg.max-cons")),
-		pg.WithMaxIdleConnections(viper.GetInt("pg.max-idle-cons")),
-		pg.WithConnectionTimeout(viper.GetDuration("pg.connection-timeout")),
+	tp, err := tracing.NewProvider(
+		context.Background(),
+		viper.GetString("tracing.exporter"),
+		viper.GetString("tracing.endpoint"),
+		"auth-go",
 	)
-	if err = pgClient.Open(viper.GetString("pg.conn-string")); err != nil {
-		logger.Fatal().Err(err).Msg("db connection failed")
+	if err != nil {
+		logger.Fatal().Err(err).Msg("tracing setup failed")
 		os.Exit(1)
 	}
 
 	defer func() {
-		if err = pgClient.Close(); err != nil {
-			logger.Error().Err(err).Msg("db close failed")
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+
+		if err = tp.Shutdown(shutdownCtx); err != nil {
+			logger.Error().Err(err).Msg("tracing shutdown failed")
 		}
 	}()
 
-	credRepository := pg.NewCredentialRepository(pgClient)
+	var (
+		pgClient       *pg.Client
+		credRepository auth.CredentialRepository
+		checks         []health.Check
+	)
+
+	switch viper.GetString("storage") {
+	case storageMemory:
+		logger.Warn().Msg("credentials are kept in memory and lost on restart, only the credentials API is available")
+
+		credRepository = memory.NewCredentialRepository()
+	case storageSQLite:
+		logger.Warn().Msg("only the credentials API is available with sqlite")
+
+		sqliteClient := sqlite.NewClient(
+			sqlite.WithLogger(logger),
+			sqlite.WithStatementTimeout(viper.GetDuration("sqlite.statement-timeout")),
+		)
+		if err = sqliteClient.Open(viper.GetString("sqlite.path")); err != nil {
+			logger.Fatal().Err(err).Msg("db connection failed")
+			os.Exit(1)
+		}
+
+		defer func() {
+			if err = sqliteClient.Close(); err != nil {
+				logger.Error().Err(err).Msg("db close failed")
+			}
+		}()
+
+		// The database is local to the process, so migrations are applied on start.
+		if _, err = sqlite.NewMigrator(sqliteClient).Up(context.Background()); err != nil {
+			logger.Fatal().Err(err).Msg("migration failed")
+			os.Exit(1)
+		}
+
+		credRepository = sqlite.NewCredentialRepository(sqliteClient)
+		checks = append(checks, health.Check{Name: "db", Fn: sqliteClient.Ping})
+	case storagePostgres:
+		pgClient = pg.NewClient(
+			pg.WithLogger(logger),
+			pg.WithMaxConnections(viper.GetInt("pg.max-cons")),
+			pg.WithMinIdleConnections(viper.GetInt("pg.min-idle-cons")),
+			pg.WithConnectionTimeout(viper.GetDuration("pg.connection-timeout")),
+			pg.WithStatementTimeout(viper.GetDuration("pg.statement-timeout")),
+			pg.WithTracerProvider(tp),
+		)
+		if err = pgClient.Open(viper.GetString("pg.conn-string")); err != nil {
+			logger.Fatal().Err(err).Msg("db connection failed")
+			os.Exit(1)
+		}
+
+		if fs.Arg(0) == "migrate" {
+			err = runMigrate(context.Background(), pg.NewMigrator(pgClient), fs.Args()[1:], os.Stdout)
+			if closeErr := pgClient.Close(); closeErr != nil {
+				logger.Error().Err(closeErr).Msg("db close failed")
+			}
 
-	r := api.NewRouter(
-		api.NewCredentialService(
+			if err != nil {
+				logger.Fatal().Err(err).Msg("migration failed")
+				os.Exit(1)
+			}
+
+			return
+		}
+
+		defer func() {
+			if err = pgClient.Close(); err != nil {
+				logger.Error().Err(err).Msg("db close failed")
+			}
+		}()
+
+		credRepository = pg.NewCredentialRepository(pgClient)
+	default:
+		logger.Fatal().Msgf("unknown storage %q, postgres, sqlite or memory expected", viper.GetString("storage"))
+		os.Exit(1)
+	}
+
+	if fs.NArg() > 0 && pgClient == nil {
+		logger.Fatal().Msgf("%s requires the postgres storage", fs.Arg(0))
+		os.Exit(1)
+	}
+
+	nowFn := func() time.Time {
+		return time.Now().UTC()
+	}
+
+	hasher, err := password.New(
+		password.WithAlgorithm(viper.GetString("password.algorithm")),
+		password.WithCost(viper.GetInt("password.cost")),
+		password.WithMemory(viper.GetUint32("password.argon2-memory")),
+		password.WithPepper([]byte(viper.GetString("password.pepper"))),
+	)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("password hashing setup failed")
+		os.Exit(1)
+	}
+
+	m := metrics.New()
+
+	var credCache *cache.CredentialRepository
+
+	// The cache wraps the repository of all services, so their writes evict the cached credentials.
+	if size := viper.GetInt("cache.size"); size > 0 {
+		credCache = cache.NewCredentialRepository(
 			credRepository,
-			func() time.Time {
-				return time.Now().UTC()
-			},
-			generator.GenerateRandomString,
+			nowFn,
+			cache.WithSize(size),
+			cache.WithTTL(viper.GetDuration("cache.ttl")),
+			cache.WithNegativeTTL(viper.GetDuration("cache.negative-ttl")),
+			cache.WithMetrics(m),
+		)
+		credRepository = credCache
+	}
+
+	credOptions := []api.ServiceOption{
+		api.WithMetrics(m),
+		api.WithTracerProvider(tp),
+		api.WithHasher(hasher),
+	}
+
+	routerOptions := []api.RouterOption{
+		api.WithMiddleware(
+			tracing.Middleware(tp),
+			logging.Middleware(logger, generator.GenerateRandomString),
+			m.Middleware(),
 		),
+	}
+
+	var (
+		auditLog       *audit.BatchLog
+		roleRepository *pg.RoleRepository
+		accountService *api.AccountService
 	)
 
+	if pgClient != nil {
+		m.RegisterPool(pgClient.Stats)
+		checks = append(checks, health.Check{Name: "db", Fn: pgClient.Ping})
+
+		// Token and API key use is recorded on every request, so it is appended in batches.
+		auditLog = audit.NewBatchLog(
+			pg.NewAuditLog(pgClient),
+			[]string{auth.AuditTokenUse, auth.AuditAPIKeyUse},
+			audit.WithBatchSize(viper.GetInt("audit.batch-size")),
+			audit.WithFlushInterval(viper.GetDuration("audit.flush-interval")),
+			audit.WithLogger(logger),
+		)
+		roleRepository = pg.NewRoleRepository(pgClient)
+		credOptions = append(credOptions, api.WithAuditLog(auditLog), api.WithRoleRepository(roleRepository))
+	}
+
+	credService := api.NewCredentialService(credRepository, nowFn, generator.GenerateRandomString, credOptions...)
+
+	// Features other than credentials are available only with Postgres.
+	if pgClient != nil {
+		tenantRepository := pg.NewTenantRepository(pgClient)
+		orgRepository := pg.NewOrganizationRepository(pgClient)
+		apiKeyRepository := pg.NewAPIKeyRepository(pgClient)
+		profileRepository := pg.NewProfileRepository(pgClient)
+
+		accountService = api.NewAccountService(
+			credRepository,
+			profileRepository,
+			apiKeyRepository,
+			orgRepository,
+			auditLog,
+			nowFn,
+			hasher,
+			viper.GetDuration("deletion-grace-period"),
+		)
+
+		adminService := api.NewAdminService(
+			credRepository,
+			roleRepository,
+			tenantRepository,
+			auditLog,
+			nowFn,
+			generator.GenerateRandomString,
+			hasher,
+		)
+
+		if fs.Arg(0) == "import" {
+			err = runImport(context.Background(), adminService, tenantRepository, fs.Args()[1:], os.Stdout)
+			if err != nil {
+				logger.Fatal().Err(err).Msg("import failed")
+				os.Exit(1)
+			}
+
+			return
+		}
+
+		// Invitations signed with a random key would be rejected after a restart and by other instances.
+		invitationKey := viper.GetString("invitation-key")
+		if invitationKey == "" {
+			logger.Fatal().Msg("invitation key is required with the postgres storage")
+			os.Exit(1)
+		}
+
+		routerOptions = append(routerOptions,
+			api.WithAdmin(adminService, viper.GetString("admin-token")),
+			api.WithTenants(tenantRepository),
+			api.WithAPIKeys(
+				api.NewAPIKeyService(
+					apiKeyRepository,
+					credRepository,
+					auditLog,
+					nowFn,
+					generator.GenerateRandomString,
+				),
+			),
+			api.WithOrganizations(
+				api.NewOrganizationService(
+					orgRepository,
+					credRepository,
+					credService,
+					auditLog,
+					nowFn,
+					[]byte(invitationKey),
+				),
+			),
+			api.WithProfiles(api.NewProfileService(profileRepository, auditLog, nowFn)),
+			api.WithAccounts(accountService),
+		)
+	}
+
+	r := api.NewRouter(credService, routerOptions...)
+
 	apiServer := &http.Server{
 		Addr:    viper.GetString("http-addr"),
 		Handler: r.Handler(),
 	}
 
+	readiness := health.NewReadiness()
+
+	h := health.New(readiness, viper.GetDuration("readiness-timeout"), checks...)
+
+	adminMux := http.NewServeMux()
+	adminMux.HandleFunc("/healthz", h.Liveness)
+	adminMux.HandleFunc("/readyz", h.Readiness)
+	adminMux.Handle("/metrics", m.Handler())
+
+	adminServer := &http.Server{
+		Addr:    viper.GetString("admin-addr"),
+		Handler: adminMux,
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
 	var g run.Group
@@ -104,7 +388,7 @@ func main() {
 					return fmt.Errorf("signal received: %v", si)
 				}
 			}, func(err error) {
-				logger.Fatal().Err(err).Msg("app was interrupted")
+				logger.Info().Err(err).Msg("app was interrupted")
 				cancel()
 			},
 		)
@@ -114,22 +398,71 @@ func main() {
 			logger.Info().Msgf("started server for addr: %s", apiServer.Addr)
 			return apiServer.ListenAndServe()
 		}, func(err error) {
-			logger.Info().Err(err).Msg("server was stopped")
+			readiness.Drain()
+
+			drainPeriod := viper.GetDuration("http-drain-period")
+			logger.Info().Dur("drain_period", drainPeriod).Msg("draining server")
+			time.Sleep(drainPeriod)
+
+			shutdownCtx, shutdownCancel := context.WithTimeout(
+				context.Background(),
+				viper.GetDuration("http-shutdown-timeout"),
+			)
+			defer shutdownCancel()
+
+			if err := apiServer.Shutdown(shutdownCtx); err != nil {
+				logger.Error().Err(err).Msg("server shutdown failed")
+			}
+
+			logger.Info().Msg("server was stopped")
 		})
 	}
 	{
+		// The admin server is stopped after the API one, so probes see the draining.
 		g.Add(func() error {
-			for {
-				select {
-				case <-ctx.Done():
-					return nil
-				case <-time.After(5 * time.Second):
-					b, _ := json.Marshal(pgClient.Stats())
-					logger.Debug().Msgf("pgStats :%v", string(b))
-				}
+			logger.Info().Msgf("started admin server for addr: %s", adminServer.Addr)
+			return adminServer.ListenAndServe()
+		}, func(err error) {
+			if err := adminServer.Close(); err != nil {
+				logger.Error().Err(err).Msg("admin server close failed")
 			}
+
+			logger.Info().Msg("admin server was stopped")
+		})
+	}
+
+	if accountService != nil {
+		purgeCtx, purgeCancel := context.WithCancel(context.Background())
+
+		g.Add(func() error {
+			return runPurge(purgeCtx, accountService, viper.GetDuration("purge-interval"), logger)
+		}, func(err error) {
+			purgeCancel()
+		})
+	}
+
+	// Changes made by other instances and authctl are evicted from the cache as Postgres notifies about them.
+	if credCache != nil && pgClient != nil {
+		listener := pg.NewCredentialListener(pgClient, func(c pg.CredentialChange) {
+			credCache.Evict(c.TenantID, c.ID)
+		}, credCache.Flush)
+		listenCtx, listenCancel := context.WithCancel(context.Background())
+
+		g.Add(func() error {
+			return listener.Run(logger.WithContext(listenCtx))
 		}, func(err error) {
+			listenCancel()
+		})
+	}
 
+	// Added last, so it is stopped after the API server and appends everything queued by requests.
+	if auditLog != nil {
+		auditCtx, auditCancel := context.WithCancel(context.Background())
+
+		g.Add(func() error {
+			return auditLog.Run(logger.WithContext(auditCtx))
+		}, func(err error) {
+			auditCancel()
 		})
 	}
 
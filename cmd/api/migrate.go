@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kl09/auth-go/internal/pg"
+)
+
+const migrateUsage = "usage: migrate up|down [n]|status"
+
+// runMigrate executes the migrate subcommand.
+func runMigrate(ctx context.Context, m *pg.Migrator, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up(ctx)
+		for _, mig := range applied {
+			fmt.Fprintf(out, "applied %04d_%s\n", mig.Version, mig.Name)
+		}
+
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(out, "no pending migrations")
+		}
+
+		return err
+	case "down":
+		n := 1
+
+		if len(args) > 1 {
+			var err error
+
+			n, err = strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("bad number of migrations %q: %s", args[1], migrateUsage)
+			}
+		}
+
+		rolledBack, err := m.Down(ctx, n)
+		for _, mig := range rolledBack {
+			fmt.Fprintf(out, "rolled back %04d_%s\n", mig.Version, mig.Name)
+		}
+
+		return err
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.Applied {
+				appliedAt = s.AppliedAt.UTC().Format(time.RFC3339)
+			}
+
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q: %s", args[0], migrateUsage)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	auth "github.com/kl09/auth-go"
+)
+
+// runPurge purges deleted credentials every interval until ctx is done.
+func runPurge(ctx context.Context, s auth.AccountService, interval time.Duration, logger zerolog.Logger) error {
+	ctx = logger.WithContext(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			purged, err := s.Purge(ctx)
+			if err != nil {
+				logger.Error().Err(err).Msg("purge failed")
+				continue
+			}
+
+			if purged > 0 {
+				logger.Info().Int("purged", purged).Msg("deleted credentials were purged")
+			}
+		}
+	}
+}
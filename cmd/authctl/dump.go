@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	auth "github.com/kl09/auth-go"
+)
+
+// dumpBatchSize is the number of credentials read from the storage at once.
+const dumpBatchSize = 1000
+
+// credentialRecord is a line of a dump, organizations aren't dumped as they may not exist where it is restored.
+type credentialRecord struct {
+	ID                       int        `json:"id"`
+	Email                    string     `json:"email"`
+	EmailTmp                 string     `json:"email_tmp,omitempty"`
+	EmailVerified            bool       `json:"email_verified"`
+	Password                 string     `json:"password"`
+	Token                    string     `json:"token"`
+	TokenExpiresAt           *time.Time `json:"token_expires_at,omitempty"`
+	VerificationCode         string     `json:"verification_code,omitempty"`
+	VerificationCodeAttempts uint8      `json:"verification_code_attempts,omitempty"`
+	Status                   string     `json:"status"`
+	StatusReason             string     `json:"status_reason,omitempty"`
+	StatusUntil              *time.Time `json:"status_until,omitempty"`
+	CreatedAt                time.Time  `json:"created_at"`
+	UpdatedAt                time.Time  `json:"updated_at"`
+}
+
+func credToRecord(c auth.Credential) credentialRecord {
+	return credentialRecord{
+		ID:                       c.ID,
+		Email:                    c.Email,
+		EmailTmp:                 c.EmailTmp,
+		EmailVerified:            c.EmailVerified,
+		Password:                 c.Password,
+		Token:                    c.Token,
+		TokenExpiresAt:           timeOrNil(c.TokenExpiresAt),
+		VerificationCode:         c.VerificationCode,
+		VerificationCodeAttempts: c.VerificationCodeAttempts,
+		Status:                   c.Status,
+		StatusReason:             c.StatusReason,
+		StatusUntil:              timeOrNil(c.StatusUntil),
+		CreatedAt:                c.CreatedAt,
+		UpdatedAt:                c.UpdatedAt,
+	}
+}
+
+func recordToCred(r credentialRecord) auth.Credential {
+	c := auth.Credential{
+		Email:                    r.Email,
+		EmailTmp:                 r.EmailTmp,
+		EmailVerified:            r.EmailVerified,
+		Password:                 r.Password,
+		Token:                    r.Token,
+		VerificationCode:         r.VerificationCode,
+		VerificationCodeAttempts: r.VerificationCodeAttempts,
+		Status:                   r.Status,
+		StatusReason:             r.StatusReason,
+		CreatedAt:                r.CreatedAt,
+		UpdatedAt:                r.UpdatedAt,
+	}
+
+	if r.TokenExpiresAt != nil {
+		c.TokenExpiresAt = *r.TokenExpiresAt
+	}
+
+	if r.StatusUntil != nil {
+		c.StatusUntil = *r.StatusUntil
+	}
+
+	return c
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
+
+// dump writes all credentials of the tenant as JSONL ordered by id.
+func dump(ctx context.Context, r auth.CredentialRepository, out io.Writer) error {
+	w := bufio.NewWriter(out)
+	enc := json.NewEncoder(w)
+
+	for offset := 0; ; offset += dumpBatchSize {
+		creds, err := r.Search(ctx, auth.CredentialFilter{Limit: dumpBatchSize, Offset: offset})
+		if err != nil {
+			return err
+		}
+
+		for _, c := range creds {
+			if err = enc.Encode(credToRecord(c)); err != nil {
+				return err
+			}
+		}
+
+		if len(creds) < dumpBatchSize {
+			return w.Flush()
+		}
+	}
+}
+
+// restore creates credentials from JSONL, a credential with the same email is updated keeping its id.
+// It stops on the first bad line reporting the counts of the restored credentials.
+func restore(
+	ctx context.Context,
+	r auth.CredentialRepository,
+	nowFn func() time.Time,
+	in io.Reader,
+	out io.Writer,
+) error {
+	var created, updated int
+
+	defer func() {
+		fmt.Fprintf(out, "created %d, updated %d credentials\n", created, updated)
+	}()
+
+	s := bufio.NewScanner(in)
+	s.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for line := 1; s.Scan(); line++ {
+		if strings.TrimSpace(s.Text()) == "" {
+			continue
+		}
+
+		var rec credentialRecord
+
+		err := json.Unmarshal(s.Bytes(), &rec)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if rec.Email == "" || rec.Password == "" || rec.Token == "" {
+			return fmt.Errorf("line %d: email, password and token are required", line)
+		}
+
+		cred := recordToCred(rec)
+		if cred.CreatedAt.IsZero() {
+			cred.CreatedAt = nowFn()
+		}
+
+		if cred.UpdatedAt.IsZero() {
+			cred.UpdatedAt = nowFn()
+		}
+
+		existing, err := r.ByEmail(ctx, cred.Email)
+
+		switch {
+		case err == nil:
+			cred.ID = existing.ID
+			cred.ActiveOrganizationID = existing.ActiveOrganizationID
+
+			if err = r.Update(ctx, &cred); err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+
+			updated++
+		case auth.ErrorCode(err) == auth.ErrCredNotFound:
+			if err = r.Create(ctx, &cred); err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+
+			created++
+		default:
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+
+	return s.Err()
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/mock"
+)
+
+var now = time.Date(2020, time.April, 15, 10, 11, 12, 0, time.UTC)
+
+func TestDump(t *testing.T) {
+	creds := make([]auth.Credential, dumpBatchSize+1)
+	for i := range creds {
+		creds[i] = auth.Credential{ID: i + 1, Email: "user@example.org", Status: auth.StatusActive, CreatedAt: now, UpdatedAt: now}
+	}
+
+	creds[0] = auth.Credential{
+		ID:             1,
+		Email:          "example@example.org",
+		Password:       "$argon2id$hash",
+		Token:          "token",
+		TokenExpiresAt: now.Add(time.Hour),
+		Status:         auth.StatusSuspended,
+		StatusUntil:    now.Add(time.Hour),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	r := &mock.CredentialRepositoryMock{
+		SearchFunc: func(ctx context.Context, f auth.CredentialFilter) ([]auth.Credential, error) {
+			end := f.Offset + f.Limit
+			if end > len(creds) {
+				end = len(creds)
+			}
+
+			return creds[f.Offset:end], nil
+		},
+	}
+
+	var out bytes.Buffer
+
+	err := dump(context.Background(), r, &out)
+	require.Nil(t, err)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, len(creds))
+	require.Equal(t, `{"id":1,"email":"example@example.org","email_verified":false,"password":"$argon2id$hash","token":"token",`+
+		`"token_expires_at":"2020-04-15T11:11:12Z","status":"suspended","status_until":"2020-04-15T11:11:12Z",`+
+		`"created_at":"2020-04-15T10:11:12Z","updated_at":"2020-04-15T10:11:12Z"}`, lines[0])
+	require.Len(t, r.SearchCalls(), 2)
+}
+
+func TestRestore(t *testing.T) {
+	in := `{"id":7,"email":"existing@example.org","password":"$argon2id$new","token":"token1","status":"active"}
+
+{"id":8,"email":"new@example.org","password":"$argon2id$hash","token":"token2","status":"disabled","status_reason":"fraud",` +
+		`"created_at":"2020-04-15T10:11:12Z","updated_at":"2020-04-15T10:11:12Z"}
+`
+
+	r := &mock.CredentialRepositoryMock{
+		ByEmailFunc: func(ctx context.Context, email string) (auth.Credential, error) {
+			if email == "existing@example.org" {
+				return auth.Credential{ID: 3, Email: email, ActiveOrganizationID: 5}, nil
+			}
+
+			return auth.Credential{}, auth.NewError(auth.ErrCredNotFound, "Credential not found")
+		},
+		UpdateFunc: func(ctx context.Context, c *auth.Credential) error {
+			return nil
+		},
+		CreateFunc: func(ctx context.Context, c *auth.Credential) error {
+			c.ID = 4
+			return nil
+		},
+	}
+
+	var out bytes.Buffer
+
+	err := restore(context.Background(), r, func() time.Time { return now }, strings.NewReader(in), &out)
+	require.Nil(t, err)
+	require.Equal(t, "created 1, updated 1 credentials\n", out.String())
+
+	require.Len(t, r.UpdateCalls(), 1)
+	require.Equal(t, auth.Credential{
+		ID:                   3,
+		Email:                "existing@example.org",
+		Password:             "$argon2id$new",
+		Token:                "token1",
+		Status:               auth.StatusActive,
+		ActiveOrganizationID: 5,
+		CreatedAt:            now,
+		UpdatedAt:            now,
+	}, *r.UpdateCalls()[0].C)
+
+	require.Len(t, r.CreateCalls(), 1)
+	require.Equal(t, auth.Credential{
+		ID:           4,
+		Email:        "new@example.org",
+		Password:     "$argon2id$hash",
+		Token:        "token2",
+		Status:       auth.StatusDisabled,
+		StatusReason: "fraud",
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}, *r.CreateCalls()[0].C)
+}
+
+func TestRestore_BadLine(t *testing.T) {
+	r := &mock.CredentialRepositoryMock{}
+
+	var out bytes.Buffer
+
+	err := restore(context.Background(), r, time.Now, strings.NewReader(`{"email":"a@example.org"}`), &out)
+	require.EqualError(t, err, "line 1: email, password and token are required")
+	require.Equal(t, "created 0, updated 0 credentials\n", out.String())
+}
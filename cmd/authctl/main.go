@@ -0,0 +1,208 @@
+// Command authctl runs operational tasks on credentials directly against Postgres.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/api"
+	"github.com/kl09/auth-go/internal/generator"
+	"github.com/kl09/auth-go/internal/logging"
+	"github.com/kl09/auth-go/internal/password"
+	"github.com/kl09/auth-go/internal/pg"
+)
+
+const usage = `usage: authctl [flags] command
+commands:
+  dump [file]                 dump credentials to JSONL, stdout if no file
+  restore [file]              restore credentials from JSONL updating existing ones by email, stdin if no file
+  create-user email           create a credential, the password is read from stdin
+  reset-password email        set a new password read from stdin and revoke the token
+  revoke email                revoke the token`
+
+func main() {
+	logger := zerolog.New(logging.NewRedactWriter(os.Stderr)).With().Timestamp().Logger()
+
+	fs := pflag.NewFlagSet(os.Args[0], pflag.ContinueOnError)
+	{
+		fs.String(
+			"pg.conn-string",
+			"user=auth password=auth host=localhost port=5432 dbname=auth_test connect_timeout=3 sslmode=disable",
+			"Postgresql connection string",
+		)
+		fs.Duration("pg.statement-timeout", 30*time.Second, "Default timeout of a query to Postgres.")
+
+		fs.String("tenant", auth.DefaultTenantID, "Tenant of the credentials.")
+
+		fs.String("password.algorithm", password.Argon2id, "Password hashing algorithm: argon2id, bcrypt or scrypt.")
+		fs.Int("password.cost", 0, "Cost of password hashing, the default of the algorithm if 0.")
+		fs.Uint32("password.argon2-memory", 0, "Memory of argon2id in KiB, the default if 0.")
+		fs.String("password.pepper", "", "Secret mixed into password hashes, must be the same as the one of the API.")
+
+		fs.String("log-lvl", "warn", "Log level.")
+	}
+
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, usage)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return
+		}
+
+		os.Exit(2)
+	}
+
+	if err := viper.BindPFlags(fs); err != nil {
+		logger.Fatal().Err(err).Msg("failed bind pflags")
+	}
+
+	logLvl, err := zerolog.ParseLevel(viper.GetString("log-lvl"))
+	if err != nil {
+		logger.Fatal().Err(err).Msg("couldn't parse log lvl")
+	}
+
+	logger = logger.Level(logLvl)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	hasher, err := password.New(
+		password.WithAlgorithm(viper.GetString("password.algorithm")),
+		password.WithCost(viper.GetInt("password.cost")),
+		password.WithMemory(viper.GetUint32("password.argon2-memory")),
+		password.WithPepper([]byte(viper.GetString("password.pepper"))),
+	)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("password hashing setup failed")
+	}
+
+	pgClient := pg.NewClient(
+		pg.WithLogger(logger),
+		pg.WithMaxConnections(2),
+		pg.WithStatementTimeout(viper.GetDuration("pg.statement-timeout")),
+	)
+	if err = pgClient.Open(viper.GetString("pg.conn-string")); err != nil {
+		logger.Fatal().Err(err).Msg("db connection failed")
+	}
+
+	err = run(context.Background(), pgClient, hasher, viper.GetString("tenant"), fs.Args(), logger)
+
+	if closeErr := pgClient.Close(); closeErr != nil {
+		logger.Error().Err(closeErr).Msg("db close failed")
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "authctl:", err)
+		os.Exit(1)
+	}
+}
+
+// run executes the command with the tenant from the storage.
+func run(
+	ctx context.Context,
+	c *pg.Client,
+	hasher api.Hasher,
+	tenantID string,
+	args []string,
+	logger zerolog.Logger,
+) error {
+	tenant, err := pg.NewTenantRepository(c).ByID(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	ctx = logger.WithContext(ctx)
+	ctx = api.ContextWithActor(auth.ContextWithTenant(ctx, tenant), auth.ActorAdmin)
+
+	credRepository := pg.NewCredentialRepository(c)
+	auditLog := pg.NewAuditLog(c)
+
+	nowFn := func() time.Time {
+		return time.Now().UTC()
+	}
+
+	credService := api.NewCredentialService(
+		credRepository,
+		nowFn,
+		generator.GenerateRandomString,
+		api.WithAuditLog(auditLog),
+		api.WithHasher(hasher),
+	)
+	adminService := api.NewAdminService(
+		credRepository,
+		nil,
+		nil,
+		auditLog,
+		nowFn,
+		generator.GenerateRandomString,
+		hasher,
+	)
+
+	cmd, args := args[0], args[1:]
+
+	switch cmd {
+	case "dump":
+		return withOutput(args, func(f *os.File) error {
+			return dump(ctx, credRepository, f)
+		})
+	case "restore":
+		return withInput(args, func(f *os.File) error {
+			return restore(ctx, credRepository, nowFn, f, os.Stdout)
+		})
+	case "create-user":
+		return createUser(ctx, credService, args, os.Stdin, os.Stdout)
+	case "reset-password":
+		return resetPassword(ctx, credRepository, adminService, args, os.Stdin, os.Stdout)
+	case "revoke":
+		return revoke(ctx, credRepository, adminService, args, os.Stdout)
+	default:
+		return fmt.Errorf("unknown command %q\n%s", cmd, usage)
+	}
+}
+
+// withOutput runs fn with the file of the first argument or stdout.
+func withOutput(args []string, fn func(f *os.File) error) error {
+	if len(args) == 0 {
+		return fn(os.Stdout)
+	}
+
+	f, err := os.Create(args[0])
+	if err != nil {
+		return err
+	}
+
+	err = fn(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// withInput runs fn with the file of the first argument or stdin.
+func withInput(args []string, fn func(f *os.File) error) error {
+	if len(args) == 0 {
+		return fn(os.Stdin)
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return fn(f)
+}
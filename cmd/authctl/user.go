@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	auth "github.com/kl09/auth-go"
+)
+
+// createUser registers a credential with the password from in, so it doesn't get into the shell history.
+func createUser(ctx context.Context, s auth.CredentialService, args []string, in io.Reader, out io.Writer) error {
+	if len(args) != 1 {
+		return errors.New("usage: create-user email")
+	}
+
+	pwd, err := readPassword(in)
+	if err != nil {
+		return err
+	}
+
+	cred := auth.Credential{
+		Email:    args[0],
+		Password: pwd,
+	}
+
+	err = s.Register(ctx, &cred)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "created credential %d\n", cred.ID)
+
+	return nil
+}
+
+// resetPassword sets the password from in and revokes the token of the credential.
+func resetPassword(
+	ctx context.Context,
+	r auth.CredentialRepository,
+	s auth.AdminService,
+	args []string,
+	in io.Reader,
+	out io.Writer,
+) error {
+	if len(args) != 1 {
+		return errors.New("usage: reset-password email")
+	}
+
+	cred, err := r.ByEmail(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	pwd, err := readPassword(in)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.ResetPassword(ctx, cred.ID, pwd)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "password of credential %d was reset\n", cred.ID)
+
+	return nil
+}
+
+// revoke replaces the token of the credential.
+func revoke(ctx context.Context, r auth.CredentialRepository, s auth.AdminService, args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return errors.New("usage: revoke email")
+	}
+
+	cred, err := r.ByEmail(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	_, err = s.RevokeSessions(ctx, cred.ID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "token of credential %d was revoked\n", cred.ID)
+
+	return nil
+}
+
+// readPassword reads the password from the first line of in.
+func readPassword(in io.Reader) (string, error) {
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	pwd := strings.TrimRight(line, "\r\n")
+	if pwd == "" {
+		return "", errors.New("no password on stdin")
+	}
+
+	return pwd, nil
+}
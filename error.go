@@ -14,6 +14,34 @@ const (
 	ErrAuth = "auth_failed"
 	// ErrEmailExists is returned when email already exists.
 	ErrEmailExists = "email_already_exists"
+	// ErrCredDisabled is returned when credential is disabled.
+	ErrCredDisabled = "credential_disabled"
+	// ErrCredSuspended is returned when credential is suspended.
+	ErrCredSuspended = "credential_suspended"
+	// ErrCredPendingDeletion is returned when credential is going to be deleted.
+	ErrCredPendingDeletion = "credential_pending_deletion"
+	// ErrRoleNotFound is returned when role not found.
+	ErrRoleNotFound = "role_not_found"
+	// ErrRoleExists is returned when role already exists.
+	ErrRoleExists = "role_already_exists"
+	// ErrTenantNotFound is returned when tenant not found.
+	ErrTenantNotFound = "tenant_not_found"
+	// ErrOrgNotFound is returned when organization not found or the credential isn't its member.
+	ErrOrgNotFound = "organization_not_found"
+	// ErrInvitationInvalid is returned when invitation token is invalid, expired or already accepted.
+	ErrInvitationInvalid = "invitation_invalid"
+	// ErrAPIKeyNotFound is returned when API key not found.
+	ErrAPIKeyNotFound = "api_key_not_found"
+	// ErrPermissionDenied is returned when the credential isn't allowed to do the operation.
+	ErrPermissionDenied = "permission_denied"
+	// ErrTokenExpired is returned when token is expired.
+	ErrTokenExpired = "token_expired"
+	// ErrPasswordPolicy is returned when password doesn't satisfy the password policy.
+	ErrPasswordPolicy = "password_policy_violation"
+	// ErrValidation is returned when input is invalid.
+	ErrValidation = "validation_failed"
+	// ErrTimeout is returned when an operation didn't finish in time.
+	ErrTimeout = "timeout"
 )
 
 // Error represents an error within the context of Quoter service.
@@ -0,0 +1,33 @@
+package auth
+
+// ImportRecord is a credential of another system with its password hash.
+type ImportRecord struct {
+	// Line is the line of the record in the imported file.
+	Line          int
+	Email         string
+	EmailVerified bool
+	// Algorithm is the algorithm of the hash: pbkdf2-sha256, md5, sha1, bcrypt, argon2id or scrypt.
+	Algorithm string
+	// Hash is the hex encoded digest or key, bcrypt hashes are in the modular crypt format,
+	// argon2id and scrypt ones in the PHC string format.
+	Hash string
+	Salt string
+	// Iterations is the number of iterations of PBKDF2.
+	Iterations int
+	// SaltPosition is the position of the salt in salted MD5 and SHA1: prefix or suffix.
+	SaltPosition string
+}
+
+// ImportResult is a result of an import of credentials.
+type ImportResult struct {
+	Created int
+	// Errors are errors of records which weren't imported.
+	Errors []ImportError
+}
+
+// ImportError is an error of an imported record.
+type ImportError struct {
+	Line  int
+	Email string
+	Err   error
+}
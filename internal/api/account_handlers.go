@@ -0,0 +1,102 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	auth "github.com/kl09/auth-go"
+)
+
+// sessionResponse describes the token of a credential without the token itself.
+type sessionResponse struct {
+	Type      string     `json:"type"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type exportResponse struct {
+	Credential    adminCredentialResponse `json:"credential"`
+	Sessions      []sessionResponse       `json:"sessions"`
+	APIKeys       []apiKeyResponse        `json:"api_keys"`
+	Organizations []organizationResponse  `json:"organizations"`
+	Profile       profileResponse         `json:"profile"`
+	AuditEntries  []auditEntryResponse    `json:"audit_entries"`
+	CreatedAt     time.Time               `json:"created_at"`
+}
+
+func exportToResponse(e auth.Export) exportResponse {
+	resp := exportResponse{
+		Credential:    credToAdminResponse(e.Credential),
+		Sessions:      make([]sessionResponse, 0, 1),
+		APIKeys:       make([]apiKeyResponse, 0, len(e.APIKeys)),
+		Organizations: make([]organizationResponse, 0, len(e.Memberships)),
+		Profile:       profileToResponse(e.Profile, true),
+		AuditEntries:  make([]auditEntryResponse, 0, len(e.AuditEntries)),
+		CreatedAt:     e.CreatedAt,
+	}
+
+	if e.Credential.Token != "" {
+		s := sessionResponse{Type: "token"}
+		if !e.Credential.TokenExpiresAt.IsZero() {
+			s.ExpiresAt = &e.Credential.TokenExpiresAt
+		}
+
+		resp.Sessions = append(resp.Sessions, s)
+	}
+
+	for _, k := range e.APIKeys {
+		resp.APIKeys = append(resp.APIKeys, apiKeyToResponse(k))
+	}
+
+	for _, m := range e.Memberships {
+		resp.Organizations = append(resp.Organizations, membershipToResponse(m))
+	}
+
+	for _, entry := range e.AuditEntries {
+		resp.AuditEntries = append(resp.AuditEntries, auditEntryToResponse(entry))
+	}
+
+	return resp
+}
+
+// export responds with a JSON archive of the personal data of the authenticated credential.
+func (r *Router) export(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	e, err := r.accountService.Export(ctx, credentialFromContext(ctx).ID)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="export.json"`)
+
+	return c.JSON(http.StatusOK, exportToResponse(e))
+}
+
+// deleteAccount schedules the deletion of the authenticated credential confirmed by its password.
+func (r *Router) deleteAccount(c echo.Context) error {
+	var request struct {
+		Password string `json:"password"`
+	}
+
+	err := c.Bind(&request)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	cred, err := r.accountService.RequestDeletion(ctx, credentialFromContext(ctx).ID, request.Password)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusAccepted, struct {
+		Status  string    `json:"status"`
+		PurgeAt time.Time `json:"purge_at"`
+	}{
+		Status:  cred.Status,
+		PurgeAt: cred.StatusUntil,
+	})
+}
@@ -0,0 +1,194 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	auth "github.com/kl09/auth-go"
+)
+
+const (
+	// maxExportAuditEntries limits the audit entries of an export to the newest ones.
+	maxExportAuditEntries = 10000
+	// purgeBatchSize limits the credentials purged by a single Purge.
+	purgeBatchSize = 100
+	// deletionRequestReason is the status reason of a Credential deleted by the user.
+	deletionRequestReason = "user_request"
+)
+
+// AccountService is a service for data-subject requests of users.
+type AccountService struct {
+	credentialRepository   auth.CredentialRepository
+	profileRepository      auth.ProfileRepository
+	apiKeyRepository       auth.APIKeyRepository
+	organizationRepository auth.OrganizationRepository
+	auditLog               auth.AuditLog
+	nowFn                  func() time.Time
+	hasher                 Hasher
+	// gracePeriod is a time between a deletion request and the purge.
+	gracePeriod time.Duration
+}
+
+// NewAccountService creates an AccountService.
+func NewAccountService(
+	r auth.CredentialRepository,
+	profiles auth.ProfileRepository,
+	keys auth.APIKeyRepository,
+	orgs auth.OrganizationRepository,
+	a auth.AuditLog,
+	nowFn func() time.Time,
+	hasher Hasher,
+	gracePeriod time.Duration,
+) *AccountService {
+	return &AccountService{
+		credentialRepository:   r,
+		profileRepository:      profiles,
+		apiKeyRepository:       keys,
+		organizationRepository: orgs,
+		auditLog:               a,
+		nowFn:                  nowFn,
+		hasher:                 hasher,
+		gracePeriod:            gracePeriod,
+	}
+}
+
+// Export retrieves the personal data of a Credential.
+func (s *AccountService) Export(ctx context.Context, credID int) (auth.Export, error) {
+	var (
+		e   = auth.Export{CreatedAt: s.nowFn()}
+		err error
+	)
+
+	e.Credential, err = s.credentialRepository.ByID(ctx, credID)
+	if err != nil {
+		return auth.Export{}, err
+	}
+
+	e.Profile, err = s.profileRepository.ByCredential(ctx, credID)
+	if err != nil {
+		return auth.Export{}, err
+	}
+
+	e.APIKeys, err = s.apiKeyRepository.ByCredential(ctx, credID)
+	if err != nil {
+		return auth.Export{}, err
+	}
+
+	e.Memberships, err = s.organizationRepository.ByCredential(ctx, credID)
+	if err != nil {
+		return auth.Export{}, err
+	}
+
+	e.AuditEntries, err = s.auditLog.Find(ctx, auth.AuditFilter{CredentialID: credID, Limit: maxExportAuditEntries})
+	if err != nil {
+		return auth.Export{}, err
+	}
+
+	s.audit(ctx, auth.AuditExport, credID, "")
+
+	return e, nil
+}
+
+// RequestDeletion confirms the password and blocks the Credential until the purge after the grace period,
+// an administrator can restore it by changing the status before.
+func (s *AccountService) RequestDeletion(ctx context.Context, credID int, plainPassword string) (auth.Credential, error) {
+	cred, err := s.credentialRepository.ByID(ctx, credID)
+	if err != nil {
+		return auth.Credential{}, err
+	}
+
+	if ok, _ := s.hasher.Verify(cred.Password, plainPassword); !ok {
+		s.audit(ctx, auth.AuditDeletionRequest, credID, auth.ErrAuth)
+
+		return auth.Credential{}, auth.NewError(auth.ErrAuth, "Password is wrong.")
+	}
+
+	now := s.nowFn()
+
+	cred.Status = auth.StatusPendingDeletion
+	cred.StatusReason = deletionRequestReason
+	cred.StatusUntil = now.Add(s.gracePeriod)
+	cred.UpdatedAt = now
+
+	err = s.credentialRepository.Update(ctx, &cred)
+	if err != nil {
+		return auth.Credential{}, err
+	}
+
+	s.audit(ctx, auth.AuditDeletionRequest, credID, "")
+
+	return cred, nil
+}
+
+// Purge deletes Credentials whose grace period is over with invitations addressed to their emails,
+// their audit entries are anonymized instead.
+// A failed Credential is logged and retried by the next Purge.
+func (s *AccountService) Purge(ctx context.Context) (int, error) {
+	creds, err := s.credentialRepository.PendingDeletion(ctx, s.nowFn(), purgeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx = ContextWithActor(ctx, auth.ActorSystem)
+
+	var purged int
+
+	for _, cred := range creds {
+		err = s.purge(auth.ContextWithTenant(ctx, auth.Tenant{ID: cred.TenantID}), cred)
+		if err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Int("credential_id", cred.ID).Msg("credential purge failed")
+			continue
+		}
+
+		purged++
+	}
+
+	return purged, nil
+}
+
+// purge anonymizes the audit entries and deletes the invitations before the deletion,
+// so a failed deletion is retried safely. Token and API key use of the Credential isn't recorded meanwhile,
+// see purgeable, so no entry appended in a batch later keeps it.
+func (s *AccountService) purge(ctx context.Context, cred auth.Credential) error {
+	err := s.auditLog.Anonymize(ctx, cred.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, email := range []string{cred.Email, cred.EmailTmp} {
+		if email == "" {
+			continue
+		}
+
+		if err = s.organizationRepository.DeleteInvitations(ctx, email); err != nil {
+			return err
+		}
+	}
+
+	err = s.credentialRepository.Delete(ctx, cred.ID)
+	if err != nil && auth.ErrorHas(err, auth.ErrCredNotFound) == nil {
+		return err
+	}
+
+	// The entry isn't linked to the purged credential.
+	s.audit(ctx, auth.AuditPurge, 0, "")
+
+	return nil
+}
+
+// purgeable reports that the status error is of a Credential pending deletion. Token and API key use of such
+// a Credential isn't recorded, the entry could be appended in a batch after the purge anonymized its entries.
+func purgeable(err error) bool {
+	return auth.ErrorHas(err, auth.ErrCredPendingDeletion) != nil
+}
+
+func (s *AccountService) audit(ctx context.Context, event string, credID int, reason string) {
+	appendAudit(ctx, s.auditLog, &auth.AuditEntry{
+		CredentialID: credID,
+		Event:        event,
+		Reason:       reason,
+		CreatedAt:    s.nowFn(),
+	})
+}
@@ -0,0 +1,374 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/audit"
+	"github.com/kl09/auth-go/internal/mock"
+)
+
+func TestAccountService_RequestDeletion(t *testing.T) {
+	hash, err := testHasher.Hash("66554433")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		password string
+		wantErr  error
+	}{
+		{
+			name:     "success",
+			password: "66554433",
+		},
+		{
+			name:     "error - wrong password",
+			password: "12345",
+			wantErr:  auth.NewError(auth.ErrAuth, "Password is wrong."),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			credRep := &mock.CredentialRepositoryMock{
+				ByIDFunc: func(ctx context.Context, id int) (auth.Credential, error) {
+					return auth.Credential{ID: id, Password: hash, Status: auth.StatusActive}, nil
+				},
+				UpdateFunc: func(ctx context.Context, c *auth.Credential) error {
+					return nil
+				},
+			}
+			auditLog := &mock.AuditLogMock{
+				AppendFunc: func(ctx context.Context, e *auth.AuditEntry) error {
+					return nil
+				},
+			}
+
+			s := NewAccountService(credRep, nil, nil, nil, auditLog, nowFunc, testHasher, time.Hour)
+
+			cred, err := s.RequestDeletion(context.Background(), 1, tc.password)
+
+			require.Equal(t, tc.wantErr, err)
+			require.Len(t, auditLog.AppendCalls(), 1)
+			require.Equal(t, auth.AuditDeletionRequest, auditLog.AppendCalls()[0].E.Event)
+
+			if tc.wantErr != nil {
+				require.Empty(t, credRep.UpdateCalls())
+				return
+			}
+
+			want := auth.Credential{
+				ID:           1,
+				Password:     hash,
+				Status:       auth.StatusPendingDeletion,
+				StatusReason: "user_request",
+				StatusUntil:  now.Add(time.Hour),
+				UpdatedAt:    now,
+			}
+
+			if diff := cmp.Diff(want, cred); diff != "" {
+				t.Fatal(diff)
+			}
+
+			if diff := cmp.Diff(want, *credRep.UpdateCalls()[0].C); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestAccountService_Purge(t *testing.T) {
+	credRep := &mock.CredentialRepositoryMock{
+		PendingDeletionFunc: func(ctx context.Context, before time.Time, limit int) ([]auth.Credential, error) {
+			require.Equal(t, now, before)
+
+			return []auth.Credential{
+				{ID: 1, TenantID: "shop", Email: "one@example.org", EmailTmp: "new@example.org"},
+				{ID: 2, TenantID: "shop", Email: "two@example.org"},
+				{ID: 3, Email: "three@example.org"},
+				{ID: 4, TenantID: "shop", Email: "four@example.org"},
+			}, nil
+		},
+		DeleteFunc: func(ctx context.Context, id int) error {
+			require.Equal(t, "shop", auth.TenantFromContext(ctx).ID)
+
+			if id == 2 {
+				return errors.New("connection reset")
+			}
+
+			return nil
+		},
+	}
+	auditLog := &mock.AuditLogMock{
+		AnonymizeFunc: func(ctx context.Context, credID int) error {
+			if credID == 3 {
+				return errors.New("connection reset")
+			}
+
+			return nil
+		},
+		AppendFunc: func(ctx context.Context, e *auth.AuditEntry) error {
+			return nil
+		},
+	}
+
+	orgRep := &mock.OrganizationRepositoryMock{
+		DeleteInvitationsFunc: func(ctx context.Context, email string) error {
+			require.Equal(t, "shop", auth.TenantFromContext(ctx).ID)
+
+			if email == "four@example.org" {
+				return errors.New("connection reset")
+			}
+
+			return nil
+		},
+	}
+
+	s := NewAccountService(credRep, nil, nil, orgRep, auditLog, nowFunc, testHasher, time.Hour)
+
+	purged, err := s.Purge(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, 1, purged)
+
+	// Audit entries are anonymized and invitations are deleted before the deletion,
+	// a failed credential is retried by the next purge.
+	require.Len(t, auditLog.AnonymizeCalls(), 4)
+	require.Len(t, credRep.DeleteCalls(), 2)
+
+	var emails []string
+	for _, c := range orgRep.DeleteInvitationsCalls() {
+		emails = append(emails, c.Email)
+	}
+
+	require.Equal(t, []string{"one@example.org", "new@example.org", "two@example.org", "four@example.org"}, emails)
+
+	entries := auditLog.AppendCalls()
+	require.Len(t, entries, 1)
+	require.Equal(t, auth.AuditEntry{Event: auth.AuditPurge, Actor: auth.ActorSystem, CreatedAt: now}, *entries[0].E)
+}
+
+// batchAuditLog records entries appended in batches.
+type batchAuditLog struct {
+	*mock.AuditLogMock
+
+	batched []auth.AuditEntry
+}
+
+func (l *batchAuditLog) AppendBatch(ctx context.Context, entries []*auth.AuditEntry) error {
+	for _, e := range entries {
+		l.batched = append(l.batched, *e)
+	}
+
+	return nil
+}
+
+func TestAccountService_Purge_BatchedTokenUse(t *testing.T) {
+	creds := map[string]auth.Credential{
+		"pending": {ID: 1, Status: auth.StatusPendingDeletion, StatusUntil: now.Add(-time.Hour)},
+		"active":  {ID: 2},
+	}
+
+	credRep := &mock.CredentialRepositoryMock{
+		ByTokenFunc: func(ctx context.Context, token string) (auth.Credential, error) {
+			return creds[token], nil
+		},
+		PendingDeletionFunc: func(ctx context.Context, before time.Time, limit int) ([]auth.Credential, error) {
+			return []auth.Credential{creds["pending"]}, nil
+		},
+		DeleteFunc: func(ctx context.Context, id int) error {
+			return nil
+		},
+	}
+	next := &batchAuditLog{AuditLogMock: &mock.AuditLogMock{
+		AnonymizeFunc: func(ctx context.Context, credID int) error {
+			return nil
+		},
+		AppendFunc: func(ctx context.Context, e *auth.AuditEntry) error {
+			return nil
+		},
+	}}
+	auditLog := audit.NewBatchLog(next, []string{auth.AuditTokenUse}, audit.WithFlushInterval(time.Hour))
+
+	credService := NewCredentialService(credRep, nowFunc, nil, WithAuditLog(auditLog))
+	accountService := NewAccountService(credRep, nil, nil, nil, auditLog, nowFunc, testHasher, time.Hour)
+
+	// Both token uses are queued before the purge and appended after it.
+	_, err := credService.ByToken(context.Background(), "pending")
+	require.Equal(t, auth.ErrCredPendingDeletion, auth.ErrorCode(err))
+
+	_, err = credService.ByToken(context.Background(), "active")
+	require.Nil(t, err)
+
+	purged, err := accountService.Purge(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, 1, purged)
+	require.Len(t, next.AnonymizeCalls(), 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Nil(t, auditLog.Run(ctx))
+
+	require.Equal(t, []auth.AuditEntry{{CredentialID: 2, Event: auth.AuditTokenUse, CreatedAt: now}}, next.batched)
+}
+
+func TestAccount_Handlers(t *testing.T) {
+	hash, err := testHasher.Hash("66554433")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		header     string
+		value      string
+		wantResp   string
+		wantStatus int
+	}{
+		{
+			name:   "export",
+			method: http.MethodGet,
+			path:   "/v1/me/export",
+			header: echo.HeaderAuthorization,
+			value:  "Bearer token",
+			wantResp: `{"credential":{"id":1,"email":"example@example.org","email_tmp":"","email_verified":true,"status":"active","status_reason":"","status_until":null,` +
+				`"created_at":"2020-04-15T10:11:12Z","updated_at":"2020-04-15T10:11:12Z"},` +
+				`"sessions":[{"type":"token","expires_at":null}],` +
+				`"api_keys":[{"id":1,"name":"ci","prefix":"gen","scopes":[],"expires_at":null,"last_used_at":null,"created_at":"2020-04-15T10:11:12Z"}],` +
+				`"organizations":[{"id":1,"name":"Acme","role":"owner","created_at":"2020-04-15T10:11:12Z","updated_at":"2020-04-15T10:11:12Z"}],` +
+				`"profile":{"display_name":"Jane","locale":"","timezone":"","public_metadata":{},"private_metadata":{},"updated_at":null},` +
+				`"audit_entries":[{"id":1,"credential_id":1,"event":"login_success","ip":"10.0.0.1","user_agent":"curl","reason":"","actor":"","created_at":"2020-04-15T10:11:12Z"}],` +
+				`"created_at":"2020-04-15T10:11:12Z"}` + "\n",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "delete",
+			method:     http.MethodDelete,
+			path:       "/v1/me",
+			body:       `{"password":"66554433"}`,
+			header:     echo.HeaderAuthorization,
+			value:      "Bearer token",
+			wantResp:   `{"status":"pending_deletion","purge_at":"2020-05-15T10:11:12Z"}` + "\n",
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name:       "error - delete with wrong password",
+			method:     http.MethodDelete,
+			path:       "/v1/me",
+			body:       `{"password":"12345"}`,
+			header:     echo.HeaderAuthorization,
+			value:      "Bearer token",
+			wantResp:   `{"error":{"code":"auth_failed","message":"Password is wrong."}}` + "\n",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "error - export with api key",
+			method:     http.MethodGet,
+			path:       "/v1/me/export",
+			header:     HeaderAPIKey,
+			value:      testAPIKey,
+			wantResp:   `{"error":{"code":"permission_denied","message":"Token is required."}}` + "\n",
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cred := auth.Credential{
+				ID:            1,
+				Password:      hash,
+				Token:         "token",
+				Email:         "example@example.org",
+				EmailVerified: true,
+				Status:        auth.StatusActive,
+				CreatedAt:     now,
+				UpdatedAt:     now,
+			}
+
+			credRep := &mock.CredentialRepositoryMock{
+				ByTokenFunc: func(ctx context.Context, token string) (auth.Credential, error) {
+					return cred, nil
+				},
+				ByIDFunc: func(ctx context.Context, id int) (auth.Credential, error) {
+					return cred, nil
+				},
+				UpdateFunc: func(ctx context.Context, c *auth.Credential) error {
+					return nil
+				},
+			}
+			profileRep := &mock.ProfileRepositoryMock{
+				ByCredentialFunc: func(ctx context.Context, credID int) (auth.Profile, error) {
+					return auth.Profile{CredentialID: credID, DisplayName: "Jane"}, nil
+				},
+			}
+			keyRep := &mock.APIKeyRepositoryMock{
+				ByCredentialFunc: func(ctx context.Context, credID int) ([]auth.APIKey, error) {
+					return []auth.APIKey{{ID: 1, CredentialID: credID, Name: "ci", Prefix: "gen", CreatedAt: now}}, nil
+				},
+				ByPrefixFunc: func(ctx context.Context, prefix string) (auth.APIKey, error) {
+					return auth.APIKey{ID: 1, CredentialID: 1, Prefix: "gen", Hash: hashAPIKey(testAPIKey), LastUsedAt: now}, nil
+				},
+			}
+			orgRep := &mock.OrganizationRepositoryMock{
+				ByCredentialFunc: func(ctx context.Context, credID int) ([]auth.Membership, error) {
+					return []auth.Membership{{
+						Organization: auth.Organization{ID: 1, Name: "Acme", CreatedAt: now, UpdatedAt: now},
+						CredentialID: credID,
+						Role:         auth.OrgRoleOwner,
+						CreatedAt:    now,
+					}}, nil
+				},
+			}
+			auditLog := &mock.AuditLogMock{
+				AppendFunc: func(ctx context.Context, e *auth.AuditEntry) error {
+					return nil
+				},
+				FindFunc: func(ctx context.Context, f auth.AuditFilter) ([]auth.AuditEntry, error) {
+					return []auth.AuditEntry{{
+						ID:           1,
+						CredentialID: f.CredentialID,
+						Event:        auth.AuditLoginSuccess,
+						IP:           "10.0.0.1",
+						UserAgent:    "curl",
+						CreatedAt:    now,
+					}}, nil
+				},
+			}
+
+			h := NewRouter(
+				NewCredentialService(credRep, nowFunc, nil),
+				WithAPIKeys(NewAPIKeyService(keyRep, credRep, auditLog, nowFunc, nil)),
+				WithAccounts(NewAccountService(credRep, profileRep, keyRep, orgRep, auditLog, nowFunc, testHasher, auth.DefaultDeletionGracePeriod)),
+			).Handler().Server.Handler
+
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(tc.header, tc.value)
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if diff := cmp.Diff(tc.wantStatus, rec.Code); diff != "" {
+				t.Error(diff)
+			}
+
+			if diff := cmp.Diff(tc.wantResp, rec.Body.String()); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,663 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	auth "github.com/kl09/auth-go"
+)
+
+const (
+	// maxImportSize limits the body of an import.
+	maxImportSize = 32 << 20
+	// maxImportRecords limits the records of an import, bigger files are imported by the import command.
+	maxImportRecords = 10000
+)
+
+type auditEntryResponse struct {
+	ID           int       `json:"id"`
+	CredentialID int       `json:"credential_id"`
+	Event        string    `json:"event"`
+	IP           string    `json:"ip"`
+	UserAgent    string    `json:"user_agent"`
+	Reason       string    `json:"reason"`
+	Actor        string    `json:"actor"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func auditEntryToResponse(e auth.AuditEntry) auditEntryResponse {
+	return auditEntryResponse{
+		ID:           e.ID,
+		CredentialID: e.CredentialID,
+		Event:        e.Event,
+		IP:           e.IP,
+		UserAgent:    e.UserAgent,
+		Reason:       e.Reason,
+		Actor:        e.Actor,
+		CreatedAt:    e.CreatedAt,
+	}
+}
+
+// adminCredentialResponse is a Credential without secrets.
+type adminCredentialResponse struct {
+	ID            int        `json:"id"`
+	Email         string     `json:"email"`
+	EmailTmp      string     `json:"email_tmp"`
+	EmailVerified bool       `json:"email_verified"`
+	Status        string     `json:"status"`
+	StatusReason  string     `json:"status_reason"`
+	StatusUntil   *time.Time `json:"status_until"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+type importResponse struct {
+	Created int                   `json:"created"`
+	Errors  []importErrorResponse `json:"errors"`
+}
+
+type importErrorResponse struct {
+	Line  int         `json:"line"`
+	Email string      `json:"email"`
+	Err   *auth.Error `json:"error"`
+}
+
+type roleResponse struct {
+	ID          int                  `json:"id"`
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Permissions []permissionResponse `json:"permissions"`
+	CreatedAt   time.Time            `json:"created_at"`
+}
+
+type rolesResponse struct {
+	Roles []roleResponse `json:"roles"`
+}
+
+func rolesToResponse(roles []auth.Role) rolesResponse {
+	resp := rolesResponse{
+		Roles: make([]roleResponse, 0, len(roles)),
+	}
+
+	for _, r := range roles {
+		resp.Roles = append(resp.Roles, roleResponse{
+			ID:          r.ID,
+			Name:        r.Name,
+			Description: r.Description,
+			Permissions: permissionsToResponse(r.Permissions),
+			CreatedAt:   r.CreatedAt,
+		})
+	}
+
+	return resp
+}
+
+type tenantRequest struct {
+	Name     string                 `json:"name"`
+	Hosts    []string               `json:"hosts"`
+	Settings tenantSettingsResponse `json:"settings"`
+}
+
+type tenantSettingsResponse struct {
+	PasswordPolicy       passwordPolicyResponse          `json:"password_policy"`
+	TokenTTLSeconds      int64                           `json:"token_ttl_seconds"`
+	InvitationTTLSeconds int64                           `json:"invitation_ttl_seconds"`
+	MailTemplates        map[string]mailTemplateResponse `json:"mail_templates"`
+	ProfileSchema        map[string]string               `json:"profile_schema"`
+}
+
+type passwordPolicyResponse struct {
+	MinLength     int  `json:"min_length"`
+	MaxLength     int  `json:"max_length"`
+	RequireUpper  bool `json:"require_upper"`
+	RequireLower  bool `json:"require_lower"`
+	RequireDigit  bool `json:"require_digit"`
+	RequireSymbol bool `json:"require_symbol"`
+}
+
+type mailTemplateResponse struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+type tenantResponse struct {
+	ID string `json:"id"`
+	tenantRequest
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func tenantToResponse(t auth.Tenant) tenantResponse {
+	resp := tenantResponse{
+		ID: t.ID,
+		tenantRequest: tenantRequest{
+			Name:  t.Name,
+			Hosts: t.Hosts,
+			Settings: tenantSettingsResponse{
+				PasswordPolicy:       passwordPolicyResponse(t.Settings.PasswordPolicy),
+				TokenTTLSeconds:      int64(t.Settings.TokenTTL / time.Second),
+				InvitationTTLSeconds: int64(t.Settings.InvitationTTL / time.Second),
+				MailTemplates:        make(map[string]mailTemplateResponse, len(t.Settings.MailTemplates)),
+				ProfileSchema:        make(map[string]string, len(t.Settings.ProfileSchema)),
+			},
+		},
+		CreatedAt: t.CreatedAt,
+		UpdatedAt: t.UpdatedAt,
+	}
+
+	if resp.Hosts == nil {
+		resp.Hosts = []string{}
+	}
+
+	for name, tmpl := range t.Settings.MailTemplates {
+		resp.Settings.MailTemplates[name] = mailTemplateResponse(tmpl)
+	}
+
+	for k, typ := range t.Settings.ProfileSchema {
+		resp.Settings.ProfileSchema[k] = typ
+	}
+
+	return resp
+}
+
+func credToAdminResponse(cred auth.Credential) adminCredentialResponse {
+	resp := adminCredentialResponse{
+		ID:            cred.ID,
+		Email:         cred.Email,
+		EmailTmp:      cred.EmailTmp,
+		EmailVerified: cred.EmailVerified,
+		Status:        cred.Status,
+		StatusReason:  cred.StatusReason,
+		CreatedAt:     cred.CreatedAt,
+		UpdatedAt:     cred.UpdatedAt,
+	}
+
+	if !cred.StatusUntil.IsZero() {
+		resp.StatusUntil = &cred.StatusUntil
+	}
+
+	return resp
+}
+
+// adminAuth allows only requests with the admin token or a token of a credential
+// having the admin permission in the Authorization header.
+func adminAuth(token string, credService auth.CredentialService) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			got := strings.TrimPrefix(req.Header.Get(echo.HeaderAuthorization), "Bearer ")
+			if got == "" {
+				return echo.NewHTTPError(http.StatusUnauthorized)
+			}
+
+			actor := auth.ActorAdmin
+			if token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				credID, err := adminCredential(req.Context(), credService, got)
+				if err != nil {
+					return err
+				}
+
+				actor = auth.ActorCredential(credID)
+			}
+
+			c.SetRequest(req.WithContext(ContextWithActor(req.Context(), actor)))
+
+			return next(c)
+		}
+	}
+}
+
+// superAdminOnly allows only requests with the admin token, e.g. to manage all tenants.
+func superAdminOnly(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if actor, _ := c.Request().Context().Value(actorKey{}).(string); actor != auth.ActorAdmin {
+			return echo.NewHTTPError(http.StatusForbidden)
+		}
+
+		return next(c)
+	}
+}
+
+// adminCredential returns the id of the credential with the token if it has the admin permission.
+func adminCredential(ctx context.Context, credService auth.CredentialService, token string) (int, error) {
+	cred, err := credService.ByToken(ctx, token)
+	if err != nil {
+		if auth.ErrorHas(err, auth.ErrCredNotFound) != nil {
+			return 0, echo.NewHTTPError(http.StatusUnauthorized)
+		}
+
+		return 0, err
+	}
+
+	roles, err := credService.Roles(ctx, cred.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	if !auth.Allowed(auth.EffectivePermissions(roles), adminAction, adminResource) {
+		return 0, echo.NewHTTPError(http.StatusForbidden)
+	}
+
+	return cred.ID, nil
+}
+
+// auditLog retrieves security events filtered by credential_id, from, to and limit query params.
+func (r *Router) auditLog(c echo.Context) error {
+	var (
+		f   auth.AuditFilter
+		err error
+	)
+
+	if v := c.QueryParam("credential_id"); v != "" {
+		if f.CredentialID, err = strconv.Atoi(v); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Bad credential_id.")
+		}
+	}
+
+	if v := c.QueryParam("from"); v != "" {
+		if f.From, err = time.Parse(time.RFC3339, v); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Bad from, RFC3339 expected.")
+		}
+	}
+
+	if v := c.QueryParam("to"); v != "" {
+		if f.To, err = time.Parse(time.RFC3339, v); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Bad to, RFC3339 expected.")
+		}
+	}
+
+	if v := c.QueryParam("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Bad limit.")
+		}
+	}
+
+	entries, err := r.adminService.AuditLog(c.Request().Context(), f)
+	if err != nil {
+		return err
+	}
+
+	resp := struct {
+		Entries []auditEntryResponse `json:"entries"`
+	}{
+		Entries: make([]auditEntryResponse, 0, len(entries)),
+	}
+
+	for _, e := range entries {
+		resp.Entries = append(resp.Entries, auditEntryToResponse(e))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// searchCredentials searches credentials filtered by email, limit and offset query params.
+func (r *Router) searchCredentials(c echo.Context) error {
+	var (
+		f   auth.CredentialFilter
+		err error
+	)
+
+	f.Email = c.QueryParam("email")
+
+	if v := c.QueryParam("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Bad limit.")
+		}
+	}
+
+	if v := c.QueryParam("offset"); v != "" {
+		if f.Offset, err = strconv.Atoi(v); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Bad offset.")
+		}
+	}
+
+	creds, err := r.adminService.Credentials(c.Request().Context(), f)
+	if err != nil {
+		return err
+	}
+
+	resp := struct {
+		Credentials []adminCredentialResponse `json:"credentials"`
+	}{
+		Credentials: make([]adminCredentialResponse, 0, len(creds)),
+	}
+
+	for _, cred := range creds {
+		resp.Credentials = append(resp.Credentials, credToAdminResponse(cred))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// credential retrieves a credential by id.
+func (r *Router) credential(c echo.Context) error {
+	return r.adminAction(c, r.adminService.Credential)
+}
+
+// verifyEmail marks the email of a credential as verified.
+func (r *Router) verifyEmail(c echo.Context) error {
+	return r.adminAction(c, r.adminService.VerifyEmail)
+}
+
+// resetPassword sets a new password of a credential.
+func (r *Router) resetPassword(c echo.Context) error {
+	var request struct {
+		Password string `json:"password"`
+	}
+
+	err := c.Bind(&request)
+	if err != nil {
+		return err
+	}
+
+	if request.Password == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Password is required.")
+	}
+
+	return r.adminAction(c, func(ctx context.Context, id int) (auth.Credential, error) {
+		return r.adminService.ResetPassword(ctx, id, request.Password)
+	})
+}
+
+// setStatus changes the status of a credential.
+func (r *Router) setStatus(c echo.Context) error {
+	var request struct {
+		Status string    `json:"status"`
+		Reason string    `json:"reason"`
+		Until  time.Time `json:"until"`
+	}
+
+	err := c.Bind(&request)
+	if err != nil {
+		return err
+	}
+
+	return r.adminAction(c, func(ctx context.Context, id int) (auth.Credential, error) {
+		return r.adminService.SetStatus(ctx, id, request.Status, request.Reason, request.Until)
+	})
+}
+
+// disableCredential disables a credential.
+func (r *Router) disableCredential(c echo.Context) error {
+	return r.adminAction(c, func(ctx context.Context, id int) (auth.Credential, error) {
+		return r.adminService.SetStatus(ctx, id, auth.StatusDisabled, "", time.Time{})
+	})
+}
+
+// enableCredential enables a credential.
+func (r *Router) enableCredential(c echo.Context) error {
+	return r.adminAction(c, func(ctx context.Context, id int) (auth.Credential, error) {
+		return r.adminService.SetStatus(ctx, id, auth.StatusActive, "", time.Time{})
+	})
+}
+
+// revokeSessions revokes the token of a credential.
+func (r *Router) revokeSessions(c echo.Context) error {
+	return r.adminAction(c, r.adminService.RevokeSessions)
+}
+
+// deleteCredential deletes a credential.
+func (r *Router) deleteCredential(c echo.Context) error {
+	id, err := credentialIDParam(c)
+	if err != nil {
+		return err
+	}
+
+	err = r.adminService.Delete(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// roles retrieves all roles.
+func (r *Router) roles(c echo.Context) error {
+	roles, err := r.adminService.Roles(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, rolesToResponse(roles))
+}
+
+// createRole creates a new role with permissions.
+func (r *Router) createRole(c echo.Context) error {
+	var request struct {
+		Name        string               `json:"name"`
+		Description string               `json:"description"`
+		Permissions []permissionResponse `json:"permissions"`
+	}
+
+	err := c.Bind(&request)
+	if err != nil {
+		return err
+	}
+
+	role := auth.Role{
+		Name:        request.Name,
+		Description: request.Description,
+		Permissions: make([]auth.Permission, 0, len(request.Permissions)),
+	}
+
+	for _, p := range request.Permissions {
+		role.Permissions = append(role.Permissions, auth.Permission{
+			Action:   p.Action,
+			Resource: p.Resource,
+		})
+	}
+
+	err = r.adminService.CreateRole(c.Request().Context(), &role)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, roleResponse{
+		ID:          role.ID,
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: permissionsToResponse(role.Permissions),
+		CreatedAt:   role.CreatedAt,
+	})
+}
+
+// deleteRole deletes a role with all its assignments.
+func (r *Router) deleteRole(c echo.Context) error {
+	err := r.adminService.DeleteRole(c.Request().Context(), c.Param("name"))
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// credentialRoles retrieves roles assigned to a credential.
+func (r *Router) credentialRoles(c echo.Context) error {
+	id, err := credentialIDParam(c)
+	if err != nil {
+		return err
+	}
+
+	roles, err := r.adminService.CredentialRoles(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, rolesToResponse(roles))
+}
+
+// assignRole assigns a role to a credential.
+func (r *Router) assignRole(c echo.Context) error {
+	id, err := credentialIDParam(c)
+	if err != nil {
+		return err
+	}
+
+	err = r.adminService.AssignRole(c.Request().Context(), id, c.Param("name"))
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// unassignRole removes a role from a credential.
+func (r *Router) unassignRole(c echo.Context) error {
+	id, err := credentialIDParam(c)
+	if err != nil {
+		return err
+	}
+
+	err = r.adminService.UnassignRole(c.Request().Context(), id, c.Param("name"))
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// tenants retrieves all tenants.
+func (r *Router) tenants(c echo.Context) error {
+	tenants, err := r.adminService.Tenants(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	resp := struct {
+		Tenants []tenantResponse `json:"tenants"`
+	}{
+		Tenants: make([]tenantResponse, 0, len(tenants)),
+	}
+
+	for _, t := range tenants {
+		resp.Tenants = append(resp.Tenants, tenantToResponse(t))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// saveTenant creates or updates a tenant.
+func (r *Router) saveTenant(c echo.Context) error {
+	var request tenantRequest
+
+	err := c.Bind(&request)
+	if err != nil {
+		return err
+	}
+
+	t := auth.Tenant{
+		ID:    c.Param("id"),
+		Name:  request.Name,
+		Hosts: request.Hosts,
+		Settings: auth.TenantSettings{
+			PasswordPolicy: auth.PasswordPolicy(request.Settings.PasswordPolicy),
+			TokenTTL:       time.Duration(request.Settings.TokenTTLSeconds) * time.Second,
+			InvitationTTL:  time.Duration(request.Settings.InvitationTTLSeconds) * time.Second,
+		},
+	}
+
+	if len(request.Settings.MailTemplates) > 0 {
+		t.Settings.MailTemplates = make(map[string]auth.MailTemplate, len(request.Settings.MailTemplates))
+		for name, tmpl := range request.Settings.MailTemplates {
+			t.Settings.MailTemplates[name] = auth.MailTemplate(tmpl)
+		}
+	}
+
+	if len(request.Settings.ProfileSchema) > 0 {
+		t.Settings.ProfileSchema = auth.ProfileSchema(request.Settings.ProfileSchema)
+	}
+
+	err = r.adminService.SaveTenant(c.Request().Context(), &t)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, tenantToResponse(t))
+}
+
+// importCredentials creates credentials from a CSV or JSONL body keeping their password hashes.
+// The format is taken from the format query parameter or the content type.
+func (r *Router) importCredentials(c echo.Context) error {
+	format := c.QueryParam("format")
+	if format == "" {
+		format = importFormat(c.Request().Header.Get(echo.HeaderContentType))
+	}
+
+	body := http.MaxBytesReader(c.Response(), c.Request().Body, maxImportSize)
+
+	records, err := ReadImportRecords(body, format)
+	if err != nil {
+		return err
+	}
+
+	if len(records) > maxImportRecords {
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			fmt.Sprintf("Too many records, %d at most, use the import command for bigger files.", maxImportRecords),
+		)
+	}
+
+	result, err := r.adminService.ImportCredentials(c.Request().Context(), records)
+	if err != nil {
+		return err
+	}
+
+	resp := importResponse{
+		Created: result.Created,
+		Errors:  make([]importErrorResponse, 0, len(result.Errors)),
+	}
+
+	for _, e := range result.Errors {
+		resp.Errors = append(resp.Errors, importErrorResponse{
+			Line:  e.Line,
+			Email: e.Email,
+			Err: &auth.Error{
+				Code:    auth.ErrorCode(e.Err),
+				Message: auth.ErrorMsg(e.Err),
+			},
+		})
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// importFormat returns the import format of the content type, CSV by default.
+func importFormat(contentType string) string {
+	if strings.Contains(contentType, "json") {
+		return ImportJSONL
+	}
+
+	return ImportCSV
+}
+
+// adminAction runs fn with the credential id from the path and responds with the credential.
+func (r *Router) adminAction(c echo.Context, fn func(ctx context.Context, id int) (auth.Credential, error)) error {
+	id, err := credentialIDParam(c)
+	if err != nil {
+		return err
+	}
+
+	cred, err := fn(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, credToAdminResponse(cred))
+}
+
+func credentialIDParam(c echo.Context) (int, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "Bad id.")
+	}
+
+	return id, nil
+}
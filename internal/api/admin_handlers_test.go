@@ -0,0 +1,896 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/mock"
+)
+
+const adminToken = "admin-token"
+
+func TestAdmin_AuditLog(t *testing.T) {
+	cases := []struct {
+		name       string
+		query      string
+		authHeader string
+		wantFilter auth.AuditFilter
+		wantResp   string
+		wantStatus int
+	}{
+		{
+			name:       "success",
+			query:      "credential_id=1&from=2020-04-15T00:00:00Z&to=2020-04-16T00:00:00Z&limit=10",
+			authHeader: "Bearer " + adminToken,
+			wantFilter: auth.AuditFilter{
+				CredentialID: 1,
+				From:         now.Truncate(24 * time.Hour),
+				To:           now.Truncate(24 * time.Hour).Add(24 * time.Hour),
+				Limit:        10,
+			},
+			wantResp:   `{"entries":[{"id":2,"credential_id":1,"event":"login_success","ip":"127.0.0.1","user_agent":"curl","reason":"","actor":"","created_at":"2020-04-15T10:11:12Z"}]}` + "\n",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "error - bad from",
+			query:      "from=yesterday",
+			authHeader: "Bearer " + adminToken,
+			wantResp:   `{"error":{"code":"http_400","message":"Bad from, RFC3339 expected."}}` + "\n",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "error - bad token",
+			authHeader: "Bearer 12345",
+			wantResp:   `{"error":{"code":"http_401","message":"Unauthorized"}}` + "\n",
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			auditLog := &mock.AuditLogMock{
+				FindFunc: func(ctx context.Context, f auth.AuditFilter) ([]auth.AuditEntry, error) {
+					if diff := cmp.Diff(tc.wantFilter, f); diff != "" {
+						t.Error(diff)
+					}
+
+					return []auth.AuditEntry{{
+						ID:           2,
+						CredentialID: 1,
+						Event:        auth.AuditLoginSuccess,
+						IP:           "127.0.0.1",
+						UserAgent:    "curl",
+						CreatedAt:    now,
+					}}, nil
+				},
+			}
+
+			credRep := &mock.CredentialRepositoryMock{
+				ByTokenFunc: func(ctx context.Context, token string) (auth.Credential, error) {
+					return auth.Credential{}, auth.NewError(auth.ErrCredNotFound, "Credential not found")
+				},
+			}
+
+			h := NewRouter(
+				NewCredentialService(credRep, nowFunc, nil),
+				WithAdmin(NewAdminService(nil, nil, nil, auditLog, nowFunc, nil, testHasher), adminToken),
+			).Handler().Server.Handler
+
+			srv := httptest.NewServer(h)
+			defer srv.Close()
+
+			req, err := http.NewRequest(
+				"GET",
+				fmt.Sprintf("%s/admin/v1/audit?%s", srv.URL, tc.query),
+				strings.NewReader(``),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", tc.authHeader)
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if diff := cmp.Diff(tc.wantStatus, resp.StatusCode); diff != "" {
+				t.Error(diff)
+			}
+
+			b, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tc.wantResp, string(b)); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestAdmin_RoleAccess(t *testing.T) {
+	cases := []struct {
+		name       string
+		token      string
+		adminToken string
+		roles      []auth.Role
+		wantStatus int
+		wantActor  string
+	}{
+		{
+			name:  "credential with admin permission",
+			token: "token",
+			roles: []auth.Role{{
+				Name:        "support",
+				Permissions: []auth.Permission{{Action: "manage", Resource: "admin"}},
+			}},
+			wantStatus: http.StatusOK,
+			wantActor:  "credential:1",
+		},
+		{
+			name:  "credential with wildcard permission",
+			token: "token",
+			roles: []auth.Role{{
+				Name:        "superuser",
+				Permissions: []auth.Permission{{Action: auth.Wildcard, Resource: auth.Wildcard}},
+			}},
+			wantStatus: http.StatusOK,
+			wantActor:  "credential:1",
+		},
+		{
+			name:  "credential without admin permission",
+			token: "token",
+			roles: []auth.Role{{
+				Name:        "reader",
+				Permissions: []auth.Permission{{Action: "read", Resource: "admin"}},
+			}},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "unknown token",
+			token:      "unknown",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "admin token",
+			token:      adminToken,
+			adminToken: adminToken,
+			wantStatus: http.StatusOK,
+			wantActor:  auth.ActorAdmin,
+		},
+		{
+			name:       "empty token",
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			credRep := &mock.CredentialRepositoryMock{
+				ByTokenFunc: func(ctx context.Context, token string) (auth.Credential, error) {
+					if token != "token" {
+						return auth.Credential{}, auth.NewError(auth.ErrCredNotFound, "Credential not found")
+					}
+
+					return auth.Credential{ID: 1, Token: token}, nil
+				},
+				ByIDFunc: func(ctx context.Context, id int) (auth.Credential, error) {
+					return auth.Credential{ID: id}, nil
+				},
+			}
+			roleRep := &mock.RoleRepositoryMock{
+				ByCredentialFunc: func(ctx context.Context, credID int) ([]auth.Role, error) {
+					return tc.roles, nil
+				},
+			}
+			auditLog := &mock.AuditLogMock{
+				AppendFunc: func(ctx context.Context, e *auth.AuditEntry) error {
+					return nil
+				},
+			}
+
+			h := NewRouter(
+				NewCredentialService(credRep, nowFunc, nil, WithRoleRepository(roleRep)),
+				WithAdmin(NewAdminService(credRep, roleRep, nil, auditLog, nowFunc, nil, testHasher), tc.adminToken),
+			).Handler().Server.Handler
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/v1/credentials/1", nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if diff := cmp.Diff(tc.wantStatus, rec.Code); diff != "" {
+				t.Fatal(diff)
+			}
+
+			if tc.wantActor == "" {
+				return
+			}
+
+			calls := auditLog.AppendCalls()
+			last := calls[len(calls)-1].E
+
+			if diff := cmp.Diff(auth.AuditView, last.Event); diff != "" {
+				t.Error(diff)
+			}
+
+			if diff := cmp.Diff(tc.wantActor, last.Actor); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestUser_Auth_AuditClientInfo(t *testing.T) {
+	auditLog := &mock.AuditLogMock{
+		AppendFunc: func(ctx context.Context, e *auth.AuditEntry) error {
+			return nil
+		},
+	}
+
+	h := NewRouter(NewCredentialService(&mock.CredentialRepositoryMock{
+		ByEmailFunc: func(ctx context.Context, email string) (auth.Credential, error) {
+			return auth.Credential{}, auth.NewError(auth.ErrCredNotFound, "Credential not found")
+		},
+	}, nowFunc, nil, WithAuditLog(auditLog))).Handler().Server.Handler
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/auth", strings.NewReader(`{"email":"example@example.org","password":"1"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "curl/7.68.0")
+	req.Header.Set("X-Real-IP", "10.0.0.1")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	calls := auditLog.AppendCalls()
+	if len(calls) != 1 {
+		t.Fatalf("expected 1 audit entry, got %d", len(calls))
+	}
+
+	if diff := cmp.Diff("10.0.0.1", calls[0].E.IP); diff != "" {
+		t.Error(diff)
+	}
+
+	if diff := cmp.Diff("curl/7.68.0", calls[0].E.UserAgent); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestAdmin_Credentials(t *testing.T) {
+	stored := auth.Credential{
+		ID:        1,
+		Password:  "hash",
+		Token:     "token",
+		Email:     "example@example.org",
+		Status:    auth.StatusActive,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	cases := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantResp   string
+		wantStatus int
+		wantEvent  string
+	}{
+		{
+			name:       "search",
+			method:     http.MethodGet,
+			path:       "/admin/v1/credentials?email=example&limit=10",
+			wantResp:   `{"credentials":[{"id":1,"email":"example@example.org","email_tmp":"","email_verified":false,"status":"active","status_reason":"","status_until":null,"created_at":"2020-04-15T10:11:12Z","updated_at":"2020-04-15T10:11:12Z"}]}` + "\n",
+			wantStatus: http.StatusOK,
+			wantEvent:  auth.AuditSearch,
+		},
+		{
+			name:       "view",
+			method:     http.MethodGet,
+			path:       "/admin/v1/credentials/1",
+			wantResp:   `{"id":1,"email":"example@example.org","email_tmp":"","email_verified":false,"status":"active","status_reason":"","status_until":null,"created_at":"2020-04-15T10:11:12Z","updated_at":"2020-04-15T10:11:12Z"}` + "\n",
+			wantStatus: http.StatusOK,
+			wantEvent:  auth.AuditView,
+		},
+		{
+			name:       "disable",
+			method:     http.MethodPost,
+			path:       "/admin/v1/credentials/1/disable",
+			wantResp:   `{"id":1,"email":"example@example.org","email_tmp":"","email_verified":false,"status":"disabled","status_reason":"","status_until":null,"created_at":"2020-04-15T10:11:12Z","updated_at":"2020-04-15T10:11:12Z"}` + "\n",
+			wantStatus: http.StatusOK,
+			wantEvent:  auth.AuditDisable,
+		},
+		{
+			name:       "suspend",
+			method:     http.MethodPost,
+			path:       "/admin/v1/credentials/1/status",
+			body:       `{"status":"suspended","reason":"spam","until":"2020-04-16T00:00:00Z"}`,
+			wantResp:   `{"id":1,"email":"example@example.org","email_tmp":"","email_verified":false,"status":"suspended","status_reason":"spam","status_until":"2020-04-16T00:00:00Z","created_at":"2020-04-15T10:11:12Z","updated_at":"2020-04-15T10:11:12Z"}` + "\n",
+			wantStatus: http.StatusOK,
+			wantEvent:  auth.AuditSuspend,
+		},
+		{
+			name:       "error - unknown status",
+			method:     http.MethodPost,
+			path:       "/admin/v1/credentials/1/status",
+			body:       `{"status":"banned"}`,
+			wantResp:   `{"error":{"code":"validation_failed","message":"Unknown status."}}` + "\n",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "delete",
+			method:     http.MethodDelete,
+			path:       "/admin/v1/credentials/1",
+			wantStatus: http.StatusNoContent,
+			wantEvent:  auth.AuditDelete,
+		},
+		{
+			name:       "error - not found",
+			method:     http.MethodGet,
+			path:       "/admin/v1/credentials/2",
+			wantResp:   `{"error":{"code":"credential_not_found","message":"Credential not found"}}` + "\n",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "error - bad id",
+			method:     http.MethodPost,
+			path:       "/admin/v1/credentials/abc/revoke-sessions",
+			wantResp:   `{"error":{"code":"http_400","message":"Bad id."}}` + "\n",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "error - empty password",
+			method:     http.MethodPost,
+			path:       "/admin/v1/credentials/1/password",
+			body:       `{"password":""}`,
+			wantResp:   `{"error":{"code":"http_400","message":"Password is required."}}` + "\n",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			credRep := &mock.CredentialRepositoryMock{
+				SearchFunc: func(ctx context.Context, f auth.CredentialFilter) ([]auth.Credential, error) {
+					if diff := cmp.Diff(auth.CredentialFilter{Email: "example", Limit: 10}, f); diff != "" {
+						t.Error(diff)
+					}
+
+					return []auth.Credential{stored}, nil
+				},
+				ByIDFunc: func(ctx context.Context, id int) (auth.Credential, error) {
+					if id != stored.ID {
+						return auth.Credential{}, auth.NewError(auth.ErrCredNotFound, "Credential not found")
+					}
+
+					return stored, nil
+				},
+				UpdateFunc: func(ctx context.Context, c *auth.Credential) error {
+					return nil
+				},
+				DeleteFunc: func(ctx context.Context, id int) error {
+					return nil
+				},
+			}
+			auditLog := &mock.AuditLogMock{
+				AppendFunc: func(ctx context.Context, e *auth.AuditEntry) error {
+					return nil
+				},
+			}
+
+			h := NewRouter(
+				NewCredentialService(nil, nowFunc, nil),
+				WithAdmin(NewAdminService(credRep, nil, nil, auditLog, nowFunc, nil, testHasher), adminToken),
+			).Handler().Server.Handler
+
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+adminToken)
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if diff := cmp.Diff(tc.wantStatus, rec.Code); diff != "" {
+				t.Error(diff)
+			}
+
+			if diff := cmp.Diff(tc.wantResp, rec.Body.String()); diff != "" {
+				t.Error(diff)
+			}
+
+			if tc.wantEvent == "" {
+				if len(auditLog.AppendCalls()) != 0 {
+					t.Errorf("unexpected audit entries: %d", len(auditLog.AppendCalls()))
+				}
+
+				return
+			}
+
+			calls := auditLog.AppendCalls()
+			if len(calls) != 1 {
+				t.Fatalf("expected 1 audit entry, got %d", len(calls))
+			}
+
+			if diff := cmp.Diff(tc.wantEvent, calls[0].E.Event); diff != "" {
+				t.Error(diff)
+			}
+
+			if diff := cmp.Diff(auth.ActorAdmin, calls[0].E.Actor); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestAdmin_Roles(t *testing.T) {
+	cases := []struct {
+		name        string
+		method      string
+		path        string
+		body        string
+		wantResp    string
+		wantStatus  int
+		wantEvent   string
+		wantReason  string
+		wantCredID  int
+		createError error
+	}{
+		{
+			name:       "create",
+			method:     http.MethodPost,
+			path:       "/admin/v1/roles",
+			body:       `{"name":"support","description":"Support staff","permissions":[{"action":"manage","resource":"admin"}]}`,
+			wantResp:   `{"id":3,"name":"support","description":"Support staff","permissions":[{"action":"manage","resource":"admin"}],"created_at":"2020-04-15T10:11:12Z"}` + "\n",
+			wantStatus: http.StatusCreated,
+			wantEvent:  auth.AuditRoleCreate,
+			wantReason: "support",
+		},
+		{
+			name:       "error - bad name",
+			method:     http.MethodPost,
+			path:       "/admin/v1/roles",
+			body:       `{"name":"Support Staff"}`,
+			wantResp:   `{"error":{"code":"validation_failed","message":"Bad role name, lowercase letters, digits and _.- expected."}}` + "\n",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "error - exists",
+			method:      http.MethodPost,
+			path:        "/admin/v1/roles",
+			body:        `{"name":"support"}`,
+			wantResp:    `{"error":{"code":"role_already_exists","message":"Role with this name already exists."}}` + "\n",
+			wantStatus:  http.StatusConflict,
+			createError: auth.NewError(auth.ErrRoleExists, "Role with this name already exists."),
+		},
+		{
+			name:       "list credential roles",
+			method:     http.MethodGet,
+			path:       "/admin/v1/credentials/1/roles",
+			wantResp:   `{"roles":[{"id":3,"name":"support","description":"","permissions":[{"action":"manage","resource":"admin"}],"created_at":"2020-04-15T10:11:12Z"}]}` + "\n",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "assign",
+			method:     http.MethodPut,
+			path:       "/admin/v1/credentials/1/roles/support",
+			wantStatus: http.StatusNoContent,
+			wantEvent:  auth.AuditRoleAssign,
+			wantReason: "support",
+			wantCredID: 1,
+		},
+		{
+			name:       "error - assign unknown role",
+			method:     http.MethodPut,
+			path:       "/admin/v1/credentials/1/roles/unknown",
+			wantResp:   `{"error":{"code":"role_not_found","message":"Role not found"}}` + "\n",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "error - assign to a credential of another tenant",
+			method:     http.MethodPut,
+			path:       "/admin/v1/credentials/2/roles/support",
+			wantResp:   `{"error":{"code":"credential_not_found","message":"Credential not found"}}` + "\n",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "error - roles of a credential of another tenant",
+			method:     http.MethodGet,
+			path:       "/admin/v1/credentials/2/roles",
+			wantResp:   `{"error":{"code":"credential_not_found","message":"Credential not found"}}` + "\n",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "unassign",
+			method:     http.MethodDelete,
+			path:       "/admin/v1/credentials/1/roles/support",
+			wantStatus: http.StatusNoContent,
+			wantEvent:  auth.AuditRoleUnassign,
+			wantReason: "support",
+			wantCredID: 1,
+		},
+		{
+			name:       "delete",
+			method:     http.MethodDelete,
+			path:       "/admin/v1/roles/support",
+			wantStatus: http.StatusNoContent,
+			wantEvent:  auth.AuditRoleDelete,
+			wantReason: "support",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			support := auth.Role{
+				ID:          3,
+				Name:        "support",
+				Permissions: []auth.Permission{{Action: "manage", Resource: "admin"}},
+				CreatedAt:   now,
+			}
+
+			roleRep := &mock.RoleRepositoryMock{
+				CreateFunc: func(ctx context.Context, r *auth.Role) error {
+					r.ID = 3
+					return tc.createError
+				},
+				ByCredentialFunc: func(ctx context.Context, credID int) ([]auth.Role, error) {
+					return []auth.Role{support}, nil
+				},
+				AssignFunc: func(ctx context.Context, credID int, name string) error {
+					if name != support.Name {
+						return auth.NewError(auth.ErrRoleNotFound, "Role not found")
+					}
+
+					return nil
+				},
+				UnassignFunc: func(ctx context.Context, credID int, name string) error {
+					return nil
+				},
+				DeleteFunc: func(ctx context.Context, name string) error {
+					return nil
+				},
+			}
+			// The credential 1 is the only one of the tenant.
+			credRep := &mock.CredentialRepositoryMock{
+				ByIDFunc: func(ctx context.Context, id int) (auth.Credential, error) {
+					if id != 1 {
+						return auth.Credential{}, auth.NewError(auth.ErrCredNotFound, "Credential not found")
+					}
+
+					return auth.Credential{ID: 1}, nil
+				},
+			}
+			auditLog := &mock.AuditLogMock{
+				AppendFunc: func(ctx context.Context, e *auth.AuditEntry) error {
+					return nil
+				},
+			}
+
+			h := NewRouter(
+				NewCredentialService(nil, nowFunc, nil),
+				WithAdmin(NewAdminService(credRep, roleRep, nil, auditLog, nowFunc, nil, testHasher), adminToken),
+			).Handler().Server.Handler
+
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+adminToken)
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if diff := cmp.Diff(tc.wantStatus, rec.Code); diff != "" {
+				t.Error(diff)
+			}
+
+			if diff := cmp.Diff(tc.wantResp, rec.Body.String()); diff != "" {
+				t.Error(diff)
+			}
+
+			calls := auditLog.AppendCalls()
+
+			if tc.wantEvent == "" {
+				if len(calls) != 0 {
+					t.Errorf("unexpected audit entries: %d", len(calls))
+				}
+
+				return
+			}
+
+			if len(calls) != 1 {
+				t.Fatalf("expected 1 audit entry, got %d", len(calls))
+			}
+
+			want := auth.AuditEntry{
+				CredentialID: tc.wantCredID,
+				Event:        tc.wantEvent,
+				Reason:       tc.wantReason,
+				Actor:        auth.ActorAdmin,
+				IP:           "192.0.2.1",
+				CreatedAt:    now,
+			}
+
+			if diff := cmp.Diff(want, *calls[0].E); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestAdmin_Tenants(t *testing.T) {
+	cases := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		token      string
+		wantResp   string
+		wantStatus int
+		wantSaved  *auth.Tenant
+	}{
+		{
+			name:   "save",
+			method: http.MethodPut,
+			path:   "/admin/v1/tenants/shop",
+			body: `{"name":"Shop","hosts":["Shop.example.org"],"settings":{"password_policy":{"min_length":8,"require_digit":true},` +
+				`"token_ttl_seconds":3600,"invitation_ttl_seconds":0,"mail_templates":{"verify_email":{"subject":"Verify","body":"Code: {{.Code}}"}},"profile_schema":{"plan":"string"}}}`,
+			token: adminToken,
+			wantResp: `{"id":"shop","name":"Shop","hosts":["shop.example.org"],"settings":{"password_policy":{"min_length":8,"max_length":0,"require_upper":false,"require_lower":false,"require_digit":true,"require_symbol":false},` +
+				`"token_ttl_seconds":3600,"invitation_ttl_seconds":0,"mail_templates":{"verify_email":{"subject":"Verify","body":"Code: {{.Code}}"}},"profile_schema":{"plan":"string"}},"created_at":"2020-04-15T10:11:12Z","updated_at":"2020-04-15T10:11:12Z"}` + "\n",
+			wantStatus: http.StatusOK,
+			wantSaved: &auth.Tenant{
+				ID:    "shop",
+				Name:  "Shop",
+				Hosts: []string{"shop.example.org"},
+				Settings: auth.TenantSettings{
+					PasswordPolicy: auth.PasswordPolicy{MinLength: 8, RequireDigit: true},
+					TokenTTL:       time.Hour,
+					MailTemplates: map[string]auth.MailTemplate{
+						"verify_email": {Subject: "Verify", Body: "Code: {{.Code}}"},
+					},
+					ProfileSchema: auth.ProfileSchema{"plan": auth.MetadataString},
+				},
+				CreatedAt: now,
+				UpdatedAt: now,
+			},
+		},
+		{
+			name:       "error - bad id",
+			method:     http.MethodPut,
+			path:       "/admin/v1/tenants/Shop",
+			body:       `{"name":"Shop"}`,
+			token:      adminToken,
+			wantResp:   `{"error":{"code":"validation_failed","message":"Bad tenant id, lowercase letters, digits and _.- expected."}}` + "\n",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "error - bad password policy",
+			method:     http.MethodPut,
+			path:       "/admin/v1/tenants/shop",
+			body:       `{"settings":{"password_policy":{"min_length":8,"max_length":4}}}`,
+			token:      adminToken,
+			wantResp:   `{"error":{"code":"validation_failed","message":"Bad password policy length."}}` + "\n",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "error - bad profile schema",
+			method:     http.MethodPut,
+			path:       "/admin/v1/tenants/shop",
+			body:       `{"settings":{"profile_schema":{"plan":"date"}}}`,
+			token:      adminToken,
+			wantResp:   `{"error":{"code":"validation_failed","message":"Bad type of metadata key \"plan\"."}}` + "\n",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "list",
+			method: http.MethodGet,
+			path:   "/admin/v1/tenants",
+			token:  adminToken,
+			wantResp: `{"tenants":[{"id":"default","name":"Default","hosts":[],"settings":{"password_policy":{"min_length":0,"max_length":0,"require_upper":false,"require_lower":false,"require_digit":false,"require_symbol":false},` +
+				`"token_ttl_seconds":0,"invitation_ttl_seconds":0,"mail_templates":{},"profile_schema":{}},"created_at":"2020-04-15T10:11:12Z","updated_at":"2020-04-15T10:11:12Z"}]}` + "\n",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "error - credential with admin permission",
+			method:     http.MethodGet,
+			path:       "/admin/v1/tenants",
+			token:      "token",
+			wantResp:   `{"error":{"code":"http_403","message":"Forbidden"}}` + "\n",
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			credRep := &mock.CredentialRepositoryMock{
+				ByTokenFunc: func(ctx context.Context, token string) (auth.Credential, error) {
+					return auth.Credential{ID: 1, Token: token}, nil
+				},
+			}
+			roleRep := &mock.RoleRepositoryMock{
+				ByCredentialFunc: func(ctx context.Context, credID int) ([]auth.Role, error) {
+					return []auth.Role{{
+						Name:        "support",
+						Permissions: []auth.Permission{{Action: "manage", Resource: "admin"}},
+					}}, nil
+				},
+			}
+			tenantRep := &mock.TenantRepositoryMock{
+				SaveFunc: func(ctx context.Context, t *auth.Tenant) error {
+					return nil
+				},
+				TenantsFunc: func(ctx context.Context) ([]auth.Tenant, error) {
+					return []auth.Tenant{{ID: auth.DefaultTenantID, Name: "Default", CreatedAt: now, UpdatedAt: now}}, nil
+				},
+			}
+			auditLog := &mock.AuditLogMock{
+				AppendFunc: func(ctx context.Context, e *auth.AuditEntry) error {
+					return nil
+				},
+			}
+
+			h := NewRouter(
+				NewCredentialService(credRep, nowFunc, nil, WithRoleRepository(roleRep)),
+				WithAdmin(NewAdminService(credRep, roleRep, tenantRep, auditLog, nowFunc, nil, testHasher), adminToken),
+			).Handler().Server.Handler
+
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if diff := cmp.Diff(tc.wantStatus, rec.Code); diff != "" {
+				t.Error(diff)
+			}
+
+			if diff := cmp.Diff(tc.wantResp, rec.Body.String()); diff != "" {
+				t.Error(diff)
+			}
+
+			calls := tenantRep.SaveCalls()
+
+			if tc.wantSaved == nil {
+				if len(calls) != 0 {
+					t.Errorf("unexpected tenant saves: %d", len(calls))
+				}
+
+				return
+			}
+
+			if len(calls) != 1 {
+				t.Fatalf("expected 1 tenant save, got %d", len(calls))
+			}
+
+			if diff := cmp.Diff(*tc.wantSaved, *calls[0].T); diff != "" {
+				t.Error(diff)
+			}
+
+			if diff := cmp.Diff(auth.AuditTenantSave, auditLog.AppendCalls()[0].E.Event); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestAdmin_ImportCredentials(t *testing.T) {
+	cases := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+		wantResp    string
+		wantStatus  int
+		wantCreated int
+	}{
+		{
+			name:        "csv",
+			path:        "/admin/v1/credentials/import",
+			contentType: "text/csv",
+			body: "email,algorithm,hash,salt,email_verified\n" +
+				"user@example.org,md5,527ca2d6f2e1fc83ec00a43e088d29ce,pepper,true\n" +
+				"bad,md5,527ca2d6f2e1fc83ec00a43e088d29ce,,\n",
+			wantResp:    `{"created":1,"errors":[{"line":3,"email":"bad","error":{"code":"validation_failed","message":"Bad email."}}]}` + "\n",
+			wantStatus:  http.StatusOK,
+			wantCreated: 1,
+		},
+		{
+			name:        "jsonl",
+			path:        "/admin/v1/credentials/import",
+			contentType: "application/x-ndjson",
+			body: `{"email":"user@example.org","algorithm":"pbkdf2-sha256","hash":"ca7afb8c4555ea1faa0e152e411b522549d84dc7d59036ab3a9b72e5d88658e4",` +
+				`"salt":"salt","iterations":1000}` + "\n\n",
+			wantResp:    `{"created":1,"errors":[]}` + "\n",
+			wantStatus:  http.StatusOK,
+			wantCreated: 1,
+		},
+		{
+			name:        "error - bad hash",
+			path:        "/admin/v1/credentials/import?format=jsonl",
+			contentType: "text/plain",
+			body:        `{"email":"user@example.org","algorithm":"pbkdf2-sha256","hash":"ca7afb8c","salt":"salt","iterations":1000}`,
+			wantResp:    `{"created":0,"errors":[{"line":1,"email":"user@example.org","error":{"code":"validation_failed","message":"Bad hash: bad pbkdf2 key length 4."}}]}` + "\n",
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "error - no hash column",
+			path:        "/admin/v1/credentials/import",
+			contentType: "text/csv",
+			body:        "email,algorithm\nuser@example.org,md5\n",
+			wantResp:    `{"error":{"code":"validation_failed","message":"No hash column."}}` + "\n",
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "error - bad json",
+			path:        "/admin/v1/credentials/import",
+			contentType: "application/jsonl",
+			body:        `{"email":`,
+			wantResp:    `{"error":{"code":"validation_failed","message":"Bad JSON on line 1."}}` + "\n",
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:        "error - unknown format",
+			path:        "/admin/v1/credentials/import?format=xml",
+			contentType: "text/csv",
+			body:        "email,algorithm,hash\n",
+			wantResp:    `{"error":{"code":"validation_failed","message":"Unknown import format \"xml\", csv or jsonl expected."}}` + "\n",
+			wantStatus:  http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			credRep := &mock.CredentialRepositoryMock{
+				ByEmailFunc: func(ctx context.Context, email string) (auth.Credential, error) {
+					return auth.Credential{}, auth.NewError(auth.ErrCredNotFound, "Credential not found")
+				},
+				CreateFunc: func(ctx context.Context, c *auth.Credential) error {
+					return nil
+				},
+			}
+			auditLog := &mock.AuditLogMock{
+				AppendFunc: func(ctx context.Context, e *auth.AuditEntry) error {
+					return nil
+				},
+			}
+
+			h := NewRouter(
+				NewCredentialService(credRep, nowFunc, nil),
+				WithAdmin(NewAdminService(credRep, nil, nil, auditLog, nowFunc, func(n int) (string, error) {
+					return "token", nil
+				}, testHasher), adminToken),
+			).Handler().Server.Handler
+
+			req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", tc.contentType)
+			req.Header.Set("Authorization", "Bearer "+adminToken)
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if diff := cmp.Diff(tc.wantStatus, rec.Code); diff != "" {
+				t.Error(diff)
+			}
+
+			if diff := cmp.Diff(tc.wantResp, rec.Body.String()); diff != "" {
+				t.Error(diff)
+			}
+
+			if diff := cmp.Diff(tc.wantCreated, len(credRep.CreateCalls())); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,450 @@
+package api
+
+import (
+	"context"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"time"
+
+	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/password"
+)
+
+const (
+	maxAuditLimit  = 1000
+	maxSearchLimit = 1000
+	// maxHashLength is the max length of a password hash in the storage.
+	maxHashLength = 255
+)
+
+// tenantIDRe matches valid tenant ids.
+var tenantIDRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_.\-]{0,63}$`)
+
+// statusEvents are audit events of status changes.
+var statusEvents = map[string]string{
+	auth.StatusActive:          auth.AuditEnable,
+	auth.StatusSuspended:       auth.AuditSuspend,
+	auth.StatusDisabled:        auth.AuditDisable,
+	auth.StatusPendingDeletion: auth.AuditDeletionRequest,
+}
+
+// AdminService is a service for administrators.
+// Every action is recorded in the audit log on behalf of the actor of the request.
+type AdminService struct {
+	credentialRepository auth.CredentialRepository
+	roleRepository       auth.RoleRepository
+	tenantRepository     auth.TenantRepository
+	auditLog             auth.AuditLog
+	nowFn                func() time.Time
+	generatorFn          func(n int) (string, error)
+	hasher               Hasher
+}
+
+// NewAdminService creates an AdminService.
+func NewAdminService(
+	r auth.CredentialRepository,
+	roles auth.RoleRepository,
+	tenants auth.TenantRepository,
+	a auth.AuditLog,
+	nowFn func() time.Time,
+	generatorFn func(n int) (string, error),
+	hasher Hasher,
+) *AdminService {
+	return &AdminService{
+		credentialRepository: r,
+		roleRepository:       roles,
+		tenantRepository:     tenants,
+		auditLog:             a,
+		nowFn:                nowFn,
+		generatorFn:          generatorFn,
+		hasher:               hasher,
+	}
+}
+
+// AuditLog returns security events matching the filter.
+func (s *AdminService) AuditLog(ctx context.Context, f auth.AuditFilter) ([]auth.AuditEntry, error) {
+	if f.Limit > maxAuditLimit {
+		f.Limit = maxAuditLimit
+	}
+
+	return s.auditLog.Find(ctx, f)
+}
+
+// Credentials searches Credentials matching the filter.
+func (s *AdminService) Credentials(ctx context.Context, f auth.CredentialFilter) ([]auth.Credential, error) {
+	if f.Limit > maxSearchLimit {
+		f.Limit = maxSearchLimit
+	}
+
+	creds, err := s.credentialRepository.Search(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, auth.AuditSearch, 0, "")
+
+	return creds, nil
+}
+
+// Credential retrieves a Credential by id.
+func (s *AdminService) Credential(ctx context.Context, id int) (auth.Credential, error) {
+	cred, err := s.credentialRepository.ByID(ctx, id)
+	if err != nil {
+		return auth.Credential{}, err
+	}
+
+	s.audit(ctx, auth.AuditView, id, "")
+
+	return cred, nil
+}
+
+// VerifyEmail marks the email of a Credential as verified without a verification code.
+// A pending email change is applied.
+func (s *AdminService) VerifyEmail(ctx context.Context, id int) (auth.Credential, error) {
+	return s.update(ctx, id, auth.AuditEmailVerify, func(cred *auth.Credential) error {
+		if cred.EmailTmp != "" {
+			cred.Email = cred.EmailTmp
+			cred.EmailTmp = ""
+		}
+
+		cred.EmailVerified = true
+		cred.VerificationCode = ""
+		cred.VerificationCodeAttempts = 0
+
+		return nil
+	})
+}
+
+// ResetPassword sets a new password and revokes the token of a Credential.
+func (s *AdminService) ResetPassword(ctx context.Context, id int, plainPassword string) (auth.Credential, error) {
+	return s.update(ctx, id, auth.AuditPasswordChange, func(cred *auth.Credential) error {
+		err := auth.TenantFromContext(ctx).Settings.PasswordPolicy.Validate(plainPassword)
+		if err != nil {
+			return err
+		}
+
+		cred.Password, err = s.hasher.Hash(plainPassword)
+		if err != nil {
+			return err
+		}
+
+		return s.newToken(ctx, cred)
+	})
+}
+
+// SetStatus changes the status of a Credential, until is the end of a suspension.
+func (s *AdminService) SetStatus(
+	ctx context.Context,
+	id int,
+	status, reason string,
+	until time.Time,
+) (auth.Credential, error) {
+	if !auth.ValidStatus(status) {
+		return auth.Credential{}, auth.NewError(auth.ErrValidation, "Unknown status.")
+	}
+
+	if status != auth.StatusSuspended && !until.IsZero() {
+		return auth.Credential{}, auth.NewError(auth.ErrValidation, "Until is allowed only for suspension.")
+	}
+
+	if !until.IsZero() && !until.After(s.nowFn()) {
+		return auth.Credential{}, auth.NewError(auth.ErrValidation, "Until must be in the future.")
+	}
+
+	return s.update(ctx, id, statusEvents[status], func(cred *auth.Credential) error {
+		cred.Status = status
+		cred.StatusReason = reason
+		cred.StatusUntil = until
+
+		return nil
+	})
+}
+
+// RevokeSessions replaces the token of a Credential, so the old one can't be used anymore.
+func (s *AdminService) RevokeSessions(ctx context.Context, id int) (auth.Credential, error) {
+	return s.update(ctx, id, auth.AuditRevoke, func(cred *auth.Credential) error {
+		return s.newToken(ctx, cred)
+	})
+}
+
+// Delete deletes a Credential.
+func (s *AdminService) Delete(ctx context.Context, id int) error {
+	err := s.credentialRepository.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	s.audit(ctx, auth.AuditDelete, id, "")
+
+	return nil
+}
+
+// Roles retrieves all Roles of the tenant.
+func (s *AdminService) Roles(ctx context.Context) ([]auth.Role, error) {
+	return s.roleRepository.Roles(ctx)
+}
+
+// CreateRole creates a new Role of the tenant.
+func (s *AdminService) CreateRole(ctx context.Context, r *auth.Role) error {
+	err := validateRole(*r)
+	if err != nil {
+		return err
+	}
+
+	r.CreatedAt = s.nowFn()
+
+	err = s.roleRepository.Create(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	s.audit(ctx, auth.AuditRoleCreate, 0, r.Name)
+
+	return nil
+}
+
+// DeleteRole deletes a Role of the tenant with all its assignments.
+func (s *AdminService) DeleteRole(ctx context.Context, name string) error {
+	err := s.roleRepository.Delete(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	s.audit(ctx, auth.AuditRoleDelete, 0, name)
+
+	return nil
+}
+
+// CredentialRoles retrieves Roles assigned to a Credential.
+func (s *AdminService) CredentialRoles(ctx context.Context, credID int) ([]auth.Role, error) {
+	_, err := s.credentialRepository.ByID(ctx, credID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.roleRepository.ByCredential(ctx, credID)
+}
+
+// AssignRole assigns a Role to a Credential.
+func (s *AdminService) AssignRole(ctx context.Context, credID int, name string) error {
+	// The Credential is checked to report an unknown one like the other methods do.
+	_, err := s.credentialRepository.ByID(ctx, credID)
+	if err != nil {
+		return err
+	}
+
+	err = s.roleRepository.Assign(ctx, credID, name)
+	if err != nil {
+		return err
+	}
+
+	s.audit(ctx, auth.AuditRoleAssign, credID, name)
+
+	return nil
+}
+
+// UnassignRole removes a Role from a Credential.
+func (s *AdminService) UnassignRole(ctx context.Context, credID int, name string) error {
+	_, err := s.credentialRepository.ByID(ctx, credID)
+	if err != nil {
+		return err
+	}
+
+	err = s.roleRepository.Unassign(ctx, credID, name)
+	if err != nil {
+		return err
+	}
+
+	s.audit(ctx, auth.AuditRoleUnassign, credID, name)
+
+	return nil
+}
+
+// Tenants retrieves all Tenants.
+func (s *AdminService) Tenants(ctx context.Context) ([]auth.Tenant, error) {
+	return s.tenantRepository.Tenants(ctx)
+}
+
+// SaveTenant creates or updates a Tenant.
+func (s *AdminService) SaveTenant(ctx context.Context, t *auth.Tenant) error {
+	if !tenantIDRe.MatchString(t.ID) {
+		return auth.NewError(auth.ErrValidation, "Bad tenant id, lowercase letters, digits and _.- expected.")
+	}
+
+	p := t.Settings.PasswordPolicy
+	if p.MinLength < 0 || p.MaxLength < 0 || (p.MaxLength > 0 && p.MaxLength < p.MinLength) {
+		return auth.NewError(auth.ErrValidation, "Bad password policy length.")
+	}
+
+	if t.Settings.TokenTTL < 0 {
+		return auth.NewError(auth.ErrValidation, "Bad token TTL.")
+	}
+
+	if t.Settings.InvitationTTL < 0 {
+		return auth.NewError(auth.ErrValidation, "Bad invitation TTL.")
+	}
+
+	err := validateProfileSchema(t.Settings.ProfileSchema)
+	if err != nil {
+		return err
+	}
+
+	for i, h := range t.Hosts {
+		t.Hosts[i] = strings.ToLower(h)
+	}
+
+	t.CreatedAt = s.nowFn()
+	t.UpdatedAt = s.nowFn()
+
+	err = s.tenantRepository.Save(ctx, t)
+	if err != nil {
+		return err
+	}
+
+	s.audit(ctx, auth.AuditTenantSave, 0, t.ID)
+
+	return nil
+}
+
+// ImportCredentials creates Credentials of another system keeping their password hashes,
+// which are upgraded on the first login. Bad records and existing emails are skipped and reported in the result,
+// the import stops on other errors.
+func (s *AdminService) ImportCredentials(ctx context.Context, records []auth.ImportRecord) (auth.ImportResult, error) {
+	var result auth.ImportResult
+
+	for _, r := range records {
+		err := s.importCredential(ctx, r)
+		if err == nil {
+			result.Created++
+			continue
+		}
+
+		if auth.ErrorHas(err, auth.ErrValidation, auth.ErrEmailExists) == nil {
+			return result, err
+		}
+
+		result.Errors = append(result.Errors, auth.ImportError{Line: r.Line, Email: r.Email, Err: err})
+	}
+
+	return result, nil
+}
+
+func (s *AdminService) importCredential(ctx context.Context, r auth.ImportRecord) error {
+	email := strings.TrimSpace(r.Email)
+	if !strings.Contains(email, "@") {
+		return auth.NewError(auth.ErrValidation, "Bad email.")
+	}
+
+	hash := []byte(r.Hash)
+
+	switch r.Algorithm {
+	case password.Bcrypt, password.Argon2id, password.Scrypt:
+		// Imported as is, Encode rejects costs over the limits of verified hashes.
+	default:
+		var err error
+
+		hash, err = hex.DecodeString(r.Hash)
+		if err != nil {
+			return auth.NewError(auth.ErrValidation, "Bad hash, hex expected.")
+		}
+	}
+
+	encoded, err := password.Encode(password.Foreign{
+		Algorithm:    r.Algorithm,
+		Hash:         hash,
+		Salt:         []byte(r.Salt),
+		Iterations:   r.Iterations,
+		SaltPosition: r.SaltPosition,
+	})
+	if err != nil {
+		return auth.WrapError(err, auth.ErrValidation, "Bad hash: "+err.Error()+".")
+	}
+
+	if len(encoded) > maxHashLength {
+		return auth.NewError(auth.ErrValidation, "Hash or salt is too long.")
+	}
+
+	_, err = s.credentialRepository.ByEmail(ctx, email)
+	if err == nil {
+		return auth.NewError(auth.ErrEmailExists, "User with this email already exists.")
+	}
+
+	if auth.ErrorCode(err) != auth.ErrCredNotFound {
+		return err
+	}
+
+	cred := auth.Credential{
+		Email:         email,
+		EmailVerified: r.EmailVerified,
+		Password:      encoded,
+		Status:        auth.StatusActive,
+		CreatedAt:     s.nowFn(),
+		UpdatedAt:     s.nowFn(),
+	}
+
+	err = s.newToken(ctx, &cred)
+	if err != nil {
+		return err
+	}
+
+	err = s.credentialRepository.Create(ctx, &cred)
+	if err != nil {
+		return err
+	}
+
+	s.audit(ctx, auth.AuditImport, cred.ID, r.Algorithm)
+
+	return nil
+}
+
+// newToken replaces the token of the Credential.
+func (s *AdminService) newToken(ctx context.Context, cred *auth.Credential) error {
+	token, err := s.generatorFn(tokenLength)
+	if err != nil {
+		return err
+	}
+
+	cred.Token = token
+	cred.TokenExpiresAt = tokenExpiresAt(s.nowFn(), auth.TenantFromContext(ctx).Settings.TokenTTL)
+
+	return nil
+}
+
+// update applies fn to the Credential and saves it recording the event.
+func (s *AdminService) update(
+	ctx context.Context,
+	id int,
+	event string,
+	fn func(cred *auth.Credential) error,
+) (auth.Credential, error) {
+	cred, err := s.credentialRepository.ByID(ctx, id)
+	if err != nil {
+		return auth.Credential{}, err
+	}
+
+	err = fn(&cred)
+	if err != nil {
+		return auth.Credential{}, err
+	}
+
+	cred.UpdatedAt = s.nowFn()
+
+	err = s.credentialRepository.Update(ctx, &cred)
+	if err != nil {
+		return auth.Credential{}, err
+	}
+
+	s.audit(ctx, event, id, "")
+
+	return cred, nil
+}
+
+func (s *AdminService) audit(ctx context.Context, event string, credID int, reason string) {
+	appendAudit(ctx, s.auditLog, &auth.AuditEntry{
+		CredentialID: credID,
+		Event:        event,
+		Reason:       reason,
+		CreatedAt:    s.nowFn(),
+	})
+}
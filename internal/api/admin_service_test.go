@@ -0,0 +1,343 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+
+	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/mock"
+	"github.com/kl09/auth-go/internal/password"
+)
+
+func TestAdminService_Actions(t *testing.T) {
+	stored := auth.Credential{
+		ID:                       1,
+		Password:                 "hash",
+		Token:                    "token",
+		Email:                    "example@example.org",
+		EmailTmp:                 "new@example.org",
+		VerificationCode:         "1234",
+		VerificationCodeAttempts: 2,
+	}
+
+	cases := []struct {
+		name      string
+		action    func(s *AdminService) (auth.Credential, error)
+		want      auth.Credential
+		wantEvent string
+	}{
+		{
+			name: "verify email",
+			action: func(s *AdminService) (auth.Credential, error) {
+				return s.VerifyEmail(context.Background(), 1)
+			},
+			want: auth.Credential{
+				ID:            1,
+				Password:      "hash",
+				Token:         "token",
+				Email:         "new@example.org",
+				EmailVerified: true,
+				UpdatedAt:     now,
+			},
+			wantEvent: auth.AuditEmailVerify,
+		},
+		{
+			name: "disable",
+			action: func(s *AdminService) (auth.Credential, error) {
+				return s.SetStatus(context.Background(), 1, auth.StatusDisabled, "abuse", time.Time{})
+			},
+			want: auth.Credential{
+				ID:                       1,
+				Password:                 "hash",
+				Token:                    "token",
+				Email:                    "example@example.org",
+				EmailTmp:                 "new@example.org",
+				VerificationCode:         "1234",
+				VerificationCodeAttempts: 2,
+				Status:                   auth.StatusDisabled,
+				StatusReason:             "abuse",
+				UpdatedAt:                now,
+			},
+			wantEvent: auth.AuditDisable,
+		},
+		{
+			name: "suspend",
+			action: func(s *AdminService) (auth.Credential, error) {
+				return s.SetStatus(context.Background(), 1, auth.StatusSuspended, "spam", now.Add(time.Hour))
+			},
+			want: auth.Credential{
+				ID:                       1,
+				Password:                 "hash",
+				Token:                    "token",
+				Email:                    "example@example.org",
+				EmailTmp:                 "new@example.org",
+				VerificationCode:         "1234",
+				VerificationCodeAttempts: 2,
+				Status:                   auth.StatusSuspended,
+				StatusReason:             "spam",
+				StatusUntil:              now.Add(time.Hour),
+				UpdatedAt:                now,
+			},
+			wantEvent: auth.AuditSuspend,
+		},
+		{
+			name: "revoke sessions",
+			action: func(s *AdminService) (auth.Credential, error) {
+				return s.RevokeSessions(context.Background(), 1)
+			},
+			want: auth.Credential{
+				ID:                       1,
+				Password:                 "hash",
+				Token:                    "new_token",
+				Email:                    "example@example.org",
+				EmailTmp:                 "new@example.org",
+				VerificationCode:         "1234",
+				VerificationCodeAttempts: 2,
+				UpdatedAt:                now,
+			},
+			wantEvent: auth.AuditRevoke,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			credRep := &mock.CredentialRepositoryMock{
+				ByIDFunc: func(ctx context.Context, id int) (auth.Credential, error) {
+					return stored, nil
+				},
+				UpdateFunc: func(ctx context.Context, c *auth.Credential) error {
+					return nil
+				},
+			}
+			auditLog := &mock.AuditLogMock{
+				AppendFunc: func(ctx context.Context, e *auth.AuditEntry) error {
+					return nil
+				},
+			}
+
+			s := NewAdminService(credRep, nil, nil, auditLog, nowFunc, func(n int) (string, error) {
+				return "new_token", nil
+			}, testHasher)
+
+			cred, err := tc.action(s)
+			require.Nil(t, err)
+
+			if diff := cmp.Diff(tc.want, cred); diff != "" {
+				t.Fatal(diff)
+			}
+
+			require.Len(t, credRep.UpdateCalls(), 1)
+
+			if diff := cmp.Diff(tc.want, *credRep.UpdateCalls()[0].C); diff != "" {
+				t.Fatal(diff)
+			}
+
+			require.Len(t, auditLog.AppendCalls(), 1)
+			require.Equal(t, tc.wantEvent, auditLog.AppendCalls()[0].E.Event)
+			require.Equal(t, 1, auditLog.AppendCalls()[0].E.CredentialID)
+		})
+	}
+}
+
+func TestAdminService_ResetPassword(t *testing.T) {
+	credRep := &mock.CredentialRepositoryMock{
+		ByIDFunc: func(ctx context.Context, id int) (auth.Credential, error) {
+			return auth.Credential{ID: 1, Password: "hash", Token: "token"}, nil
+		},
+		UpdateFunc: func(ctx context.Context, c *auth.Credential) error {
+			return nil
+		},
+	}
+	auditLog := &mock.AuditLogMock{
+		AppendFunc: func(ctx context.Context, e *auth.AuditEntry) error {
+			return nil
+		},
+	}
+
+	s := NewAdminService(credRep, nil, nil, auditLog, nowFunc, func(n int) (string, error) {
+		return "new_token", nil
+	}, testHasher)
+
+	cred, err := s.ResetPassword(ContextWithActor(context.Background(), auth.ActorAdmin), 1, "password_12345")
+	require.Nil(t, err)
+
+	require.True(t, passwordMatches(cred.Password, "password_12345"))
+	require.Equal(t, "new_token", cred.Token)
+
+	require.Len(t, auditLog.AppendCalls(), 1)
+	require.Equal(t, auth.AuditPasswordChange, auditLog.AppendCalls()[0].E.Event)
+	require.Equal(t, auth.ActorAdmin, auditLog.AppendCalls()[0].E.Actor)
+}
+
+func TestAdminService_NotFound(t *testing.T) {
+	credRep := &mock.CredentialRepositoryMock{
+		ByIDFunc: func(ctx context.Context, id int) (auth.Credential, error) {
+			return auth.Credential{}, auth.NewError(auth.ErrCredNotFound, "Credential not found")
+		},
+		DeleteFunc: func(ctx context.Context, id int) error {
+			return auth.NewError(auth.ErrCredNotFound, "Credential not found")
+		},
+	}
+	auditLog := &mock.AuditLogMock{}
+
+	s := NewAdminService(credRep, nil, nil, auditLog, nowFunc, nil, testHasher)
+
+	_, err := s.SetStatus(context.Background(), 1, auth.StatusDisabled, "", time.Time{})
+	require.Equal(t, auth.ErrCredNotFound, auth.ErrorCode(err))
+
+	err = s.Delete(context.Background(), 1)
+	require.Equal(t, auth.ErrCredNotFound, auth.ErrorCode(err))
+
+	require.Empty(t, credRep.UpdateCalls())
+	require.Empty(t, auditLog.AppendCalls())
+}
+
+func TestAdminService_SetStatus_Validation(t *testing.T) {
+	cases := []struct {
+		name    string
+		status  string
+		until   time.Time
+		wantErr error
+	}{
+		{
+			name:    "unknown status",
+			status:  "banned",
+			wantErr: auth.NewError(auth.ErrValidation, "Unknown status."),
+		},
+		{
+			name:    "until without suspension",
+			status:  auth.StatusDisabled,
+			until:   now.Add(time.Hour),
+			wantErr: auth.NewError(auth.ErrValidation, "Until is allowed only for suspension."),
+		},
+		{
+			name:    "until in the past",
+			status:  auth.StatusSuspended,
+			until:   now,
+			wantErr: auth.NewError(auth.ErrValidation, "Until must be in the future."),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			credRep := &mock.CredentialRepositoryMock{}
+
+			s := NewAdminService(credRep, nil, nil, &mock.AuditLogMock{}, nowFunc, nil, testHasher)
+
+			_, err := s.SetStatus(context.Background(), 1, tc.status, "", tc.until)
+			require.Equal(t, tc.wantErr, err)
+			require.Empty(t, credRep.ByIDCalls())
+		})
+	}
+}
+
+func TestAdminService_ImportCredentials(t *testing.T) {
+	records := []auth.ImportRecord{
+		{
+			Line:       2,
+			Email:      "pbkdf2@example.org",
+			Algorithm:  password.PBKDF2SHA256,
+			Hash:       "ca7afb8c4555ea1faa0e152e411b522549d84dc7d59036ab3a9b72e5d88658e4",
+			Salt:       "salt",
+			Iterations: 1000,
+		},
+		{
+			Line:          3,
+			Email:         "md5@example.org",
+			EmailVerified: true,
+			Algorithm:     password.SaltedMD5,
+			Hash:          "527ca2d6f2e1fc83ec00a43e088d29ce",
+			Salt:          "pepper",
+		},
+		{Line: 4, Email: "exists@example.org", Algorithm: password.SaltedMD5, Hash: "527ca2d6f2e1fc83ec00a43e088d29ce"},
+		{Line: 5, Email: "bad-hash@example.org", Algorithm: password.SaltedSHA1, Hash: "xyz"},
+		{Line: 6, Email: "bad-email", Algorithm: password.SaltedMD5, Hash: "527ca2d6f2e1fc83ec00a43e088d29ce"},
+		{Line: 7, Email: "argon2id@example.org", Algorithm: password.Argon2id, Hash: "$argon2id$v=19$m=4194304,t=1,p=1$c2FsdA$a2V5"},
+		{Line: 8, Email: "bcrypt@example.org", Algorithm: password.Bcrypt, Hash: "$2a$31$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy"},
+	}
+
+	var created []auth.Credential
+
+	credRep := &mock.CredentialRepositoryMock{
+		ByEmailFunc: func(ctx context.Context, email string) (auth.Credential, error) {
+			if email == "exists@example.org" {
+				return auth.Credential{ID: 1, Email: email}, nil
+			}
+
+			return auth.Credential{}, auth.NewError(auth.ErrCredNotFound, "Credential not found")
+		},
+		CreateFunc: func(ctx context.Context, c *auth.Credential) error {
+			c.ID = 10 + len(created)
+			created = append(created, *c)
+
+			return nil
+		},
+	}
+	auditLog := &mock.AuditLogMock{
+		AppendFunc: func(ctx context.Context, e *auth.AuditEntry) error {
+			return nil
+		},
+	}
+
+	s := NewAdminService(credRep, nil, nil, auditLog, nowFunc, func(n int) (string, error) {
+		return "token", nil
+	}, testHasher)
+
+	result, err := s.ImportCredentials(ContextWithActor(context.Background(), auth.ActorAdmin), records)
+	require.Nil(t, err)
+
+	require.Equal(t, 2, result.Created)
+	require.Len(t, result.Errors, 5)
+	require.Equal(t, []auth.ImportError{
+		{Line: 4, Email: "exists@example.org", Err: auth.NewError(auth.ErrEmailExists, "User with this email already exists.")},
+		{Line: 5, Email: "bad-hash@example.org", Err: auth.NewError(auth.ErrValidation, "Bad hash, hex expected.")},
+		{Line: 6, Email: "bad-email", Err: auth.NewError(auth.ErrValidation, "Bad email.")},
+	}, result.Errors[:3])
+
+	// Costs over the limits of verified hashes are rejected.
+	for i, line := range []int{7, 8} {
+		require.Equal(t, line, result.Errors[3+i].Line)
+		require.Equal(t, auth.ErrValidation, auth.ErrorCode(result.Errors[3+i].Err))
+	}
+
+	require.Len(t, created, 2)
+	require.Equal(t, "$pbkdf2-sha256$i=1000$c2FsdA$ynr7jEVV6h+qDhUuQRtSJUnYTcfVkDarOpty5diGWOQ", created[0].Password)
+	require.Equal(t, auth.StatusActive, created[0].Status)
+	require.Equal(t, "token", created[0].Token)
+	require.Equal(t, now, created[0].CreatedAt)
+	require.True(t, created[1].EmailVerified)
+
+	ok, rehash := testHasher.Verify(created[1].Password, "12345")
+	require.True(t, ok)
+	require.True(t, rehash)
+
+	calls := auditLog.AppendCalls()
+	require.Len(t, calls, 2)
+	require.Equal(t, auth.AuditImport, calls[0].E.Event)
+	require.Equal(t, 10, calls[0].E.CredentialID)
+	require.Equal(t, password.PBKDF2SHA256, calls[0].E.Reason)
+	require.Equal(t, auth.ActorAdmin, calls[0].E.Actor)
+}
+
+func TestAdminService_ImportCredentials_Abort(t *testing.T) {
+	credRep := &mock.CredentialRepositoryMock{
+		ByEmailFunc: func(ctx context.Context, email string) (auth.Credential, error) {
+			return auth.Credential{}, errors.New("db error")
+		},
+	}
+
+	s := NewAdminService(credRep, nil, nil, &mock.AuditLogMock{}, nowFunc, nil, testHasher)
+
+	result, err := s.ImportCredentials(context.Background(), []auth.ImportRecord{
+		{Line: 1, Email: "a@example.org", Algorithm: password.SaltedMD5, Hash: "527ca2d6f2e1fc83ec00a43e088d29ce"},
+		{Line: 2, Email: "b@example.org", Algorithm: password.SaltedMD5, Hash: "527ca2d6f2e1fc83ec00a43e088d29ce"},
+	})
+	require.EqualError(t, err, "db error")
+	require.Equal(t, auth.ImportResult{}, result)
+	require.Len(t, credRep.ByEmailCalls(), 1)
+}
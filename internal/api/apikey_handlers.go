@@ -0,0 +1,119 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	auth "github.com/kl09/auth-go"
+)
+
+type apiKeyResponse struct {
+	ID         int                  `json:"id"`
+	Name       string               `json:"name"`
+	Prefix     string               `json:"prefix"`
+	Scopes     []permissionResponse `json:"scopes"`
+	ExpiresAt  *time.Time           `json:"expires_at"`
+	LastUsedAt *time.Time           `json:"last_used_at"`
+	CreatedAt  time.Time            `json:"created_at"`
+}
+
+func apiKeyToResponse(k auth.APIKey) apiKeyResponse {
+	resp := apiKeyResponse{
+		ID:        k.ID,
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		Scopes:    permissionsToResponse(k.Scopes),
+		CreatedAt: k.CreatedAt,
+	}
+
+	if !k.ExpiresAt.IsZero() {
+		resp.ExpiresAt = &k.ExpiresAt
+	}
+
+	if !k.LastUsedAt.IsZero() {
+		resp.LastUsedAt = &k.LastUsedAt
+	}
+
+	return resp
+}
+
+// apiKeys retrieves API keys of the authenticated credential.
+func (r *Router) apiKeys(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	keys, err := r.apiKeyService.APIKeys(ctx, credentialFromContext(ctx).ID)
+	if err != nil {
+		return err
+	}
+
+	resp := struct {
+		APIKeys []apiKeyResponse `json:"api_keys"`
+	}{
+		APIKeys: make([]apiKeyResponse, 0, len(keys)),
+	}
+
+	for _, k := range keys {
+		resp.APIKeys = append(resp.APIKeys, apiKeyToResponse(k))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// createAPIKey creates an API key of the authenticated credential, the key is returned only once.
+func (r *Router) createAPIKey(c echo.Context) error {
+	var request struct {
+		Name      string               `json:"name"`
+		Scopes    []permissionResponse `json:"scopes"`
+		ExpiresAt time.Time            `json:"expires_at"`
+	}
+
+	err := c.Bind(&request)
+	if err != nil {
+		return err
+	}
+
+	k := auth.APIKey{
+		Name:      request.Name,
+		Scopes:    make([]auth.Permission, 0, len(request.Scopes)),
+		ExpiresAt: request.ExpiresAt,
+	}
+
+	for _, s := range request.Scopes {
+		k.Scopes = append(k.Scopes, auth.Permission(s))
+	}
+
+	ctx := c.Request().Context()
+
+	key, err := r.apiKeyService.Create(ctx, credentialFromContext(ctx).ID, &k)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, struct {
+		apiKeyResponse
+		Key string `json:"key"`
+	}{
+		apiKeyResponse: apiKeyToResponse(k),
+		Key:            key,
+	})
+}
+
+// deleteAPIKey deletes an API key of the authenticated credential.
+func (r *Router) deleteAPIKey(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad id.")
+	}
+
+	ctx := c.Request().Context()
+
+	err = r.apiKeyService.Delete(ctx, credentialFromContext(ctx).ID, id)
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
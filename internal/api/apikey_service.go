@@ -0,0 +1,192 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/logging"
+)
+
+const (
+	apiKeyPrefix       = "ak"
+	apiKeyPrefixLength = 12
+	apiKeySecretLength = 40
+	maxAPIKeyName      = 64
+	// apiKeyTouchPeriod limits writes of the last usage time of frequently used keys.
+	apiKeyTouchPeriod = time.Minute
+)
+
+// APIKeyService is a service for API keys of credentials.
+type APIKeyService struct {
+	apiKeyRepository     auth.APIKeyRepository
+	credentialRepository auth.CredentialRepository
+	auditLog             auth.AuditLog
+	nowFn                func() time.Time
+	generatorFn          func(n int) (string, error)
+}
+
+// NewAPIKeyService creates an APIKeyService.
+func NewAPIKeyService(
+	keys auth.APIKeyRepository,
+	r auth.CredentialRepository,
+	a auth.AuditLog,
+	nowFn func() time.Time,
+	generatorFn func(n int) (string, error),
+) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepository:     keys,
+		credentialRepository: r,
+		auditLog:             a,
+		nowFn:                nowFn,
+		generatorFn:          generatorFn,
+	}
+}
+
+// Create creates a new APIKey of the Credential and returns the key "ak_<prefix>_<secret>",
+// only the prefix and the hash of the key are stored.
+func (s *APIKeyService) Create(ctx context.Context, credID int, k *auth.APIKey) (string, error) {
+	k.Name = strings.TrimSpace(k.Name)
+	if k.Name == "" || len(k.Name) > maxAPIKeyName {
+		return "", auth.NewError(auth.ErrValidation, "API key name must be from 1 to 64 characters long.")
+	}
+
+	if len(k.Scopes) == 0 {
+		return "", auth.NewError(auth.ErrValidation, "API key must have scopes.")
+	}
+
+	err := validatePermissions(k.Scopes)
+	if err != nil {
+		return "", err
+	}
+
+	if !k.ExpiresAt.IsZero() && !k.ExpiresAt.After(s.nowFn()) {
+		return "", auth.NewError(auth.ErrValidation, "Expiration must be in the future.")
+	}
+
+	prefix, err := s.generatorFn(apiKeyPrefixLength)
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := s.generatorFn(apiKeySecretLength)
+	if err != nil {
+		return "", err
+	}
+
+	key := apiKeyPrefix + "_" + prefix + "_" + secret
+
+	k.CredentialID = credID
+	k.Prefix = prefix
+	k.Hash = hashAPIKey(key)
+	k.LastUsedAt = time.Time{}
+	k.CreatedAt = s.nowFn()
+
+	err = s.apiKeyRepository.Create(ctx, k)
+	if err != nil {
+		return "", err
+	}
+
+	s.audit(ctx, auth.AuditAPIKeyCreate, credID, k.Prefix)
+
+	return key, nil
+}
+
+// APIKeys retrieves APIKeys of the Credential.
+func (s *APIKeyService) APIKeys(ctx context.Context, credID int) ([]auth.APIKey, error) {
+	return s.apiKeyRepository.ByCredential(ctx, credID)
+}
+
+// Delete deletes an APIKey of the Credential.
+func (s *APIKeyService) Delete(ctx context.Context, credID, id int) error {
+	err := s.apiKeyRepository.Delete(ctx, credID, id)
+	if err != nil {
+		return err
+	}
+
+	s.audit(ctx, auth.AuditAPIKeyDelete, credID, "")
+
+	return nil
+}
+
+// Authenticate retrieves the active Credential and the APIKey by the key.
+func (s *APIKeyService) Authenticate(ctx context.Context, key string) (auth.Credential, auth.APIKey, error) {
+	parts := strings.Split(key, "_")
+	if len(parts) != 3 || parts[0] != apiKeyPrefix {
+		return auth.Credential{}, auth.APIKey{}, auth.NewError(auth.ErrAuth, "Invalid API key.")
+	}
+
+	k, err := s.apiKeyRepository.ByPrefix(ctx, parts[1])
+	if err != nil {
+		if auth.ErrorHas(err, auth.ErrAPIKeyNotFound) != nil {
+			return auth.Credential{}, auth.APIKey{}, auth.WrapError(err, auth.ErrAuth, "Invalid API key.")
+		}
+
+		return auth.Credential{}, auth.APIKey{}, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashAPIKey(key)), []byte(k.Hash)) != 1 {
+		s.audit(ctx, auth.AuditAPIKeyUse, k.CredentialID, auth.ErrAuth)
+
+		return auth.Credential{}, auth.APIKey{}, auth.NewError(auth.ErrAuth, "Invalid API key.")
+	}
+
+	now := s.nowFn()
+
+	if !k.ExpiresAt.IsZero() && !now.Before(k.ExpiresAt) {
+		s.audit(ctx, auth.AuditAPIKeyUse, k.CredentialID, auth.ErrTokenExpired)
+
+		return auth.Credential{}, auth.APIKey{}, auth.NewError(auth.ErrTokenExpired, "API key expired")
+	}
+
+	cred, err := s.credentialRepository.ByID(ctx, k.CredentialID)
+	if err != nil {
+		return auth.Credential{}, auth.APIKey{}, err
+	}
+
+	logging.SetCredentialID(ctx, cred.ID)
+
+	err = statusError(cred, now)
+	if err != nil {
+		if !purgeable(err) {
+			s.audit(ctx, auth.AuditAPIKeyUse, cred.ID, auth.ErrorCode(err))
+		}
+
+		return auth.Credential{}, auth.APIKey{}, err
+	}
+
+	if now.Sub(k.LastUsedAt) >= apiKeyTouchPeriod {
+		k.LastUsedAt = now
+
+		// A failed touch is logged and doesn't fail the request.
+		if err = s.apiKeyRepository.Touch(ctx, k.ID, now); err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Msg("api key touch failed")
+		}
+	}
+
+	s.audit(ctx, auth.AuditAPIKeyUse, cred.ID, "")
+
+	return cred, k, nil
+}
+
+// audit records the event, the reason is the key prefix or an error code.
+func (s *APIKeyService) audit(ctx context.Context, event string, credID int, reason string) {
+	appendAudit(ctx, s.auditLog, &auth.AuditEntry{
+		CredentialID: credID,
+		Event:        event,
+		Reason:       reason,
+		CreatedAt:    s.nowFn(),
+	})
+}
+
+// hashAPIKey returns a hex SHA-256 hash of the key, keys are random so a slow hash isn't needed.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
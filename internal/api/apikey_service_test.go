@@ -0,0 +1,342 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/mock"
+)
+
+// testAPIKey is a key generated by the APIKeyService with the generator of the tests.
+const testAPIKey = "ak_gen_gen"
+
+func TestAPIKeyService_Create(t *testing.T) {
+	cases := []struct {
+		name    string
+		key     auth.APIKey
+		wantErr error
+	}{
+		{
+			name: "success",
+			key: auth.APIKey{
+				Name:      " deploy ",
+				Scopes:    []auth.Permission{{Action: "read", Resource: "orgs"}},
+				ExpiresAt: now.Add(time.Hour),
+			},
+		},
+		{
+			name:    "error - no name",
+			key:     auth.APIKey{Scopes: []auth.Permission{{Action: "read", Resource: "orgs"}}},
+			wantErr: auth.NewError(auth.ErrValidation, "API key name must be from 1 to 64 characters long."),
+		},
+		{
+			name:    "error - no scopes",
+			key:     auth.APIKey{Name: "deploy"},
+			wantErr: auth.NewError(auth.ErrValidation, "API key must have scopes."),
+		},
+		{
+			name:    "error - bad scope",
+			key:     auth.APIKey{Name: "deploy", Scopes: []auth.Permission{{Action: "read"}}},
+			wantErr: auth.NewError(auth.ErrValidation, "Bad permission resource."),
+		},
+		{
+			name: "error - expired",
+			key: auth.APIKey{
+				Name:      "deploy",
+				Scopes:    []auth.Permission{{Action: "read", Resource: "orgs"}},
+				ExpiresAt: now,
+			},
+			wantErr: auth.NewError(auth.ErrValidation, "Expiration must be in the future."),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			keyRep := &mock.APIKeyRepositoryMock{
+				CreateFunc: func(ctx context.Context, k *auth.APIKey) error {
+					k.ID = 1
+					return nil
+				},
+			}
+
+			s := NewAPIKeyService(keyRep, nil, noopAuditLog{}, nowFunc, func(n int) (string, error) {
+				return "gen", nil
+			})
+
+			k := tc.key
+			key, err := s.Create(context.Background(), 2, &k)
+
+			require.Equal(t, tc.wantErr, err)
+
+			if tc.wantErr != nil {
+				require.Empty(t, keyRep.CreateCalls())
+				return
+			}
+
+			require.Equal(t, testAPIKey, key)
+
+			want := auth.APIKey{
+				ID:           1,
+				CredentialID: 2,
+				Name:         "deploy",
+				Prefix:       "gen",
+				Hash:         hashAPIKey(testAPIKey),
+				Scopes:       tc.key.Scopes,
+				ExpiresAt:    tc.key.ExpiresAt,
+				CreatedAt:    now,
+			}
+
+			if diff := cmp.Diff(want, k); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	cases := []struct {
+		name      string
+		key       string
+		stored    auth.APIKey
+		status    string
+		wantErr   error
+		wantTouch bool
+	}{
+		{
+			name:      "success",
+			key:       testAPIKey,
+			stored:    auth.APIKey{ID: 1, CredentialID: 2, Prefix: "gen", Hash: hashAPIKey(testAPIKey)},
+			wantTouch: true,
+		},
+		{
+			name:   "success - recently used",
+			key:    testAPIKey,
+			stored: auth.APIKey{ID: 1, CredentialID: 2, Prefix: "gen", Hash: hashAPIKey(testAPIKey), LastUsedAt: now.Add(-time.Second)},
+		},
+		{
+			name:    "error - wrong secret",
+			key:     "ak_gen_other",
+			stored:  auth.APIKey{ID: 1, CredentialID: 2, Prefix: "gen", Hash: hashAPIKey(testAPIKey)},
+			wantErr: auth.NewError(auth.ErrAuth, "Invalid API key."),
+		},
+		{
+			name:    "error - malformed",
+			key:     "gen",
+			wantErr: auth.NewError(auth.ErrAuth, "Invalid API key."),
+		},
+		{
+			name:    "error - expired",
+			key:     testAPIKey,
+			stored:  auth.APIKey{ID: 1, CredentialID: 2, Prefix: "gen", Hash: hashAPIKey(testAPIKey), ExpiresAt: now},
+			wantErr: auth.NewError(auth.ErrTokenExpired, "API key expired"),
+		},
+		{
+			name:    "error - disabled credential",
+			key:     testAPIKey,
+			stored:  auth.APIKey{ID: 1, CredentialID: 2, Prefix: "gen", Hash: hashAPIKey(testAPIKey)},
+			status:  auth.StatusDisabled,
+			wantErr: auth.NewError(auth.ErrCredDisabled, "Credential is disabled"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			keyRep := &mock.APIKeyRepositoryMock{
+				ByPrefixFunc: func(ctx context.Context, prefix string) (auth.APIKey, error) {
+					return tc.stored, nil
+				},
+				TouchFunc: func(ctx context.Context, id int, at time.Time) error {
+					return nil
+				},
+			}
+			credRep := &mock.CredentialRepositoryMock{
+				ByIDFunc: func(ctx context.Context, id int) (auth.Credential, error) {
+					return auth.Credential{ID: id, Status: tc.status}, nil
+				},
+			}
+
+			s := NewAPIKeyService(keyRep, credRep, noopAuditLog{}, nowFunc, nil)
+
+			cred, k, err := s.Authenticate(context.Background(), tc.key)
+
+			require.Equal(t, tc.wantErr, err)
+			require.Equal(t, tc.wantTouch, len(keyRep.TouchCalls()) == 1)
+
+			if tc.wantErr != nil {
+				return
+			}
+
+			require.Equal(t, 2, cred.ID)
+			require.Equal(t, 1, k.ID)
+		})
+	}
+}
+
+func TestAPIKey_Handlers(t *testing.T) {
+	cases := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		header     string
+		value      string
+		wantResp   string
+		wantStatus int
+	}{
+		{
+			name:       "api key with scope",
+			method:     http.MethodGet,
+			path:       "/v1/orgs",
+			header:     HeaderAPIKey,
+			value:      testAPIKey,
+			wantResp:   `{"organizations":[]}` + "\n",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "error - api key without scope",
+			method:     http.MethodPost,
+			path:       "/v1/orgs",
+			body:       `{"name":"Acme"}`,
+			header:     HeaderAPIKey,
+			value:      testAPIKey,
+			wantResp:   `{"error":{"code":"permission_denied","message":"API key scopes don't allow write on orgs."}}` + "\n",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "error - api key without authorize scope",
+			method:     http.MethodPost,
+			path:       "/v1/authorize",
+			body:       `{"action":"read","resource":"orgs"}`,
+			header:     HeaderAPIKey,
+			value:      testAPIKey,
+			wantResp:   `{"error":{"code":"permission_denied","message":"API key scopes don't allow read on authorize."}}` + "\n",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "error - invalid api key",
+			method:     http.MethodGet,
+			path:       "/v1/orgs",
+			header:     HeaderAPIKey,
+			value:      "ak_gen_other",
+			wantResp:   `{"error":{"code":"auth_failed","message":"Invalid API key."}}` + "\n",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "error - api key manages api keys",
+			method:     http.MethodGet,
+			path:       "/v1/me/api-keys",
+			header:     HeaderAPIKey,
+			value:      testAPIKey,
+			wantResp:   `{"error":{"code":"permission_denied","message":"Token is required."}}` + "\n",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:   "list",
+			method: http.MethodGet,
+			path:   "/v1/me/api-keys",
+			header: echo.HeaderAuthorization,
+			value:  "Bearer token",
+			wantResp: `{"api_keys":[{"id":1,"name":"deploy","prefix":"gen","scopes":[{"action":"read","resource":"orgs"}],` +
+				`"expires_at":null,"last_used_at":"2020-04-15T10:11:12Z","created_at":"2020-04-15T10:11:12Z"}]}` + "\n",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "create",
+			method: http.MethodPost,
+			path:   "/v1/me/api-keys",
+			body:   `{"name":"ci","scopes":[{"action":"*","resource":"orgs"}],"expires_at":"2030-01-01T00:00:00Z"}`,
+			header: echo.HeaderAuthorization,
+			value:  "Bearer token",
+			wantResp: `{"id":2,"name":"ci","prefix":"gen","scopes":[{"action":"*","resource":"orgs"}],` +
+				`"expires_at":"2030-01-01T00:00:00Z","last_used_at":null,"created_at":"2020-04-15T10:11:12Z","key":"ak_gen_gen"}` + "\n",
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "delete",
+			method:     http.MethodDelete,
+			path:       "/v1/me/api-keys/1",
+			header:     echo.HeaderAuthorization,
+			value:      "Bearer token",
+			wantStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			stored := auth.APIKey{
+				ID:           1,
+				CredentialID: 1,
+				Name:         "deploy",
+				Prefix:       "gen",
+				Hash:         hashAPIKey(testAPIKey),
+				Scopes:       []auth.Permission{{Action: "read", Resource: "orgs"}},
+				LastUsedAt:   now,
+				CreatedAt:    now,
+			}
+
+			credRep := &mock.CredentialRepositoryMock{
+				ByTokenFunc: func(ctx context.Context, token string) (auth.Credential, error) {
+					return auth.Credential{ID: 1, Token: token}, nil
+				},
+				ByIDFunc: func(ctx context.Context, id int) (auth.Credential, error) {
+					return auth.Credential{ID: id}, nil
+				},
+			}
+			keyRep := &mock.APIKeyRepositoryMock{
+				ByPrefixFunc: func(ctx context.Context, prefix string) (auth.APIKey, error) {
+					return stored, nil
+				},
+				ByCredentialFunc: func(ctx context.Context, credID int) ([]auth.APIKey, error) {
+					return []auth.APIKey{stored}, nil
+				},
+				CreateFunc: func(ctx context.Context, k *auth.APIKey) error {
+					k.ID = 2
+					return nil
+				},
+				DeleteFunc: func(ctx context.Context, credID, id int) error {
+					return nil
+				},
+			}
+			orgRep := &mock.OrganizationRepositoryMock{
+				ByCredentialFunc: func(ctx context.Context, credID int) ([]auth.Membership, error) {
+					return []auth.Membership{}, nil
+				},
+			}
+
+			credService := NewCredentialService(credRep, nowFunc, nil)
+			generatorFn := func(n int) (string, error) {
+				return "gen", nil
+			}
+
+			h := NewRouter(
+				credService,
+				WithAPIKeys(NewAPIKeyService(keyRep, credRep, noopAuditLog{}, nowFunc, generatorFn)),
+				WithOrganizations(NewOrganizationService(orgRep, credRep, credService, noopAuditLog{}, nowFunc, invitationKey)),
+			).Handler().Server.Handler
+
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(tc.header, tc.value)
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if diff := cmp.Diff(tc.wantStatus, rec.Code); diff != "" {
+				t.Error(diff)
+			}
+
+			if diff := cmp.Diff(tc.wantResp, rec.Body.String()); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+
+	auth "github.com/kl09/auth-go"
+)
+
+type (
+	clientInfoKey struct{}
+	actorKey      struct{}
+)
+
+// clientInfo describes the client making a request.
+type clientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// clientInfoMiddleware puts the client info into the request context for the audit log.
+func clientInfoMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		ctx := context.WithValue(req.Context(), clientInfoKey{}, clientInfo{
+			IP:        c.RealIP(),
+			UserAgent: req.UserAgent(),
+		})
+		c.SetRequest(req.WithContext(ctx))
+
+		return next(c)
+	}
+}
+
+// ContextWithActor puts the actor into the context, events are recorded on behalf of the actor.
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+type noopAuditLog struct{}
+
+func (noopAuditLog) Append(context.Context, *auth.AuditEntry) error {
+	return nil
+}
+
+func (noopAuditLog) Find(context.Context, auth.AuditFilter) ([]auth.AuditEntry, error) {
+	return nil, nil
+}
+
+func (noopAuditLog) Anonymize(context.Context, int) error {
+	return nil
+}
+
+// audit records the event, a failed record is logged and doesn't fail the operation.
+func (c *CredentialService) audit(ctx context.Context, event string, credID int, reason string) {
+	appendAudit(ctx, c.auditLog, &auth.AuditEntry{
+		CredentialID: credID,
+		Event:        event,
+		Reason:       reason,
+		CreatedAt:    c.nowFn(),
+	})
+}
+
+// appendAudit appends the entry with the client and the actor taken from the context.
+func appendAudit(ctx context.Context, a auth.AuditLog, e *auth.AuditEntry) {
+	info, _ := ctx.Value(clientInfoKey{}).(clientInfo)
+	e.IP = info.IP
+	e.UserAgent = info.UserAgent
+	e.Actor, _ = ctx.Value(actorKey{}).(string)
+
+	if err := a.Append(ctx, e); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Str("event", e.Event).Msg("audit log append failed")
+	}
+}
@@ -1,35 +1,75 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
 
 	auth "github.com/kl09/auth-go"
 )
 
 type response struct {
-	ID            int       `json:"id"`
-	Token         string    `json:"token"`
-	Email         string    `json:"email"`
-	EmailTmp      string    `json:"email_tmp"`
-	EmailVerified bool      `json:"email_verified"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID             int                  `json:"id"`
+	Token          string               `json:"token"`
+	TokenExpiresAt *time.Time           `json:"token_expires_at,omitempty"`
+	Email          string               `json:"email"`
+	EmailTmp       string               `json:"email_tmp"`
+	EmailVerified  bool                 `json:"email_verified"`
+	ActiveOrgID    int                  `json:"active_organization_id,omitempty"`
+	Roles          []string             `json:"roles"`
+	Permissions    []permissionResponse `json:"permissions"`
+	Profile        *profileResponse     `json:"profile,omitempty"`
+	CreatedAt      time.Time            `json:"created_at"`
+	UpdatedAt      time.Time            `json:"updated_at"`
 }
 
-func credToResponse(cred auth.Credential) response {
-	return response{
+type permissionResponse struct {
+	Action   string `json:"action"`
+	Resource string `json:"resource"`
+}
+
+// credToResponse converts the credential with its roles and their effective permissions.
+func credToResponse(cred auth.Credential, roles []auth.Role) response {
+	resp := response{
 		ID:            cred.ID,
 		Token:         cred.Token,
 		Email:         cred.Email,
 		EmailTmp:      cred.EmailTmp,
 		EmailVerified: cred.EmailVerified,
+		ActiveOrgID:   cred.ActiveOrganizationID,
+		Roles:         make([]string, 0, len(roles)),
+		Permissions:   permissionsToResponse(auth.EffectivePermissions(roles)),
 		CreatedAt:     cred.CreatedAt,
 		UpdatedAt:     cred.UpdatedAt,
 	}
+
+	if !cred.TokenExpiresAt.IsZero() {
+		resp.TokenExpiresAt = &cred.TokenExpiresAt
+	}
+
+	for _, r := range roles {
+		resp.Roles = append(resp.Roles, r.Name)
+	}
+
+	return resp
+}
+
+func permissionsToResponse(perms []auth.Permission) []permissionResponse {
+	resp := make([]permissionResponse, 0, len(perms))
+
+	for _, p := range perms {
+		resp = append(resp, permissionResponse{
+			Action:   p.Action,
+			Resource: p.Resource,
+		})
+	}
+
+	return resp
 }
 
 // userByToken retrieves the user by token.
@@ -41,7 +81,12 @@ func (r *Router) userByToken(c echo.Context) error {
 		return err
 	}
 
-	return c.JSON(http.StatusOK, credToResponse(cred))
+	roles, err := r.credService.Roles(c.Request().Context(), cred.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, credToResponse(cred, roles))
 }
 
 // registerUser creates a new user.
@@ -66,7 +111,7 @@ func (r *Router) registerUser(c echo.Context) error {
 		return err
 	}
 
-	return c.JSON(http.StatusOK, credToResponse(cred))
+	return c.JSON(http.StatusOK, credToResponse(cred, nil))
 }
 
 func (r *Router) auth(c echo.Context) error {
@@ -85,7 +130,61 @@ func (r *Router) auth(c echo.Context) error {
 		return err
 	}
 
-	return c.JSON(http.StatusOK, credToResponse(cred))
+	roles, err := r.credService.Roles(c.Request().Context(), cred.ID)
+	if err != nil {
+		return err
+	}
+
+	resp := credToResponse(cred, roles)
+
+	if r.profileService != nil && c.QueryParam("include") == "profile" {
+		p, err := r.profileService.Profile(c.Request().Context(), cred.ID)
+		if err != nil {
+			return err
+		}
+
+		pr := profileToResponse(p, false)
+		resp.Profile = &pr
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// authorize checks if the authenticated credential is allowed to do the action on the resource,
+// credential_id is optional and must be the id of the authenticated one.
+func (r *Router) authorize(c echo.Context) error {
+	var request struct {
+		CredentialID int    `json:"credential_id"`
+		Action       string `json:"action"`
+		Resource     string `json:"resource"`
+	}
+
+	err := c.Bind(&request)
+	if err != nil {
+		return err
+	}
+
+	if request.Action == "" || request.Resource == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Action and resource are required.")
+	}
+
+	ctx := c.Request().Context()
+	cred := credentialFromContext(ctx)
+
+	if request.CredentialID != 0 && request.CredentialID != cred.ID {
+		return auth.NewError(auth.ErrPermissionDenied, "Only the authenticated credential can be authorized.")
+	}
+
+	allowed, err := r.credService.Authorize(ctx, cred.ID, request.Action, request.Resource)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, struct {
+		Allowed bool `json:"allowed"`
+	}{
+		Allowed: allowed,
+	})
 }
 
 func customHTTPErrorHandler(err error, c echo.Context) {
@@ -97,6 +196,17 @@ func customHTTPErrorHandler(err error, c echo.Context) {
 		Message: auth.ErrorMsg(err),
 	}}
 
+	timeoutErr := auth.ErrorHas(err, auth.ErrTimeout)
+	if timeoutErr == nil && errors.Is(err, context.DeadlineExceeded) {
+		timeoutErr = auth.WrapError(err, auth.ErrTimeout, "Request timed out")
+	}
+
+	if timeoutErr != nil {
+		err = timeoutErr
+		errResp.Err.Code = auth.ErrorCode(timeoutErr)
+		errResp.Err.Message = auth.ErrorMsg(timeoutErr)
+	}
+
 	switch errI := err.(type) {
 	case *echo.HTTPError:
 		httpStatus = errI.Code
@@ -107,17 +217,26 @@ func customHTTPErrorHandler(err error, c echo.Context) {
 		}
 	case auth.Error:
 		switch errI.Code {
-		case auth.ErrCredNotFound:
+		case auth.ErrCredNotFound, auth.ErrRoleNotFound, auth.ErrTenantNotFound, auth.ErrOrgNotFound,
+			auth.ErrAPIKeyNotFound:
 			httpStatus = http.StatusNotFound
-		case auth.ErrAuth:
+		case auth.ErrAuth, auth.ErrTokenExpired:
 			httpStatus = http.StatusUnauthorized
+		case auth.ErrCredDisabled, auth.ErrCredSuspended, auth.ErrCredPendingDeletion, auth.ErrPermissionDenied:
+			httpStatus = http.StatusForbidden
+		case auth.ErrValidation, auth.ErrPasswordPolicy, auth.ErrInvitationInvalid:
+			httpStatus = http.StatusBadRequest
+		case auth.ErrRoleExists:
+			httpStatus = http.StatusConflict
+		case auth.ErrTimeout:
+			httpStatus = http.StatusGatewayTimeout
 		}
 	default:
-		c.Logger().Error(err)
+		zerolog.Ctx(c.Request().Context()).Error().Err(err).Msg("unexpected error")
 	}
 
 	err = c.JSON(httpStatus, errResp)
 	if err != nil {
-		c.Logger().Error(err)
+		zerolog.Ctx(c.Request().Context()).Error().Err(err).Msg("error response failed")
 	}
 }
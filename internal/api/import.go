@@ -0,0 +1,154 @@
+package api
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	auth "github.com/kl09/auth-go"
+)
+
+// Formats of imported files.
+const (
+	ImportCSV   = "csv"
+	ImportJSONL = "jsonl"
+)
+
+// importRecord is a line of an imported JSONL file.
+type importRecord struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Algorithm     string `json:"algorithm"`
+	Hash          string `json:"hash"`
+	Salt          string `json:"salt"`
+	Iterations    int    `json:"iterations"`
+	SaltPosition  string `json:"salt_position"`
+}
+
+// ReadImportRecords reads credentials to import from a CSV file with a header or a JSONL file.
+// The fields are email, email_verified, algorithm, hash, salt, iterations and salt_position,
+// email, algorithm and hash are required.
+func ReadImportRecords(r io.Reader, format string) ([]auth.ImportRecord, error) {
+	switch format {
+	case ImportCSV:
+		return readImportCSV(r)
+	case ImportJSONL:
+		return readImportJSONL(r)
+	default:
+		return nil, auth.NewError(auth.ErrValidation, fmt.Sprintf("Unknown import format %q, csv or jsonl expected.", format))
+	}
+}
+
+func readImportCSV(r io.Reader) ([]auth.ImportRecord, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, auth.WrapError(err, auth.ErrValidation, "Bad CSV header.")
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+
+	for _, name := range []string{"email", "algorithm", "hash"} {
+		if _, ok := columns[name]; !ok {
+			return nil, auth.NewError(auth.ErrValidation, fmt.Sprintf("No %s column.", name))
+		}
+	}
+
+	var records []auth.ImportRecord
+
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+
+		line, _ := cr.FieldPos(0)
+
+		if err != nil {
+			return nil, auth.WrapError(err, auth.ErrValidation, fmt.Sprintf("Bad CSV line %d.", line))
+		}
+
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(row) {
+				return ""
+			}
+
+			return strings.TrimSpace(row[i])
+		}
+
+		rec := auth.ImportRecord{
+			Line:         line,
+			Email:        field("email"),
+			Algorithm:    field("algorithm"),
+			Hash:         field("hash"),
+			Salt:         field("salt"),
+			SaltPosition: field("salt_position"),
+		}
+
+		if v := field("email_verified"); v != "" {
+			if rec.EmailVerified, err = strconv.ParseBool(v); err != nil {
+				return nil, auth.NewError(auth.ErrValidation, fmt.Sprintf("Bad email_verified on line %d.", line))
+			}
+		}
+
+		if v := field("iterations"); v != "" {
+			if rec.Iterations, err = strconv.Atoi(v); err != nil {
+				return nil, auth.NewError(auth.ErrValidation, fmt.Sprintf("Bad iterations on line %d.", line))
+			}
+		}
+
+		records = append(records, rec)
+	}
+}
+
+func readImportJSONL(r io.Reader) ([]auth.ImportRecord, error) {
+	var records []auth.ImportRecord
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for line := 1; s.Scan(); line++ {
+		if strings.TrimSpace(s.Text()) == "" {
+			continue
+		}
+
+		var rec importRecord
+
+		err := json.Unmarshal(s.Bytes(), &rec)
+		if err != nil {
+			return nil, auth.WrapError(err, auth.ErrValidation, fmt.Sprintf("Bad JSON on line %d.", line))
+		}
+
+		records = append(records, auth.ImportRecord{
+			Line:          line,
+			Email:         rec.Email,
+			EmailVerified: rec.EmailVerified,
+			Algorithm:     rec.Algorithm,
+			Hash:          rec.Hash,
+			Salt:          rec.Salt,
+			Iterations:    rec.Iterations,
+			SaltPosition:  rec.SaltPosition,
+		})
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, auth.WrapError(err, auth.ErrValidation, "Bad JSONL file.")
+	}
+
+	return records, nil
+}
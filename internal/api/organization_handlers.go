@@ -0,0 +1,176 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	auth "github.com/kl09/auth-go"
+)
+
+type organizationResponse struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func membershipToResponse(m auth.Membership) organizationResponse {
+	return organizationResponse{
+		ID:        m.Organization.ID,
+		Name:      m.Organization.Name,
+		Role:      m.Role,
+		CreatedAt: m.Organization.CreatedAt,
+		UpdatedAt: m.Organization.UpdatedAt,
+	}
+}
+
+// organizations retrieves organizations of the authenticated credential.
+func (r *Router) organizations(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	memberships, err := r.orgService.Organizations(ctx, credentialFromContext(ctx).ID)
+	if err != nil {
+		return err
+	}
+
+	resp := struct {
+		Organizations []organizationResponse `json:"organizations"`
+	}{
+		Organizations: make([]organizationResponse, 0, len(memberships)),
+	}
+
+	for _, m := range memberships {
+		resp.Organizations = append(resp.Organizations, membershipToResponse(m))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// createOrganization creates an organization owned by the authenticated credential.
+func (r *Router) createOrganization(c echo.Context) error {
+	var request struct {
+		Name string `json:"name"`
+	}
+
+	err := c.Bind(&request)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	o := auth.Organization{Name: request.Name}
+
+	err = r.orgService.Create(ctx, credentialFromContext(ctx).ID, &o)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, membershipToResponse(auth.Membership{Organization: o, Role: auth.OrgRoleOwner}))
+}
+
+// invite invites an email to the organization, the token should be delivered to the email.
+func (r *Router) invite(c echo.Context) error {
+	var request struct {
+		Email string `json:"email"`
+		Role  string `json:"role"`
+	}
+
+	orgID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad id.")
+	}
+
+	err = c.Bind(&request)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	i := auth.Invitation{
+		OrganizationID: orgID,
+		Email:          request.Email,
+		Role:           request.Role,
+	}
+
+	token, err := r.orgService.Invite(ctx, credentialFromContext(ctx).ID, &i)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, struct {
+		ID             int       `json:"id"`
+		OrganizationID int       `json:"organization_id"`
+		Email          string    `json:"email"`
+		Role           string    `json:"role"`
+		Token          string    `json:"token"`
+		ExpiresAt      time.Time `json:"expires_at"`
+	}{
+		ID:             i.ID,
+		OrganizationID: i.OrganizationID,
+		Email:          i.Email,
+		Role:           i.Role,
+		Token:          token,
+		ExpiresAt:      i.ExpiresAt,
+	})
+}
+
+// acceptInvitation accepts an invitation, the credential is registered if the email is new.
+func (r *Router) acceptInvitation(c echo.Context) error {
+	var request struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+
+	err := c.Bind(&request)
+	if err != nil {
+		return err
+	}
+
+	cred, m, err := r.orgService.AcceptInvitation(c.Request().Context(), request.Token, request.Password)
+	if err != nil {
+		return err
+	}
+
+	roles, err := r.credService.Roles(c.Request().Context(), cred.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, struct {
+		Credential   response             `json:"credential"`
+		Organization organizationResponse `json:"organization"`
+	}{
+		Credential:   credToResponse(cred, roles),
+		Organization: membershipToResponse(m),
+	})
+}
+
+// switchOrganization sets the active organization of the authenticated credential, 0 resets it.
+func (r *Router) switchOrganization(c echo.Context) error {
+	var request struct {
+		OrganizationID int `json:"organization_id"`
+	}
+
+	err := c.Bind(&request)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	cred, err := r.orgService.Switch(ctx, credentialFromContext(ctx).ID, request.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	roles, err := r.credService.Roles(ctx, cred.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, credToResponse(cred, roles))
+}
@@ -0,0 +1,238 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	auth "github.com/kl09/auth-go"
+)
+
+const maxOrgNameLength = 255
+
+// OrganizationService is a service for organizations, their members and invitations.
+type OrganizationService struct {
+	organizationRepository auth.OrganizationRepository
+	credentialRepository   auth.CredentialRepository
+	credService            auth.CredentialService
+	auditLog               auth.AuditLog
+	nowFn                  func() time.Time
+	// invitationKey signs invitation tokens.
+	invitationKey []byte
+}
+
+// NewOrganizationService creates an OrganizationService,
+// credentials of accepted invitations are authenticated and registered with the credService.
+func NewOrganizationService(
+	orgs auth.OrganizationRepository,
+	r auth.CredentialRepository,
+	credService auth.CredentialService,
+	a auth.AuditLog,
+	nowFn func() time.Time,
+	invitationKey []byte,
+) *OrganizationService {
+	return &OrganizationService{
+		organizationRepository: orgs,
+		credentialRepository:   r,
+		credService:            credService,
+		auditLog:               a,
+		nowFn:                  nowFn,
+		invitationKey:          invitationKey,
+	}
+}
+
+// Create creates a new Organization owned by the Credential.
+func (s *OrganizationService) Create(ctx context.Context, credID int, o *auth.Organization) error {
+	o.Name = strings.TrimSpace(o.Name)
+	if o.Name == "" || len(o.Name) > maxOrgNameLength {
+		return auth.NewError(auth.ErrValidation, "Organization name must be from 1 to 255 characters long.")
+	}
+
+	o.CreatedAt = s.nowFn()
+	o.UpdatedAt = s.nowFn()
+
+	err := s.organizationRepository.Create(ctx, o, credID)
+	if err != nil {
+		return err
+	}
+
+	s.audit(ctx, auth.AuditOrgCreate, credID, o.ID)
+
+	return nil
+}
+
+// Organizations retrieves memberships of the Credential.
+func (s *OrganizationService) Organizations(ctx context.Context, credID int) ([]auth.Membership, error) {
+	return s.organizationRepository.ByCredential(ctx, credID)
+}
+
+// Invite invites the email to the Organization on behalf of the Credential, returns the invitation token.
+// Owners and admins can invite, only owners can invite owners.
+func (s *OrganizationService) Invite(ctx context.Context, credID int, i *auth.Invitation) (string, error) {
+	if !strings.Contains(i.Email, "@") {
+		return "", auth.NewError(auth.ErrValidation, "Bad email.")
+	}
+
+	if !auth.ValidOrgRole(i.Role) {
+		return "", auth.NewError(auth.ErrValidation, "Unknown role.")
+	}
+
+	m, err := s.organizationRepository.Member(ctx, i.OrganizationID, credID)
+	if err != nil {
+		return "", err
+	}
+
+	if m.Role == auth.OrgRoleMember || (i.Role == auth.OrgRoleOwner && m.Role != auth.OrgRoleOwner) {
+		return "", auth.NewError(auth.ErrPermissionDenied, "Not enough permissions to invite with this role.")
+	}
+
+	ttl := auth.TenantFromContext(ctx).Settings.InvitationTTL
+	if ttl <= 0 {
+		ttl = auth.DefaultInvitationTTL
+	}
+
+	i.InvitedBy = credID
+	i.CreatedAt = s.nowFn()
+	i.ExpiresAt = s.nowFn().Add(ttl)
+	i.AcceptedAt = time.Time{}
+
+	err = s.organizationRepository.CreateInvitation(ctx, i)
+	if err != nil {
+		return "", err
+	}
+
+	s.audit(ctx, auth.AuditInvite, credID, i.OrganizationID)
+
+	return signInvitation(s.invitationKey, auth.TenantFromContext(ctx).ID, i.ID, i.ExpiresAt), nil
+}
+
+// AcceptInvitation makes the invited Credential a member of the Organization,
+// the Credential is authenticated with the password or created with it if the email isn't registered yet,
+// a created Credential has the email unverified.
+func (s *OrganizationService) AcceptInvitation(
+	ctx context.Context,
+	token, plainPassword string,
+) (auth.Credential, auth.Membership, error) {
+	id, err := parseInvitation(s.invitationKey, auth.TenantFromContext(ctx).ID, token, s.nowFn())
+	if err != nil {
+		return auth.Credential{}, auth.Membership{}, err
+	}
+
+	i, err := s.organizationRepository.Invitation(ctx, id)
+	if err != nil {
+		return auth.Credential{}, auth.Membership{}, err
+	}
+
+	if !i.AcceptedAt.IsZero() {
+		return auth.Credential{}, auth.Membership{}, auth.NewError(auth.ErrInvitationInvalid, "Invitation is already accepted.")
+	}
+
+	cred, err := s.credService.Auth(ctx, i.Email, plainPassword)
+	if auth.ErrorHas(err, auth.ErrCredNotFound) != nil {
+		// The inviter gets the token too, so holding it doesn't prove the ownership of the email,
+		// it is verified like the one of any registered credential.
+		cred = auth.Credential{Email: i.Email, Password: plainPassword}
+		err = s.credService.Register(ctx, &cred)
+	}
+
+	if err != nil {
+		return auth.Credential{}, auth.Membership{}, err
+	}
+
+	m, err := s.organizationRepository.AcceptInvitation(ctx, i.ID, cred.ID, s.nowFn())
+	if err != nil {
+		return auth.Credential{}, auth.Membership{}, err
+	}
+
+	s.audit(ctx, auth.AuditInviteAccept, cred.ID, i.OrganizationID)
+
+	return cred, m, nil
+}
+
+// Switch makes the Organization active for the Credential, 0 resets the active Organization.
+func (s *OrganizationService) Switch(ctx context.Context, credID, orgID int) (auth.Credential, error) {
+	if orgID != 0 {
+		_, err := s.organizationRepository.Member(ctx, orgID, credID)
+		if err != nil {
+			return auth.Credential{}, err
+		}
+	}
+
+	cred, err := s.credentialRepository.ByID(ctx, credID)
+	if err != nil {
+		return auth.Credential{}, err
+	}
+
+	cred.ActiveOrganizationID = orgID
+	cred.UpdatedAt = s.nowFn()
+
+	err = s.credentialRepository.Update(ctx, &cred)
+	if err != nil {
+		return auth.Credential{}, err
+	}
+
+	s.audit(ctx, auth.AuditOrgSwitch, credID, orgID)
+
+	return cred, nil
+}
+
+// audit records the event with the organization id as the reason.
+func (s *OrganizationService) audit(ctx context.Context, event string, credID, orgID int) {
+	appendAudit(ctx, s.auditLog, &auth.AuditEntry{
+		CredentialID: credID,
+		Event:        event,
+		Reason:       strconv.Itoa(orgID),
+		CreatedAt:    s.nowFn(),
+	})
+}
+
+// signInvitation returns a token "<id>.<expiration unix time>.<signature>" of the invitation,
+// the signature is HMAC-SHA256 of the tenant, the id and the expiration.
+func signInvitation(key []byte, tenantID string, id int, expiresAt time.Time) string {
+	payload := fmt.Sprintf("%d.%d", id, expiresAt.Unix())
+
+	return payload + "." + invitationSignature(key, tenantID, payload)
+}
+
+// parseInvitation checks the signature and the expiration of the token and returns the invitation id.
+func parseInvitation(key []byte, tenantID, token string, now time.Time) (int, error) {
+	invalid := auth.NewError(auth.ErrInvitationInvalid, "Invalid invitation token.")
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, invalid
+	}
+
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(invitationSignature(key, tenantID, payload))) {
+		return 0, invalid
+	}
+
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, invalid
+	}
+
+	expiresAt, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, invalid
+	}
+
+	if !now.Before(time.Unix(expiresAt, 0)) {
+		return 0, auth.NewError(auth.ErrInvitationInvalid, "Invitation is expired.")
+	}
+
+	return id, nil
+}
+
+func invitationSignature(key []byte, tenantID, payload string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(tenantID + "." + payload))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,403 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+
+	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/mock"
+)
+
+var invitationKey = []byte("invitation-key")
+
+func TestInvitationToken(t *testing.T) {
+	token := signInvitation(invitationKey, "default", 5, now.Add(time.Hour))
+
+	cases := []struct {
+		name     string
+		token    string
+		tenantID string
+		now      time.Time
+		wantID   int
+		wantErr  error
+	}{
+		{
+			name:     "valid",
+			token:    token,
+			tenantID: "default",
+			now:      now,
+			wantID:   5,
+		},
+		{
+			name:     "error - expired",
+			token:    token,
+			tenantID: "default",
+			now:      now.Add(time.Hour),
+			wantErr:  auth.NewError(auth.ErrInvitationInvalid, "Invitation is expired."),
+		},
+		{
+			name:     "error - other tenant",
+			token:    token,
+			tenantID: "shop",
+			now:      now,
+			wantErr:  auth.NewError(auth.ErrInvitationInvalid, "Invalid invitation token."),
+		},
+		{
+			name:     "error - changed id",
+			token:    "6" + strings.TrimPrefix(token, "5"),
+			tenantID: "default",
+			now:      now,
+			wantErr:  auth.NewError(auth.ErrInvitationInvalid, "Invalid invitation token."),
+		},
+		{
+			name:     "error - malformed",
+			token:    "token",
+			tenantID: "default",
+			now:      now,
+			wantErr:  auth.NewError(auth.ErrInvitationInvalid, "Invalid invitation token."),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			id, err := parseInvitation(invitationKey, tc.tenantID, tc.token, tc.now)
+
+			require.Equal(t, tc.wantErr, err)
+			require.Equal(t, tc.wantID, id)
+		})
+	}
+}
+
+func TestOrganizationService_Invite(t *testing.T) {
+	cases := []struct {
+		name       string
+		memberRole string
+		invitation auth.Invitation
+		wantErr    error
+	}{
+		{
+			name:       "owner invites owner",
+			memberRole: auth.OrgRoleOwner,
+			invitation: auth.Invitation{OrganizationID: 3, Email: "example@example.org", Role: auth.OrgRoleOwner},
+		},
+		{
+			name:       "admin invites member",
+			memberRole: auth.OrgRoleAdmin,
+			invitation: auth.Invitation{OrganizationID: 3, Email: "example@example.org", Role: auth.OrgRoleMember},
+		},
+		{
+			name:       "error - admin invites owner",
+			memberRole: auth.OrgRoleAdmin,
+			invitation: auth.Invitation{OrganizationID: 3, Email: "example@example.org", Role: auth.OrgRoleOwner},
+			wantErr:    auth.NewError(auth.ErrPermissionDenied, "Not enough permissions to invite with this role."),
+		},
+		{
+			name:       "error - member invites",
+			memberRole: auth.OrgRoleMember,
+			invitation: auth.Invitation{OrganizationID: 3, Email: "example@example.org", Role: auth.OrgRoleMember},
+			wantErr:    auth.NewError(auth.ErrPermissionDenied, "Not enough permissions to invite with this role."),
+		},
+		{
+			name:       "error - not a member",
+			invitation: auth.Invitation{OrganizationID: 3, Email: "example@example.org", Role: auth.OrgRoleMember},
+			wantErr:    auth.NewError(auth.ErrOrgNotFound, "Organization not found"),
+		},
+		{
+			name:       "error - unknown role",
+			memberRole: auth.OrgRoleOwner,
+			invitation: auth.Invitation{OrganizationID: 3, Email: "example@example.org", Role: "guest"},
+			wantErr:    auth.NewError(auth.ErrValidation, "Unknown role."),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			orgRep := &mock.OrganizationRepositoryMock{
+				MemberFunc: func(ctx context.Context, orgID, credID int) (auth.Membership, error) {
+					if tc.memberRole == "" {
+						return auth.Membership{}, auth.NewError(auth.ErrOrgNotFound, "Organization not found")
+					}
+
+					return auth.Membership{Organization: auth.Organization{ID: orgID}, CredentialID: credID, Role: tc.memberRole}, nil
+				},
+				CreateInvitationFunc: func(ctx context.Context, i *auth.Invitation) error {
+					i.ID = 5
+					return nil
+				},
+			}
+
+			s := NewOrganizationService(orgRep, nil, nil, noopAuditLog{}, nowFunc, invitationKey)
+
+			i := tc.invitation
+			token, err := s.Invite(context.Background(), 1, &i)
+
+			require.Equal(t, tc.wantErr, err)
+
+			if tc.wantErr != nil {
+				require.Empty(t, orgRep.CreateInvitationCalls())
+				return
+			}
+
+			want := tc.invitation
+			want.ID = 5
+			want.InvitedBy = 1
+			want.CreatedAt = now
+			want.ExpiresAt = now.Add(auth.DefaultInvitationTTL)
+
+			if diff := cmp.Diff(want, i); diff != "" {
+				t.Fatal(diff)
+			}
+
+			id, err := parseInvitation(invitationKey, auth.DefaultTenantID, token, now)
+			require.Nil(t, err)
+			require.Equal(t, 5, id)
+		})
+	}
+}
+
+func TestOrganizationService_AcceptInvitation(t *testing.T) {
+	hash, err := testHasher.Hash("password_12345_1122")
+	require.Nil(t, err)
+
+	token := signInvitation(invitationKey, auth.DefaultTenantID, 5, now.Add(time.Hour))
+
+	cases := []struct {
+		name       string
+		token      string
+		passwd     string
+		registered bool
+		accepted   bool
+		wantCred   auth.Credential
+		wantErr    error
+	}{
+		{
+			name:     "new credential",
+			token:    token,
+			passwd:   "password_12345_1122",
+			wantCred: auth.Credential{ID: 2, Email: "example@example.org", Token: "token", Status: auth.StatusActive},
+		},
+		{
+			name:       "existing credential",
+			token:      token,
+			passwd:     "password_12345_1122",
+			registered: true,
+			wantCred:   auth.Credential{ID: 1, Email: "example@example.org", Password: hash, Token: "token"},
+		},
+		{
+			name:       "error - wrong password of existing credential",
+			token:      token,
+			passwd:     "12345",
+			registered: true,
+			wantErr:    auth.NewError(auth.ErrAuth, "Auth failed"),
+		},
+		{
+			name:     "error - accepted",
+			token:    token,
+			passwd:   "password_12345_1122",
+			accepted: true,
+			wantErr:  auth.NewError(auth.ErrInvitationInvalid, "Invitation is already accepted."),
+		},
+		{
+			name:    "error - bad token",
+			token:   "5.1.signature",
+			wantErr: auth.NewError(auth.ErrInvitationInvalid, "Invalid invitation token."),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			credRep := &mock.CredentialRepositoryMock{
+				ByEmailFunc: func(ctx context.Context, email string) (auth.Credential, error) {
+					if !tc.registered {
+						return auth.Credential{}, auth.NewError(auth.ErrCredNotFound, "Credential not found")
+					}
+
+					return auth.Credential{ID: 1, Email: email, Password: hash, Token: "token"}, nil
+				},
+				CreateFunc: func(ctx context.Context, c *auth.Credential) error {
+					c.ID = 2
+					return nil
+				},
+			}
+
+			orgRep := &mock.OrganizationRepositoryMock{
+				InvitationFunc: func(ctx context.Context, id int) (auth.Invitation, error) {
+					i := auth.Invitation{ID: id, OrganizationID: 3, Email: "example@example.org", Role: auth.OrgRoleMember}
+					if tc.accepted {
+						i.AcceptedAt = now
+					}
+
+					return i, nil
+				},
+				AcceptInvitationFunc: func(ctx context.Context, id, credID int, at time.Time) (auth.Membership, error) {
+					return auth.Membership{Organization: auth.Organization{ID: 3}, CredentialID: credID, Role: auth.OrgRoleMember}, nil
+				},
+			}
+
+			credService := NewCredentialService(credRep, nowFunc, func(n int) (string, error) {
+				return "token", nil
+			})
+			s := NewOrganizationService(orgRep, credRep, credService, noopAuditLog{}, nowFunc, invitationKey)
+
+			cred, m, err := s.AcceptInvitation(context.Background(), tc.token, tc.passwd)
+
+			require.Equal(t, tc.wantErr, err)
+
+			if tc.wantErr != nil {
+				require.Empty(t, orgRep.AcceptInvitationCalls())
+				return
+			}
+
+			// The password hash of a new credential is random.
+			if !tc.registered {
+				require.True(t, passwordMatches(cred.Password, tc.passwd))
+				cred.Password = ""
+			}
+
+			cred.CreatedAt, cred.UpdatedAt = time.Time{}, time.Time{}
+
+			if diff := cmp.Diff(tc.wantCred, cred); diff != "" {
+				t.Fatal(diff)
+			}
+
+			require.Equal(t, tc.wantCred.ID, m.CredentialID)
+			require.Equal(t, 3, m.Organization.ID)
+		})
+	}
+}
+
+func TestOrganizations_Handlers(t *testing.T) {
+	cases := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		token      string
+		wantResp   string
+		wantStatus int
+	}{
+		{
+			name:       "list",
+			method:     http.MethodGet,
+			path:       "/v1/orgs",
+			token:      "token",
+			wantResp:   `{"organizations":[{"id":3,"name":"Acme","role":"owner","created_at":"2020-04-15T10:11:12Z","updated_at":"2020-04-15T10:11:12Z"}]}` + "\n",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "error - list without token",
+			method:     http.MethodGet,
+			path:       "/v1/orgs",
+			wantResp:   `{"error":{"code":"http_401","message":"Unauthorized"}}` + "\n",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "error - list with unknown token",
+			method:     http.MethodGet,
+			path:       "/v1/orgs",
+			token:      "unknown",
+			wantResp:   `{"error":{"code":"http_401","message":"Unauthorized"}}` + "\n",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "create",
+			method:     http.MethodPost,
+			path:       "/v1/orgs",
+			body:       `{"name":" Acme "}`,
+			token:      "token",
+			wantResp:   `{"id":3,"name":"Acme","role":"owner","created_at":"2020-04-15T10:11:12Z","updated_at":"2020-04-15T10:11:12Z"}` + "\n",
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "switch",
+			method:     http.MethodPut,
+			path:       "/v1/me/active-org",
+			body:       `{"organization_id":3}`,
+			token:      "token",
+			wantResp:   `{"id":1,"token":"token","email":"","email_tmp":"","email_verified":false,"active_organization_id":3,"roles":[],"permissions":[],"created_at":"0001-01-01T00:00:00Z","updated_at":"2020-04-15T10:11:12Z"}` + "\n",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "error - switch to other organization",
+			method:     http.MethodPut,
+			path:       "/v1/me/active-org",
+			body:       `{"organization_id":4}`,
+			token:      "token",
+			wantResp:   `{"error":{"code":"organization_not_found","message":"Organization not found"}}` + "\n",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			acme := auth.Membership{
+				Organization: auth.Organization{ID: 3, Name: "Acme", CreatedAt: now, UpdatedAt: now},
+				CredentialID: 1,
+				Role:         auth.OrgRoleOwner,
+			}
+
+			credRep := &mock.CredentialRepositoryMock{
+				ByTokenFunc: func(ctx context.Context, token string) (auth.Credential, error) {
+					if token != "token" {
+						return auth.Credential{}, auth.NewError(auth.ErrCredNotFound, "Credential not found")
+					}
+
+					return auth.Credential{ID: 1, Token: token}, nil
+				},
+				ByIDFunc: func(ctx context.Context, id int) (auth.Credential, error) {
+					return auth.Credential{ID: id, Token: "token"}, nil
+				},
+				UpdateFunc: func(ctx context.Context, c *auth.Credential) error {
+					return nil
+				},
+			}
+			orgRep := &mock.OrganizationRepositoryMock{
+				ByCredentialFunc: func(ctx context.Context, credID int) ([]auth.Membership, error) {
+					return []auth.Membership{acme}, nil
+				},
+				CreateFunc: func(ctx context.Context, o *auth.Organization, ownerID int) error {
+					o.ID = 3
+					return nil
+				},
+				MemberFunc: func(ctx context.Context, orgID, credID int) (auth.Membership, error) {
+					if orgID != acme.Organization.ID {
+						return auth.Membership{}, auth.NewError(auth.ErrOrgNotFound, "Organization not found")
+					}
+
+					return acme, nil
+				},
+			}
+
+			credService := NewCredentialService(credRep, nowFunc, nil)
+
+			h := NewRouter(
+				credService,
+				WithOrganizations(NewOrganizationService(orgRep, credRep, credService, noopAuditLog{}, nowFunc, invitationKey)),
+			).Handler().Server.Handler
+
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if diff := cmp.Diff(tc.wantStatus, rec.Code); diff != "" {
+				t.Error(diff)
+			}
+
+			if diff := cmp.Diff(tc.wantResp, rec.Body.String()); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
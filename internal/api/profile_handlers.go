@@ -0,0 +1,85 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	auth "github.com/kl09/auth-go"
+)
+
+type profileResponse struct {
+	DisplayName    string         `json:"display_name"`
+	Locale         string         `json:"locale"`
+	Timezone       string         `json:"timezone"`
+	PublicMetadata map[string]any `json:"public_metadata"`
+	// PrivateMetadata is omitted only if nil, an empty map in an interface isn't omitted.
+	PrivateMetadata any        `json:"private_metadata,omitempty"`
+	UpdatedAt       *time.Time `json:"updated_at"`
+}
+
+// profileToResponse converts the profile, the private metadata is included only for the user.
+func profileToResponse(p auth.Profile, private bool) profileResponse {
+	resp := profileResponse{
+		DisplayName:    p.DisplayName,
+		Locale:         p.Locale,
+		Timezone:       p.Timezone,
+		PublicMetadata: p.PublicMetadata,
+	}
+
+	if resp.PublicMetadata == nil {
+		resp.PublicMetadata = map[string]any{}
+	}
+
+	if private {
+		resp.PrivateMetadata = map[string]any{}
+		if p.PrivateMetadata != nil {
+			resp.PrivateMetadata = p.PrivateMetadata
+		}
+	}
+
+	if !p.UpdatedAt.IsZero() {
+		resp.UpdatedAt = &p.UpdatedAt
+	}
+
+	return resp
+}
+
+// profile retrieves the profile of the authenticated credential.
+func (r *Router) profile(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	p, err := r.profileService.Profile(ctx, credentialFromContext(ctx).ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, profileToResponse(p, true))
+}
+
+// updateProfile updates the fields of the profile of the authenticated credential present in the request,
+// metadata keys are merged and null values delete them.
+func (r *Router) updateProfile(c echo.Context) error {
+	var request struct {
+		DisplayName     *string        `json:"display_name"`
+		Locale          *string        `json:"locale"`
+		Timezone        *string        `json:"timezone"`
+		PublicMetadata  map[string]any `json:"public_metadata"`
+		PrivateMetadata map[string]any `json:"private_metadata"`
+	}
+
+	err := c.Bind(&request)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	p, err := r.profileService.UpdateProfile(ctx, credentialFromContext(ctx).ID, auth.ProfileUpdate(request))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, profileToResponse(p, true))
+}
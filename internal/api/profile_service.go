@@ -0,0 +1,182 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	// Time zones of profiles are validated without relying on the zoneinfo of the host.
+	_ "time/tzdata"
+	"unicode/utf8"
+
+	auth "github.com/kl09/auth-go"
+)
+
+const (
+	maxDisplayNameLength = 128
+	maxMetadataKeyLength = 64
+)
+
+// localeRe matches BCP 47 language tags, e.g. "en" or "pt-BR".
+var localeRe = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// ProfileService is a service for profiles of credentials.
+type ProfileService struct {
+	profileRepository auth.ProfileRepository
+	auditLog          auth.AuditLog
+	nowFn             func() time.Time
+}
+
+// NewProfileService creates a ProfileService.
+func NewProfileService(profiles auth.ProfileRepository, a auth.AuditLog, nowFn func() time.Time) *ProfileService {
+	return &ProfileService{
+		profileRepository: profiles,
+		auditLog:          a,
+		nowFn:             nowFn,
+	}
+}
+
+// Profile retrieves the Profile of a Credential.
+func (s *ProfileService) Profile(ctx context.Context, credID int) (auth.Profile, error) {
+	return s.profileRepository.ByCredential(ctx, credID)
+}
+
+// UpdateProfile validates and applies the update to the Profile of a Credential,
+// metadata is validated against the ProfileSchema of the tenant.
+func (s *ProfileService) UpdateProfile(ctx context.Context, credID int, u auth.ProfileUpdate) (auth.Profile, error) {
+	p, err := s.profileRepository.ByCredential(ctx, credID)
+	if err != nil {
+		return auth.Profile{}, err
+	}
+
+	if u.DisplayName != nil {
+		p.DisplayName = strings.TrimSpace(*u.DisplayName)
+		if utf8.RuneCountInString(p.DisplayName) > maxDisplayNameLength {
+			return auth.Profile{}, auth.NewError(auth.ErrValidation, "Display name must be at most 128 characters long.")
+		}
+	}
+
+	if u.Locale != nil {
+		p.Locale = *u.Locale
+		if p.Locale != "" && (len(p.Locale) > 35 || !localeRe.MatchString(p.Locale)) {
+			return auth.Profile{}, auth.NewError(auth.ErrValidation, "Bad locale, a BCP 47 language tag expected.")
+		}
+	}
+
+	if u.Timezone != nil {
+		p.Timezone = *u.Timezone
+		if p.Timezone != "" {
+			if _, err := time.LoadLocation(p.Timezone); err != nil || p.Timezone == "Local" {
+				return auth.Profile{}, auth.NewError(auth.ErrValidation, "Bad timezone, an IANA time zone expected.")
+			}
+		}
+	}
+
+	schema := auth.TenantFromContext(ctx).Settings.ProfileSchema
+
+	p.PublicMetadata, err = mergeMetadata(p.PublicMetadata, u.PublicMetadata, schema)
+	if err != nil {
+		return auth.Profile{}, err
+	}
+
+	p.PrivateMetadata, err = mergeMetadata(p.PrivateMetadata, u.PrivateMetadata, schema)
+	if err != nil {
+		return auth.Profile{}, err
+	}
+
+	p.UpdatedAt = s.nowFn()
+
+	err = s.profileRepository.Save(ctx, &p)
+	if err != nil {
+		return auth.Profile{}, err
+	}
+
+	appendAudit(ctx, s.auditLog, &auth.AuditEntry{
+		CredentialID: credID,
+		Event:        auth.AuditProfileUpdate,
+		CreatedAt:    s.nowFn(),
+	})
+
+	return p, nil
+}
+
+// mergeMetadata merges the patch into the metadata, keys with nil values are deleted.
+// The result is validated against the schema and MaxMetadataSize.
+func mergeMetadata(metadata, patch map[string]any, schema auth.ProfileSchema) (map[string]any, error) {
+	merged := make(map[string]any, len(metadata)+len(patch))
+	for k, v := range metadata {
+		merged[k] = v
+	}
+
+	for k, v := range patch {
+		if v == nil {
+			delete(merged, k)
+			continue
+		}
+
+		if k == "" || len(k) > maxMetadataKeyLength {
+			return nil, auth.NewError(auth.ErrValidation, "Metadata key must be from 1 to 64 characters long.")
+		}
+
+		if len(schema) > 0 {
+			typ, ok := schema[k]
+			if !ok {
+				return nil, auth.NewError(auth.ErrValidation, fmt.Sprintf("Unknown metadata key %q.", k))
+			}
+
+			if metadataType(v) != typ {
+				return nil, auth.NewError(auth.ErrValidation, fmt.Sprintf("Metadata key %q must be %s.", k, typ))
+			}
+		}
+
+		merged[k] = v
+	}
+
+	b, err := json.Marshal(merged)
+	if err != nil {
+		return nil, auth.WrapError(err, auth.ErrValidation, "Bad metadata.")
+	}
+
+	if len(b) > auth.MaxMetadataSize {
+		return nil, auth.NewError(auth.ErrValidation, fmt.Sprintf("Metadata must be at most %d bytes.", auth.MaxMetadataSize))
+	}
+
+	return merged, nil
+}
+
+// metadataType returns the type of a decoded JSON value, see auth.MetadataString.
+func metadataType(v any) string {
+	switch v.(type) {
+	case string:
+		return auth.MetadataString
+	case float64, json.Number:
+		return auth.MetadataNumber
+	case bool:
+		return auth.MetadataBoolean
+	case map[string]any:
+		return auth.MetadataObject
+	case []any:
+		return auth.MetadataArray
+	}
+
+	return ""
+}
+
+// validateProfileSchema checks keys and types of the schema.
+func validateProfileSchema(schema auth.ProfileSchema) error {
+	for k, typ := range schema {
+		if k == "" || len(k) > maxMetadataKeyLength {
+			return auth.NewError(auth.ErrValidation, "Metadata key must be from 1 to 64 characters long.")
+		}
+
+		switch typ {
+		case auth.MetadataString, auth.MetadataNumber, auth.MetadataBoolean, auth.MetadataObject, auth.MetadataArray:
+		default:
+			return auth.NewError(auth.ErrValidation, fmt.Sprintf("Bad type of metadata key %q.", k))
+		}
+	}
+
+	return nil
+}
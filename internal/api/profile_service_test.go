@@ -0,0 +1,267 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/mock"
+)
+
+func TestProfileService_UpdateProfile(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	stored := auth.Profile{
+		CredentialID:    1,
+		DisplayName:     "Jane",
+		PublicMetadata:  map[string]any{"plan": "free", "seats": float64(1)},
+		PrivateMetadata: map[string]any{"note": "vip"},
+	}
+
+	cases := []struct {
+		name    string
+		update  auth.ProfileUpdate
+		schema  auth.ProfileSchema
+		want    auth.Profile
+		wantErr error
+	}{
+		{
+			name: "success",
+			update: auth.ProfileUpdate{
+				DisplayName:     str(" Jane Doe "),
+				Locale:          str("pt-BR"),
+				Timezone:        str("Europe/Berlin"),
+				PublicMetadata:  map[string]any{"plan": "pro", "seats": nil},
+				PrivateMetadata: map[string]any{"tags": []any{"a"}},
+			},
+			want: auth.Profile{
+				CredentialID:    1,
+				DisplayName:     "Jane Doe",
+				Locale:          "pt-BR",
+				Timezone:        "Europe/Berlin",
+				PublicMetadata:  map[string]any{"plan": "pro"},
+				PrivateMetadata: map[string]any{"note": "vip", "tags": []any{"a"}},
+				UpdatedAt:       now,
+			},
+		},
+		{
+			name:   "success - nothing changed",
+			update: auth.ProfileUpdate{},
+			want: auth.Profile{
+				CredentialID:    1,
+				DisplayName:     "Jane",
+				PublicMetadata:  stored.PublicMetadata,
+				PrivateMetadata: stored.PrivateMetadata,
+				UpdatedAt:       now,
+			},
+		},
+		{
+			name:   "success - schema",
+			update: auth.ProfileUpdate{PublicMetadata: map[string]any{"seats": float64(5)}},
+			schema: auth.ProfileSchema{"plan": auth.MetadataString, "seats": auth.MetadataNumber},
+			want: auth.Profile{
+				CredentialID:    1,
+				DisplayName:     "Jane",
+				PublicMetadata:  map[string]any{"plan": "free", "seats": float64(5)},
+				PrivateMetadata: stored.PrivateMetadata,
+				UpdatedAt:       now,
+			},
+		},
+		{
+			name:    "error - bad locale",
+			update:  auth.ProfileUpdate{Locale: str("english")},
+			wantErr: auth.NewError(auth.ErrValidation, "Bad locale, a BCP 47 language tag expected."),
+		},
+		{
+			name:    "error - bad timezone",
+			update:  auth.ProfileUpdate{Timezone: str("Mars/Olympus")},
+			wantErr: auth.NewError(auth.ErrValidation, "Bad timezone, an IANA time zone expected."),
+		},
+		{
+			name:    "error - long display name",
+			update:  auth.ProfileUpdate{DisplayName: str(strings.Repeat("a", 129))},
+			wantErr: auth.NewError(auth.ErrValidation, "Display name must be at most 128 characters long."),
+		},
+		{
+			name:    "error - unknown key",
+			update:  auth.ProfileUpdate{PrivateMetadata: map[string]any{"color": "red"}},
+			schema:  auth.ProfileSchema{"plan": auth.MetadataString},
+			wantErr: auth.NewError(auth.ErrValidation, `Unknown metadata key "color".`),
+		},
+		{
+			name:    "error - bad type",
+			update:  auth.ProfileUpdate{PublicMetadata: map[string]any{"plan": true}},
+			schema:  auth.ProfileSchema{"plan": auth.MetadataString},
+			wantErr: auth.NewError(auth.ErrValidation, `Metadata key "plan" must be string.`),
+		},
+		{
+			name:    "error - too large",
+			update:  auth.ProfileUpdate{PublicMetadata: map[string]any{"bio": strings.Repeat("a", auth.MaxMetadataSize)}},
+			wantErr: auth.NewError(auth.ErrValidation, "Metadata must be at most 8192 bytes."),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			profileRep := &mock.ProfileRepositoryMock{
+				ByCredentialFunc: func(ctx context.Context, credID int) (auth.Profile, error) {
+					return stored, nil
+				},
+				SaveFunc: func(ctx context.Context, p *auth.Profile) error {
+					return nil
+				},
+			}
+
+			ctx := auth.ContextWithTenant(context.Background(), auth.Tenant{
+				ID:       auth.DefaultTenantID,
+				Settings: auth.TenantSettings{ProfileSchema: tc.schema},
+			})
+
+			s := NewProfileService(profileRep, noopAuditLog{}, nowFunc)
+
+			p, err := s.UpdateProfile(ctx, 1, tc.update)
+
+			require.Equal(t, tc.wantErr, err)
+
+			if tc.wantErr != nil {
+				require.Empty(t, profileRep.SaveCalls())
+				return
+			}
+
+			if diff := cmp.Diff(tc.want, p); diff != "" {
+				t.Fatal(diff)
+			}
+
+			if diff := cmp.Diff(tc.want, *profileRep.SaveCalls()[0].P); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestProfile_Handlers(t *testing.T) {
+	hash, err := testHasher.Hash("66554433")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		header     string
+		wantResp   string
+		wantStatus int
+	}{
+		{
+			name:       "get",
+			method:     http.MethodGet,
+			path:       "/v1/me/profile",
+			header:     "Bearer token",
+			wantResp:   `{"display_name":"Jane","locale":"en","timezone":"","public_metadata":{"plan":"free"},"private_metadata":{},"updated_at":null}` + "\n",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "patch",
+			method:     http.MethodPatch,
+			path:       "/v1/me/profile",
+			body:       `{"timezone":"UTC","private_metadata":{"note":"vip"}}`,
+			header:     "Bearer token",
+			wantResp:   `{"display_name":"Jane","locale":"en","timezone":"UTC","public_metadata":{"plan":"free"},"private_metadata":{"note":"vip"},"updated_at":"2020-04-15T10:11:12Z"}` + "\n",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "error - unauthorized",
+			method:     http.MethodGet,
+			path:       "/v1/me/profile",
+			wantResp:   `{"error":{"code":"http_401","message":"Unauthorized"}}` + "\n",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:   "auth with profile",
+			method: http.MethodPost,
+			path:   "/v1/auth?include=profile",
+			body:   `{"email":"example@example.org","password":"66554433"}`,
+			wantResp: `{"id":1,"token":"token","email":"example@example.org","email_tmp":"","email_verified":false,"roles":[],"permissions":[],` +
+				`"profile":{"display_name":"Jane","locale":"en","timezone":"","public_metadata":{"plan":"free"},"updated_at":null},` +
+				`"created_at":"2020-04-15T10:11:12Z","updated_at":"2020-04-15T10:11:12Z"}` + "\n",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "auth without profile",
+			method: http.MethodPost,
+			path:   "/v1/auth",
+			body:   `{"email":"example@example.org","password":"66554433"}`,
+			wantResp: `{"id":1,"token":"token","email":"example@example.org","email_tmp":"","email_verified":false,"roles":[],"permissions":[],` +
+				`"created_at":"2020-04-15T10:11:12Z","updated_at":"2020-04-15T10:11:12Z"}` + "\n",
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cred := auth.Credential{
+				ID:        1,
+				Password:  hash,
+				Email:     "example@example.org",
+				Token:     "token",
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+
+			credRep := &mock.CredentialRepositoryMock{
+				ByTokenFunc: func(ctx context.Context, token string) (auth.Credential, error) {
+					return cred, nil
+				},
+				ByEmailFunc: func(ctx context.Context, email string) (auth.Credential, error) {
+					return cred, nil
+				},
+			}
+			profileRep := &mock.ProfileRepositoryMock{
+				ByCredentialFunc: func(ctx context.Context, credID int) (auth.Profile, error) {
+					return auth.Profile{
+						CredentialID:    credID,
+						DisplayName:     "Jane",
+						Locale:          "en",
+						PublicMetadata:  map[string]any{"plan": "free"},
+						PrivateMetadata: map[string]any{},
+					}, nil
+				},
+				SaveFunc: func(ctx context.Context, p *auth.Profile) error {
+					return nil
+				},
+			}
+
+			h := NewRouter(
+				NewCredentialService(credRep, nowFunc, nil),
+				WithProfiles(NewProfileService(profileRep, noopAuditLog{}, nowFunc)),
+			).Handler().Server.Handler
+
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			if tc.header != "" {
+				req.Header.Set(echo.HeaderAuthorization, tc.header)
+			}
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if diff := cmp.Diff(tc.wantStatus, rec.Code); diff != "" {
+				t.Error(diff)
+			}
+
+			if diff := cmp.Diff(tc.wantResp, rec.Body.String()); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
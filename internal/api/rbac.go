@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"regexp"
+
+	auth "github.com/kl09/auth-go"
+)
+
+// The permission required to use the admin API with a credential token.
+const (
+	adminAction   = "manage"
+	adminResource = "admin"
+)
+
+const (
+	maxActionLength   = 64
+	maxResourceLength = 128
+)
+
+// roleNameRe matches valid role names.
+var roleNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_.\-]{0,63}$`)
+
+type noopRoleRepository struct{}
+
+func (noopRoleRepository) Roles(context.Context) ([]auth.Role, error) {
+	return []auth.Role{}, nil
+}
+
+func (noopRoleRepository) ByCredential(context.Context, int) ([]auth.Role, error) {
+	return []auth.Role{}, nil
+}
+
+func (noopRoleRepository) Create(context.Context, *auth.Role) error {
+	return auth.NewError(auth.ErrInternal, "Roles are not configured")
+}
+
+func (noopRoleRepository) Delete(context.Context, string) error {
+	return auth.NewError(auth.ErrRoleNotFound, "Role not found")
+}
+
+func (noopRoleRepository) Assign(context.Context, int, string) error {
+	return auth.NewError(auth.ErrRoleNotFound, "Role not found")
+}
+
+func (noopRoleRepository) Unassign(context.Context, int, string) error {
+	return nil
+}
+
+// validateRole checks the name and the permissions of the Role.
+func validateRole(r auth.Role) error {
+	if !roleNameRe.MatchString(r.Name) {
+		return auth.NewError(auth.ErrValidation, "Bad role name, lowercase letters, digits and _.- expected.")
+	}
+
+	return validatePermissions(r.Permissions)
+}
+
+// validatePermissions checks actions and resources of the permissions.
+func validatePermissions(perms []auth.Permission) error {
+	for _, p := range perms {
+		if p.Action == "" || len(p.Action) > maxActionLength {
+			return auth.NewError(auth.ErrValidation, "Bad permission action.")
+		}
+
+		if p.Resource == "" || len(p.Resource) > maxResourceLength {
+			return auth.NewError(auth.ErrValidation, "Bad permission resource.")
+		}
+	}
+
+	return nil
+}
@@ -1,28 +1,160 @@
 package api
 
 import (
+	"time"
+
 	"github.com/labstack/echo/v4"
 
 	auth "github.com/kl09/auth-go"
 )
 
 type Router struct {
-	credService auth.CredentialService
+	credService    auth.CredentialService
+	adminService   auth.AdminService
+	orgService     auth.OrganizationService
+	apiKeyService  auth.APIKeyService
+	profileService auth.ProfileService
+	accountService auth.AccountService
+	adminToken     string
+	tenantResolver *tenantResolver
+	middleware     []echo.MiddlewareFunc
+}
+
+// RouterOption configures the Router.
+type RouterOption func(*Router)
+
+// WithMiddleware adds middleware running before every handler.
+func WithMiddleware(m ...echo.MiddlewareFunc) RouterOption {
+	return func(r *Router) {
+		r.middleware = append(r.middleware, m...)
+	}
+}
+
+// WithAdmin enables the /admin/v1 API available with the admin token
+// or a token of a credential having the manage permission on the admin resource.
+func WithAdmin(s auth.AdminService, token string) RouterOption {
+	return func(r *Router) {
+		r.adminService = s
+		r.adminToken = token
+	}
+}
+
+// WithTenants enables resolving the tenant of every request by the X-Tenant-ID header or the host,
+// all requests belong to the default tenant with default settings without it.
+func WithTenants(t auth.TenantRepository) RouterOption {
+	return func(r *Router) {
+		r.tenantResolver = newTenantResolver(t, time.Now)
+	}
 }
 
-func NewRouter(credService auth.CredentialService) *Router {
-	return &Router{
+// WithOrganizations enables organizations of credentials with the /v1/orgs API.
+func WithOrganizations(s auth.OrganizationService) RouterOption {
+	return func(r *Router) {
+		r.orgService = s
+	}
+}
+
+// WithAPIKeys enables API keys of credentials with the /v1/me/api-keys API,
+// the X-API-Key header authenticates requests to the API of credentials.
+func WithAPIKeys(s auth.APIKeyService) RouterOption {
+	return func(r *Router) {
+		r.apiKeyService = s
+	}
+}
+
+// WithProfiles enables profiles of credentials with the /v1/me/profile API,
+// the public part of a profile is embedded into the /v1/auth response with ?include=profile.
+func WithProfiles(s auth.ProfileService) RouterOption {
+	return func(r *Router) {
+		r.profileService = s
+	}
+}
+
+// WithAccounts enables data-subject requests with the /v1/me/export and DELETE /v1/me API.
+func WithAccounts(s auth.AccountService) RouterOption {
+	return func(r *Router) {
+		r.accountService = s
+	}
+}
+
+func NewRouter(credService auth.CredentialService, options ...RouterOption) *Router {
+	r := &Router{
 		credService: credService,
 	}
+
+	for _, opt := range options {
+		opt(r)
+	}
+
+	return r
 }
 
 func (r *Router) Handler() *echo.Echo {
 	e := echo.New()
 	e.HTTPErrorHandler = customHTTPErrorHandler
+	e.Use(r.middleware...)
+	e.Use(clientInfoMiddleware)
+
+	if r.tenantResolver != nil {
+		e.Use(r.tenantResolver.middleware)
+	}
 
 	e.GET("/v1/users-by-token/:token", r.userByToken)
 	e.POST("/v1/register", r.registerUser)
 	e.POST("/v1/auth", r.auth)
 
+	// Not a group to keep 404 for unknown routes instead of 401.
+	user := userAuth(r.credService, r.apiKeyService)
+
+	e.POST("/v1/authorize", r.authorize, user, requireScope("read", "authorize"))
+
+	if r.orgService != nil {
+		e.GET("/v1/orgs", r.organizations, user, requireScope("read", "orgs"))
+		e.POST("/v1/orgs", r.createOrganization, user, requireScope("write", "orgs"))
+		e.POST("/v1/orgs/:id/invitations", r.invite, user, requireScope("write", "orgs"))
+		e.PUT("/v1/me/active-org", r.switchOrganization, user, requireScope("write", "orgs"))
+
+		e.POST("/v1/invitations/accept", r.acceptInvitation)
+	}
+
+	if r.apiKeyService != nil {
+		e.GET("/v1/me/api-keys", r.apiKeys, user, tokenOnly)
+		e.POST("/v1/me/api-keys", r.createAPIKey, user, tokenOnly)
+		e.DELETE("/v1/me/api-keys/:id", r.deleteAPIKey, user, tokenOnly)
+	}
+
+	if r.profileService != nil {
+		e.GET("/v1/me/profile", r.profile, user, requireScope("read", "profile"))
+		e.PATCH("/v1/me/profile", r.updateProfile, user, requireScope("write", "profile"))
+	}
+
+	if r.accountService != nil {
+		e.GET("/v1/me/export", r.export, user, tokenOnly)
+		e.DELETE("/v1/me", r.deleteAccount, user, tokenOnly)
+	}
+
+	if r.adminService != nil {
+		admin := e.Group("/admin/v1", adminAuth(r.adminToken, r.credService))
+		admin.GET("/audit", r.auditLog)
+		admin.GET("/credentials", r.searchCredentials)
+		admin.POST("/credentials/import", r.importCredentials)
+		admin.GET("/credentials/:id", r.credential)
+		admin.DELETE("/credentials/:id", r.deleteCredential)
+		admin.POST("/credentials/:id/verify-email", r.verifyEmail)
+		admin.POST("/credentials/:id/password", r.resetPassword)
+		admin.POST("/credentials/:id/status", r.setStatus)
+		admin.POST("/credentials/:id/disable", r.disableCredential)
+		admin.POST("/credentials/:id/enable", r.enableCredential)
+		admin.POST("/credentials/:id/revoke-sessions", r.revokeSessions)
+		admin.GET("/credentials/:id/roles", r.credentialRoles)
+		admin.PUT("/credentials/:id/roles/:name", r.assignRole)
+		admin.DELETE("/credentials/:id/roles/:name", r.unassignRole)
+		admin.GET("/roles", r.roles)
+		admin.POST("/roles", r.createRole)
+		admin.DELETE("/roles/:name", r.deleteRole)
+		admin.GET("/tenants", r.tenants, superAdminOnly)
+		admin.PUT("/tenants/:id", r.saveTenant, superAdminOnly)
+	}
+
 	return e
 }
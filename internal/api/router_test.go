@@ -22,8 +22,16 @@ var (
 	nowFunc = func() time.Time {
 		return now
 	}
+	// testHasher hashes like services by default, so stored hashes aren't rehashed.
+	testHasher = DefaultHasher()
 )
 
+// passwordMatches reports whether the hash is of the password.
+func passwordMatches(hash, pwd string) bool {
+	ok, _ := testHasher.Verify(hash, pwd)
+	return ok
+}
+
 func TestUser_ByToken(t *testing.T) {
 	cases := []struct {
 		name       string
@@ -35,7 +43,7 @@ func TestUser_ByToken(t *testing.T) {
 		{
 			name:       "success",
 			token:      "12345",
-			wantResp:   `{"id":1,"token":"token","email":"example@example.org","email_tmp":"","email_verified":false,"created_at":"2020-04-15T10:11:12Z","updated_at":"2020-04-15T10:11:12Z"}` + "\n",
+			wantResp:   `{"id":1,"token":"token","email":"example@example.org","email_tmp":"","email_verified":false,"roles":[],"permissions":[],"created_at":"2020-04-15T10:11:12Z","updated_at":"2020-04-15T10:11:12Z"}` + "\n",
 			wantStatus: http.StatusOK,
 			credRep: &mock.CredentialRepositoryMock{
 				ByTokenFunc: func(ctx context.Context, token string) (auth.Credential, error) {
@@ -61,6 +69,28 @@ func TestUser_ByToken(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:       "error - disabled",
+			token:      "12345",
+			wantResp:   `{"error":{"code":"credential_disabled","message":"Credential is disabled"}}` + "\n",
+			wantStatus: http.StatusForbidden,
+			credRep: &mock.CredentialRepositoryMock{
+				ByTokenFunc: func(ctx context.Context, token string) (auth.Credential, error) {
+					return auth.Credential{ID: 1, Token: "token", Status: auth.StatusDisabled}, nil
+				},
+			},
+		},
+		{
+			name:       "error - timeout",
+			token:      "12345",
+			wantResp:   `{"error":{"code":"timeout","message":"Query timed out"}}` + "\n",
+			wantStatus: http.StatusGatewayTimeout,
+			credRep: &mock.CredentialRepositoryMock{
+				ByTokenFunc: func(ctx context.Context, token string) (auth.Credential, error) {
+					return auth.Credential{}, auth.WrapError(context.DeadlineExceeded, auth.ErrTimeout, "Query timed out")
+				},
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -114,7 +144,7 @@ func TestUser_Register(t *testing.T) {
 		{
 			name:        "success",
 			requestBody: `{"email":"example@example.org","password":"66554433"}`,
-			wantResp:    `{"id":1,"token":"1234abcd","email":"example@example.org","email_tmp":"","email_verified":false,"created_at":"2020-04-15T10:11:12Z","updated_at":"2020-04-15T10:11:12Z"}` + "\n",
+			wantResp:    `{"id":1,"token":"1234abcd","email":"example@example.org","email_tmp":"","email_verified":false,"roles":[],"permissions":[],"created_at":"2020-04-15T10:11:12Z","updated_at":"2020-04-15T10:11:12Z"}` + "\n",
 			wantStatus:  http.StatusOK,
 			credRep: &mock.CredentialRepositoryMock{
 				CreateFunc: func(ctx context.Context, c *auth.Credential) error {
@@ -205,7 +235,7 @@ func TestUser_Register(t *testing.T) {
 func TestUser_Auth(t *testing.T) {
 	token := "1234abcd"
 
-	hash, err := hashAndSalt("66554433")
+	hash, err := testHasher.Hash("66554433")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -220,7 +250,7 @@ func TestUser_Auth(t *testing.T) {
 		{
 			name:        "success",
 			requestBody: `{"email":"example@example.org","password":"66554433"}`,
-			wantResp:    `{"id":1,"token":"token","email":"example@example.org","email_tmp":"","email_verified":false,"created_at":"2020-04-15T10:11:12Z","updated_at":"2020-04-15T10:11:12Z"}` + "\n",
+			wantResp:    `{"id":1,"token":"token","email":"example@example.org","email_tmp":"","email_verified":false,"roles":[],"permissions":[],"created_at":"2020-04-15T10:11:12Z","updated_at":"2020-04-15T10:11:12Z"}` + "\n",
 			wantStatus:  http.StatusOK,
 			credRep: &mock.CredentialRepositoryMock{
 				ByEmailFunc: func(ctx context.Context, email string) (auth.Credential, error) {
@@ -264,6 +294,17 @@ func TestUser_Auth(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:        "error - timeout",
+			requestBody: `{"email":"example@example.org","password":"66554433"}`,
+			wantResp:    `{"error":{"code":"timeout","message":"Query timed out"}}` + "\n",
+			wantStatus:  http.StatusGatewayTimeout,
+			credRep: &mock.CredentialRepositoryMock{
+				ByEmailFunc: func(ctx context.Context, email string) (auth.Credential, error) {
+					return auth.Credential{}, auth.WrapError(context.DeadlineExceeded, auth.ErrTimeout, "Query timed out")
+				},
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -353,3 +394,173 @@ func Test_404_error(t *testing.T) {
 		t.Fatal(diff)
 	}
 }
+
+func TestUser_Authorize(t *testing.T) {
+	viewer := []auth.Role{{
+		Name:        "viewer",
+		Permissions: []auth.Permission{{Action: "read", Resource: auth.Wildcard}},
+	}}
+
+	cases := []struct {
+		name        string
+		requestBody string
+		token       string
+		cred        auth.Credential
+		roles       []auth.Role
+		wantResp    string
+		wantStatus  int
+	}{
+		{
+			name:        "allowed",
+			requestBody: `{"credential_id":1,"action":"read","resource":"orders"}`,
+			token:       "token",
+			cred:        auth.Credential{ID: 1},
+			roles:       viewer,
+			wantResp:    `{"allowed":true}` + "\n",
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "allowed - no credential id",
+			requestBody: `{"action":"read","resource":"orders"}`,
+			token:       "token",
+			cred:        auth.Credential{ID: 1},
+			roles:       viewer,
+			wantResp:    `{"allowed":true}` + "\n",
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "denied",
+			requestBody: `{"credential_id":1,"action":"delete","resource":"orders"}`,
+			token:       "token",
+			cred:        auth.Credential{ID: 1},
+			roles:       viewer,
+			wantResp:    `{"allowed":false}` + "\n",
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "error - disabled",
+			requestBody: `{"credential_id":1,"action":"read","resource":"orders"}`,
+			token:       "token",
+			cred:        auth.Credential{ID: 1, Status: auth.StatusDisabled},
+			roles:       viewer,
+			wantResp:    `{"error":{"code":"credential_disabled","message":"Credential is disabled"}}` + "\n",
+			wantStatus:  http.StatusForbidden,
+		},
+		{
+			name:        "error - other credential",
+			requestBody: `{"credential_id":2,"action":"read","resource":"orders"}`,
+			token:       "token",
+			cred:        auth.Credential{ID: 1},
+			wantResp:    `{"error":{"code":"permission_denied","message":"Only the authenticated credential can be authorized."}}` + "\n",
+			wantStatus:  http.StatusForbidden,
+		},
+		{
+			name:        "error - no token",
+			requestBody: `{"credential_id":1,"action":"read","resource":"orders"}`,
+			cred:        auth.Credential{ID: 1},
+			wantResp:    `{"error":{"code":"http_401","message":"Unauthorized"}}` + "\n",
+			wantStatus:  http.StatusUnauthorized,
+		},
+		{
+			name:        "error - unknown token",
+			requestBody: `{"credential_id":1,"action":"read","resource":"orders"}`,
+			token:       "unknown",
+			cred:        auth.Credential{ID: 1},
+			wantResp:    `{"error":{"code":"http_401","message":"Unauthorized"}}` + "\n",
+			wantStatus:  http.StatusUnauthorized,
+		},
+		{
+			name:        "error - no action",
+			requestBody: `{"credential_id":1,"resource":"orders"}`,
+			token:       "token",
+			cred:        auth.Credential{ID: 1},
+			wantResp:    `{"error":{"code":"http_400","message":"Action and resource are required."}}` + "\n",
+			wantStatus:  http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			credRep := &mock.CredentialRepositoryMock{
+				ByTokenFunc: func(ctx context.Context, token string) (auth.Credential, error) {
+					if token != "token" {
+						return auth.Credential{}, auth.NewError(auth.ErrCredNotFound, "Credential not found")
+					}
+
+					return tc.cred, nil
+				},
+				ByIDFunc: func(ctx context.Context, id int) (auth.Credential, error) {
+					if id != tc.cred.ID {
+						return auth.Credential{}, auth.NewError(auth.ErrCredNotFound, "Credential not found")
+					}
+
+					return tc.cred, nil
+				},
+			}
+			roleRep := &mock.RoleRepositoryMock{
+				ByCredentialFunc: func(ctx context.Context, credID int) ([]auth.Role, error) {
+					return tc.roles, nil
+				},
+			}
+
+			h := NewRouter(NewCredentialService(credRep, nowFunc, nil, WithRoleRepository(roleRep))).Handler().Server.Handler
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/authorize", strings.NewReader(tc.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if diff := cmp.Diff(tc.wantStatus, rec.Code); diff != "" {
+				t.Error(diff)
+			}
+
+			if diff := cmp.Diff(tc.wantResp, rec.Body.String()); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestUser_ByToken_Permissions(t *testing.T) {
+	credRep := &mock.CredentialRepositoryMock{
+		ByTokenFunc: func(ctx context.Context, token string) (auth.Credential, error) {
+			return auth.Credential{ID: 1, Token: token, CreatedAt: now, UpdatedAt: now}, nil
+		},
+	}
+	roleRep := &mock.RoleRepositoryMock{
+		ByCredentialFunc: func(ctx context.Context, credID int) ([]auth.Role, error) {
+			return []auth.Role{
+				{
+					Name: "editor",
+					Permissions: []auth.Permission{
+						{Action: "write", Resource: "orders"},
+						{Action: "read", Resource: "orders"},
+					},
+				},
+				{
+					Name:        "viewer",
+					Permissions: []auth.Permission{{Action: "read", Resource: "orders"}},
+				},
+			}, nil
+		},
+	}
+
+	h := NewRouter(NewCredentialService(credRep, nowFunc, nil, WithRoleRepository(roleRep))).Handler().Server.Handler
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/users-by-token/token", nil))
+
+	wantResp := `{"id":1,"token":"token","email":"","email_tmp":"","email_verified":false,` +
+		`"roles":["editor","viewer"],` +
+		`"permissions":[{"action":"read","resource":"orders"},{"action":"write","resource":"orders"}],` +
+		`"created_at":"2020-04-15T10:11:12Z","updated_at":"2020-04-15T10:11:12Z"}` + "\n"
+
+	if diff := cmp.Diff(wantResp, rec.Body.String()); diff != "" {
+		t.Error(diff)
+	}
+}
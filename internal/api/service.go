@@ -4,16 +4,103 @@ import (
 	"context"
 	"time"
 
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+
 	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/logging"
+	"github.com/kl09/auth-go/internal/password"
+)
+
+const (
+	tokenLength         = 128
+	instrumentationName = "github.com/kl09/auth-go/internal/api"
+)
+
+// Names of operations reported to Metrics.
+const (
+	opRegister = "register"
+	opAuth     = "auth"
 )
 
-const tokenLength = 128
+// Metrics collects measurements of the CredentialService.
+type Metrics interface {
+	// ObserveHashing records the duration of a password hashing.
+	ObserveHashing(d time.Duration)
+	// ObserveOutcome records the result of an operation, err is nil on success.
+	ObserveOutcome(operation string, err error)
+}
+
+// Hasher hashes passwords and verifies them, see password.Hasher.
+type Hasher interface {
+	// Hash hashes the password with a random salt.
+	Hash(pwd string) (string, error)
+	// Verify compares the password with the hash, rehash reports that the hash is outdated.
+	Verify(hash, pwd string) (ok, rehash bool)
+}
+
+// DefaultHasher returns a Hasher with argon2id of the default cost.
+func DefaultHasher() Hasher {
+	// The default options are valid.
+	h, _ := password.New()
+	return h
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveHashing(time.Duration) {}
+func (noopMetrics) ObserveOutcome(string, error) {}
 
 // CredentialService is a service that works with credentials.
 type CredentialService struct {
 	credentialRepository auth.CredentialRepository
 	nowFn                func() time.Time
 	generatorFn          func(n int) (string, error)
+	metrics              Metrics
+	tracer               trace.Tracer
+	auditLog             auth.AuditLog
+	roleRepository       auth.RoleRepository
+	hasher               Hasher
+}
+
+// ServiceOption configures the CredentialService.
+type ServiceOption func(*CredentialService)
+
+// WithMetrics configures metrics of the CredentialService.
+func WithMetrics(m Metrics) ServiceOption {
+	return func(c *CredentialService) {
+		c.metrics = m
+	}
+}
+
+// WithAuditLog configures a log of security events.
+func WithAuditLog(a auth.AuditLog) ServiceOption {
+	return func(c *CredentialService) {
+		c.auditLog = a
+	}
+}
+
+// WithRoleRepository configures a storage of roles, credentials have no roles without it.
+func WithRoleRepository(r auth.RoleRepository) ServiceOption {
+	return func(c *CredentialService) {
+		c.roleRepository = r
+	}
+}
+
+// WithHasher configures hashing of passwords, DefaultHasher is used without it.
+func WithHasher(h Hasher) ServiceOption {
+	return func(c *CredentialService) {
+		c.hasher = h
+	}
+}
+
+// WithTracerProvider configures tracing of the CredentialService.
+func WithTracerProvider(tp trace.TracerProvider) ServiceOption {
+	return func(c *CredentialService) {
+		c.tracer = tp.Tracer(instrumentationName)
+	}
 }
 
 // NewCredentialService creates a CredentialService.
@@ -21,22 +108,65 @@ func NewCredentialService(
 	r auth.CredentialRepository,
 	nowFn func() time.Time,
 	generatorFn func(n int) (string, error),
+	options ...ServiceOption,
 ) *CredentialService {
-	return &CredentialService{
+	c := &CredentialService{
 		credentialRepository: r,
 		nowFn:                nowFn,
 		generatorFn:          generatorFn,
+		metrics:              noopMetrics{},
+		tracer:               noop.NewTracerProvider().Tracer(instrumentationName),
+		auditLog:             noopAuditLog{},
+		roleRepository:       noopRoleRepository{},
+		hasher:               DefaultHasher(),
 	}
+
+	for _, opt := range options {
+		opt(c)
+	}
+
+	return c
 }
 
 // ByToken retrieves a Credential by token.
-func (c *CredentialService) ByToken(ctx context.Context, token string) (auth.Credential, error) {
-	return c.credentialRepository.ByToken(ctx, token)
+func (c *CredentialService) ByToken(ctx context.Context, token string) (_ auth.Credential, err error) {
+	ctx, span := c.tracer.Start(ctx, "CredentialService.ByToken")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	cred, err := c.credentialRepository.ByToken(ctx, token)
+	if err != nil {
+		return auth.Credential{}, err
+	}
+
+	logging.SetCredentialID(ctx, cred.ID)
+
+	err = statusError(cred, c.nowFn())
+	if err == nil && tokenExpired(cred, c.nowFn()) {
+		err = auth.NewError(auth.ErrTokenExpired, "Token expired")
+	}
+
+	if err != nil {
+		if !purgeable(err) {
+			c.audit(ctx, auth.AuditTokenUse, cred.ID, auth.ErrorCode(err))
+		}
+
+		return auth.Credential{}, err
+	}
+
+	c.audit(ctx, auth.AuditTokenUse, cred.ID, "")
+
+	return cred, nil
 }
 
 // Register creates a new credential.
-func (c *CredentialService) Register(ctx context.Context, cred *auth.Credential) error {
-	var err error
+func (c *CredentialService) Register(ctx context.Context, cred *auth.Credential) (err error) {
+	ctx, span := c.tracer.Start(ctx, "CredentialService.Register")
+	defer func() {
+		endSpan(span, err)
+		c.metrics.ObserveOutcome(opRegister, err)
+	}()
 
 	_, err = c.credentialRepository.ByEmail(ctx, cred.Email)
 	if err == nil {
@@ -47,7 +177,14 @@ func (c *CredentialService) Register(ctx context.Context, cred *auth.Credential)
 		return auth.WrapError(err, auth.ErrInternal, "Register failed")
 	}
 
-	cred.Password, err = hashAndSalt(cred.Password)
+	settings := auth.TenantFromContext(ctx).Settings
+
+	err = settings.PasswordPolicy.Validate(cred.Password)
+	if err != nil {
+		return err
+	}
+
+	cred.Password, err = c.hash(ctx, cred.Password)
 	if err != nil {
 		return err
 	}
@@ -57,23 +194,220 @@ func (c *CredentialService) Register(ctx context.Context, cred *auth.Credential)
 		return err
 	}
 
+	cred.TokenExpiresAt = tokenExpiresAt(c.nowFn(), settings.TokenTTL)
+	cred.Status = auth.StatusActive
 	cred.CreatedAt = c.nowFn()
 	cred.UpdatedAt = c.nowFn()
 
-	return c.credentialRepository.Create(ctx, cred)
+	err = c.credentialRepository.Create(ctx, cred)
+	if err != nil {
+		return err
+	}
+
+	logging.SetCredentialID(ctx, cred.ID)
+	zerolog.Ctx(ctx).Info().Msg("credential registered")
+	c.audit(ctx, auth.AuditRegister, cred.ID, "")
+
+	return nil
 }
 
 // Auth checks user's email/pass.
-func (c *CredentialService) Auth(ctx context.Context, email, plainPassword string) (auth.Credential, error) {
+func (c *CredentialService) Auth(ctx context.Context, email, plainPassword string) (_ auth.Credential, err error) {
+	ctx, span := c.tracer.Start(ctx, "CredentialService.Auth")
+	defer func() {
+		endSpan(span, err)
+		c.metrics.ObserveOutcome(opAuth, err)
+	}()
+
 	cred, err := c.credentialRepository.ByEmail(ctx, email)
 	if err != nil {
+		zerolog.Ctx(ctx).Info().Str("reason", auth.ErrorCode(err)).Msg("auth failed")
+
+		if auth.ErrorCode(err) == auth.ErrCredNotFound {
+			c.audit(ctx, auth.AuditLoginFailure, 0, auth.ErrCredNotFound)
+		}
+
 		return auth.Credential{}, auth.WrapError(err, auth.ErrAuth, "Auth failed")
 	}
 
-	result := comparePasswords(cred.Password, plainPassword)
+	logging.SetCredentialID(ctx, cred.ID)
+
+	result, rehash := c.compare(ctx, cred.Password, plainPassword)
 	if !result {
+		zerolog.Ctx(ctx).Info().Str("reason", auth.ErrAuth).Msg("auth failed")
+		c.audit(ctx, auth.AuditLoginFailure, cred.ID, auth.ErrAuth)
+
 		return auth.Credential{}, auth.NewError(auth.ErrAuth, "Auth failed")
 	}
 
+	// Checked after the password to not disclose the state of the account.
+	err = statusError(cred, c.nowFn())
+	if err != nil {
+		zerolog.Ctx(ctx).Info().Str("reason", auth.ErrorCode(err)).Msg("auth failed")
+		c.audit(ctx, auth.AuditLoginFailure, cred.ID, auth.ErrorCode(err))
+
+		return auth.Credential{}, err
+	}
+
+	if rehash {
+		c.rehash(ctx, &cred, plainPassword)
+	}
+
+	err = c.refreshToken(ctx, &cred)
+	if err != nil {
+		return auth.Credential{}, err
+	}
+
+	c.audit(ctx, auth.AuditLoginSuccess, cred.ID, "")
+
 	return cred, nil
 }
+
+// refreshToken issues a new token if the token of the Credential is expired
+// and sets the expiration of a token issued before the tenant got the token TTL.
+func (c *CredentialService) refreshToken(ctx context.Context, cred *auth.Credential) error {
+	ttl := auth.TenantFromContext(ctx).Settings.TokenTTL
+	now := c.nowFn()
+
+	switch {
+	case tokenExpired(*cred, now):
+		token, err := c.generatorFn(tokenLength)
+		if err != nil {
+			return err
+		}
+
+		cred.Token = token
+		cred.TokenExpiresAt = tokenExpiresAt(now, ttl)
+	case ttl > 0 && cred.TokenExpiresAt.IsZero():
+		cred.TokenExpiresAt = tokenExpiresAt(now, ttl)
+	default:
+		return nil
+	}
+
+	cred.UpdatedAt = now
+
+	return c.credentialRepository.Update(ctx, cred)
+}
+
+// rehash replaces an outdated hash of the password of the Credential,
+// a failed rehash is logged and doesn't fail the login as the old hash still works.
+func (c *CredentialService) rehash(ctx context.Context, cred *auth.Credential, plainPassword string) {
+	hash, err := c.hash(ctx, plainPassword)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("password rehash failed")
+		return
+	}
+
+	updated := *cred
+	updated.Password = hash
+	updated.UpdatedAt = c.nowFn()
+
+	err = c.credentialRepository.Update(ctx, &updated)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("password rehash failed")
+		return
+	}
+
+	*cred = updated
+}
+
+// Roles retrieves Roles assigned to a Credential.
+func (c *CredentialService) Roles(ctx context.Context, credID int) (_ []auth.Role, err error) {
+	ctx, span := c.tracer.Start(ctx, "CredentialService.Roles")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	return c.roleRepository.ByCredential(ctx, credID)
+}
+
+// Authorize checks if an active Credential is allowed to do the action on the resource.
+func (c *CredentialService) Authorize(ctx context.Context, credID int, action, resource string) (_ bool, err error) {
+	ctx, span := c.tracer.Start(ctx, "CredentialService.Authorize")
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	cred, err := c.credentialRepository.ByID(ctx, credID)
+	if err != nil {
+		return false, err
+	}
+
+	if statusError(cred, c.nowFn()) != nil {
+		return false, nil
+	}
+
+	roles, err := c.roleRepository.ByCredential(ctx, credID)
+	if err != nil {
+		return false, err
+	}
+
+	return auth.Allowed(auth.EffectivePermissions(roles), action, resource), nil
+}
+
+// hash hashes the password reporting the duration.
+func (c *CredentialService) hash(ctx context.Context, pwd string) (string, error) {
+	_, span := c.tracer.Start(ctx, "password.hash")
+	defer span.End()
+
+	started := time.Now()
+	hash, err := c.hasher.Hash(pwd)
+	c.metrics.ObserveHashing(time.Since(started))
+
+	return hash, err
+}
+
+// compare compares the password with the hash reporting the duration,
+// rehash reports that the hash is outdated.
+func (c *CredentialService) compare(ctx context.Context, hash, pwd string) (ok, rehash bool) {
+	_, span := c.tracer.Start(ctx, "password.compare")
+	defer span.End()
+
+	started := time.Now()
+	ok, rehash = c.hasher.Verify(hash, pwd)
+	c.metrics.ObserveHashing(time.Since(started))
+
+	return ok, rehash
+}
+
+// tokenExpired checks if the token of the Credential is expired.
+func tokenExpired(cred auth.Credential, now time.Time) bool {
+	return !cred.TokenExpiresAt.IsZero() && !now.Before(cred.TokenExpiresAt)
+}
+
+// tokenExpiresAt returns the expiration of a token issued now, zero if tokens don't expire.
+func tokenExpiresAt(now time.Time, ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+
+	return now.Add(ttl)
+}
+
+// statusError returns an error if the status of the Credential doesn't allow to use it.
+func statusError(cred auth.Credential, now time.Time) error {
+	switch cred.StatusAt(now) {
+	case auth.StatusActive:
+		return nil
+	case auth.StatusSuspended:
+		if cred.StatusUntil.IsZero() {
+			return auth.NewError(auth.ErrCredSuspended, "Credential is suspended")
+		}
+
+		return auth.NewError(auth.ErrCredSuspended, "Credential is suspended until "+cred.StatusUntil.Format(time.RFC3339))
+	case auth.StatusPendingDeletion:
+		return auth.NewError(auth.ErrCredPendingDeletion, "Credential is pending deletion")
+	default:
+		return auth.NewError(auth.ErrCredDisabled, "Credential is disabled")
+	}
+}
+
+// endSpan ends the span recording err if it is not nil.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, auth.ErrorCode(err))
+	}
+
+	span.End()
+}
@@ -2,10 +2,17 @@ package api
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"golang.org/x/crypto/bcrypt"
 
 	auth "github.com/kl09/auth-go"
 	"github.com/kl09/auth-go/internal/mock"
@@ -42,7 +49,7 @@ func TestCredentialService_Register(t *testing.T) {
 	require.NotNil(t, cred.Token)
 	require.Equal(t, cred.Token, "1234abcd")
 
-	require.True(t, comparePasswords(cred.Password, plainPass))
+	require.True(t, passwordMatches(cred.Password, plainPass))
 
 	require.Equal(t, now.String(), cred.CreatedAt.String())
 	require.Equal(t, now.String(), cred.UpdatedAt.String())
@@ -51,7 +58,7 @@ func TestCredentialService_Register(t *testing.T) {
 func TestCredentialService_Auth(t *testing.T) {
 	token := "1234abcd"
 
-	hash, err := hashAndSalt("password_12345_1122")
+	hash, err := testHasher.Hash("password_12345_1122")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -116,6 +123,100 @@ func TestCredentialService_Auth(t *testing.T) {
 			},
 			expectedErr: auth.NewError(auth.ErrAuth, "Auth failed"),
 		},
+		{
+			name:   "error - disabled",
+			email:  "example@example.org",
+			passwd: "password_12345_1122",
+			credRep: &mock.CredentialRepositoryMock{
+				ByEmailFunc: func(ctx context.Context, email string) (auth.Credential, error) {
+					return auth.Credential{
+						ID:       1,
+						Password: hash,
+						Token:    token,
+						Email:    "example@example.org",
+						Status:   auth.StatusDisabled,
+					}, nil
+				},
+			},
+			expectedErr: auth.NewError(auth.ErrCredDisabled, "Credential is disabled"),
+		},
+		{
+			name:   "error - suspended",
+			email:  "example@example.org",
+			passwd: "password_12345_1122",
+			credRep: &mock.CredentialRepositoryMock{
+				ByEmailFunc: func(ctx context.Context, email string) (auth.Credential, error) {
+					return auth.Credential{
+						ID:       1,
+						Password: hash,
+						Token:    token,
+						Email:    "example@example.org",
+						Status:   auth.StatusSuspended,
+					}, nil
+				},
+			},
+			expectedErr: auth.NewError(auth.ErrCredSuspended, "Credential is suspended"),
+		},
+		{
+			name:   "error - suspended until",
+			email:  "example@example.org",
+			passwd: "password_12345_1122",
+			credRep: &mock.CredentialRepositoryMock{
+				ByEmailFunc: func(ctx context.Context, email string) (auth.Credential, error) {
+					return auth.Credential{
+						ID:          1,
+						Password:    hash,
+						Token:       token,
+						Email:       "example@example.org",
+						Status:      auth.StatusSuspended,
+						StatusUntil: now.Add(time.Hour),
+					}, nil
+				},
+			},
+			expectedErr: auth.NewError(auth.ErrCredSuspended, "Credential is suspended until 2020-04-15T11:11:12Z"),
+		},
+		{
+			name:   "success - suspension expired",
+			email:  "example@example.org",
+			passwd: "password_12345_1122",
+			credRep: &mock.CredentialRepositoryMock{
+				ByEmailFunc: func(ctx context.Context, email string) (auth.Credential, error) {
+					return auth.Credential{
+						ID:          1,
+						Password:    hash,
+						Token:       token,
+						Email:       "example@example.org",
+						Status:      auth.StatusSuspended,
+						StatusUntil: now,
+					}, nil
+				},
+			},
+			expected: auth.Credential{
+				ID:          1,
+				Password:    hash,
+				Token:       token,
+				Email:       "example@example.org",
+				Status:      auth.StatusSuspended,
+				StatusUntil: now,
+			},
+		},
+		{
+			name:   "error - pending deletion",
+			email:  "example@example.org",
+			passwd: "password_12345_1122",
+			credRep: &mock.CredentialRepositoryMock{
+				ByEmailFunc: func(ctx context.Context, email string) (auth.Credential, error) {
+					return auth.Credential{
+						ID:       1,
+						Password: hash,
+						Token:    token,
+						Email:    "example@example.org",
+						Status:   auth.StatusPendingDeletion,
+					}, nil
+				},
+			},
+			expectedErr: auth.NewError(auth.ErrCredPendingDeletion, "Credential is pending deletion"),
+		},
 	}
 
 	for _, tc := range testCases {
@@ -143,3 +244,225 @@ func TestCredentialService_Auth(t *testing.T) {
 		})
 	}
 }
+
+type fakeMetrics struct {
+	hashings int
+	outcomes map[string]string
+}
+
+func (f *fakeMetrics) ObserveHashing(d time.Duration) {
+	f.hashings++
+}
+
+func (f *fakeMetrics) ObserveOutcome(operation string, err error) {
+	result := "success"
+	if err != nil {
+		result = auth.ErrorCode(err)
+	}
+
+	f.outcomes[operation] = result
+}
+
+func TestCredentialService_Metrics(t *testing.T) {
+	m := &fakeMetrics{outcomes: make(map[string]string)}
+
+	s := NewCredentialService(&mock.CredentialRepositoryMock{
+		CreateFunc: func(ctx context.Context, c *auth.Credential) error {
+			return nil
+		},
+		ByEmailFunc: func(ctx context.Context, email string) (auth.Credential, error) {
+			return auth.Credential{}, auth.NewError(auth.ErrCredNotFound, "Credential not found")
+		},
+	},
+		nowFunc,
+		func(n int) (string, error) {
+			return "1234abcd", nil
+		},
+		WithMetrics(m),
+	)
+
+	require.Nil(t, s.Register(context.Background(), &auth.Credential{Email: "example@example.org", Password: "12345"}))
+
+	_, err := s.Auth(context.Background(), "example@example.org", "12345")
+	require.NotNil(t, err)
+
+	require.Equal(t, 1, m.hashings)
+	require.Equal(t, map[string]string{"register": "success", "auth": auth.ErrAuth}, m.outcomes)
+}
+
+func TestCredentialService_Tracing(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+
+	hash, err := testHasher.Hash("12345")
+	require.Nil(t, err)
+
+	s := NewCredentialService(&mock.CredentialRepositoryMock{
+		ByEmailFunc: func(ctx context.Context, email string) (auth.Credential, error) {
+			return auth.Credential{ID: 1, Password: hash, Email: email}, nil
+		},
+	},
+		nowFunc,
+		nil,
+		WithTracerProvider(tp),
+	)
+
+	_, err = s.Auth(context.Background(), "example@example.org", "54321")
+	require.NotNil(t, err)
+
+	spans := exp.GetSpans()
+	require.Len(t, spans, 2)
+
+	compare, authSpan := spans[0], spans[1]
+	require.Equal(t, "password.compare", compare.Name)
+	require.Equal(t, "CredentialService.Auth", authSpan.Name)
+	require.Equal(t, authSpan.SpanContext.SpanID(), compare.Parent.SpanID())
+	require.Equal(t, codes.Error, authSpan.Status.Code)
+	require.Equal(t, auth.ErrAuth, authSpan.Status.Description)
+}
+
+func TestCredentialService_AuditLog(t *testing.T) {
+	hash, err := testHasher.Hash("password_12345_1122")
+	require.Nil(t, err)
+
+	testCases := []struct {
+		name      string
+		email     string
+		passwd    string
+		wantEntry auth.AuditEntry
+	}{
+		{
+			name:   "login success",
+			email:  "example@example.org",
+			passwd: "password_12345_1122",
+			wantEntry: auth.AuditEntry{
+				CredentialID: 1,
+				Event:        auth.AuditLoginSuccess,
+				CreatedAt:    now,
+			},
+		},
+		{
+			name:   "login failure - bad password",
+			email:  "example@example.org",
+			passwd: "12345",
+			wantEntry: auth.AuditEntry{
+				CredentialID: 1,
+				Event:        auth.AuditLoginFailure,
+				Reason:       auth.ErrAuth,
+				CreatedAt:    now,
+			},
+		},
+		{
+			name:   "login failure - unknown email",
+			email:  "unknown@example.org",
+			passwd: "12345",
+			wantEntry: auth.AuditEntry{
+				Event:     auth.AuditLoginFailure,
+				Reason:    auth.ErrCredNotFound,
+				CreatedAt: now,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			auditLog := &mock.AuditLogMock{
+				AppendFunc: func(ctx context.Context, e *auth.AuditEntry) error {
+					return nil
+				},
+			}
+
+			s := NewCredentialService(&mock.CredentialRepositoryMock{
+				ByEmailFunc: func(ctx context.Context, email string) (auth.Credential, error) {
+					if email != "example@example.org" {
+						return auth.Credential{}, auth.NewError(auth.ErrCredNotFound, "Credential not found")
+					}
+
+					return auth.Credential{ID: 1, Password: hash, Email: email}, nil
+				},
+			},
+				nowFunc,
+				nil,
+				WithAuditLog(auditLog),
+			)
+
+			_, _ = s.Auth(context.Background(), tc.email, tc.passwd)
+
+			calls := auditLog.AppendCalls()
+			require.Len(t, calls, 1)
+
+			if diff := cmp.Diff(tc.wantEntry, *calls[0].E); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestCredentialService_Rehash(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password_12345_1122"), bcrypt.MinCost)
+	require.Nil(t, err)
+
+	current, err := testHasher.Hash("password_12345_1122")
+	require.Nil(t, err)
+
+	testCases := []struct {
+		name        string
+		hash        string
+		updateErr   error
+		wantUpdates int
+		wantHash    bool
+	}{
+		{
+			name:        "legacy hash is upgraded",
+			hash:        string(legacy),
+			wantUpdates: 1,
+			wantHash:    true,
+		},
+		{
+			name:        "imported pbkdf2 hash is upgraded",
+			hash:        "$pbkdf2-sha256$i=1000$c2FsdA$zZwjy8pS/gjzwF5dy/xC4SHgi1FGvTYouW6msi9F3bQ",
+			wantUpdates: 1,
+			wantHash:    true,
+		},
+		{
+			name:        "failed upgrade keeps the login",
+			hash:        string(legacy),
+			updateErr:   errors.New("db error"),
+			wantUpdates: 1,
+		},
+		{
+			name: "current hash is kept",
+			hash: current,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			credRep := &mock.CredentialRepositoryMock{
+				ByEmailFunc: func(ctx context.Context, email string) (auth.Credential, error) {
+					return auth.Credential{ID: 1, Password: tc.hash, Email: email}, nil
+				},
+				UpdateFunc: func(ctx context.Context, cred *auth.Credential) error {
+					return tc.updateErr
+				},
+			}
+
+			s := NewCredentialService(credRep, nowFunc, nil)
+
+			cred, err := s.Auth(context.Background(), "example@example.org", "password_12345_1122")
+			require.Nil(t, err)
+
+			calls := credRep.UpdateCalls()
+			require.Len(t, calls, tc.wantUpdates)
+
+			if tc.wantUpdates > 0 {
+				updated := calls[0].C
+				require.True(t, strings.HasPrefix(updated.Password, "$argon2id$"))
+				require.True(t, passwordMatches(updated.Password, "password_12345_1122"))
+				require.Equal(t, now, updated.UpdatedAt)
+			}
+
+			require.Equal(t, tc.wantHash, cred.Password != tc.hash)
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/logging"
+)
+
+const (
+	// HeaderTenantID is a header selecting the tenant of a request.
+	HeaderTenantID = "X-Tenant-ID"
+
+	tenantCacheTTL = 30 * time.Second
+	// maxTenantCacheSize bounds the cache filled by arbitrary hosts and headers of requests.
+	maxTenantCacheSize = 1024
+)
+
+type cachedTenant struct {
+	tenant    auth.Tenant
+	err       error
+	expiresAt time.Time
+}
+
+// tenantResolver resolves tenants of requests caching them for a short time,
+// so settings changes are applied without a restart.
+type tenantResolver struct {
+	repository auth.TenantRepository
+	nowFn      func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cachedTenant
+}
+
+func newTenantResolver(r auth.TenantRepository, nowFn func() time.Time) *tenantResolver {
+	return &tenantResolver{
+		repository: r,
+		nowFn:      nowFn,
+		cache:      make(map[string]cachedTenant),
+	}
+}
+
+// middleware puts the tenant into the request context.
+// The tenant is taken from the X-Tenant-ID header, the host or the default one.
+func (t *tenantResolver) middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+
+		tenant, err := t.resolve(req.Context(), req.Header.Get(HeaderTenantID), hostname(req.Host))
+		if err != nil {
+			return err
+		}
+
+		logging.SetTenantID(req.Context(), tenant.ID)
+		c.SetRequest(req.WithContext(auth.ContextWithTenant(req.Context(), tenant)))
+
+		return next(c)
+	}
+}
+
+func (t *tenantResolver) resolve(ctx context.Context, id, host string) (auth.Tenant, error) {
+	if id != "" {
+		return t.cached(ctx, "id:"+id, func() (auth.Tenant, error) {
+			return t.repository.ByID(ctx, id)
+		})
+	}
+
+	tenant, err := t.cached(ctx, "host:"+host, func() (auth.Tenant, error) {
+		return t.repository.ByHost(ctx, host)
+	})
+	if auth.ErrorCode(err) != auth.ErrTenantNotFound {
+		return tenant, err
+	}
+
+	return t.cached(ctx, "id:"+auth.DefaultTenantID, func() (auth.Tenant, error) {
+		return t.repository.ByID(ctx, auth.DefaultTenantID)
+	})
+}
+
+// cached returns the cached result of fn, not found tenants are cached too.
+func (t *tenantResolver) cached(ctx context.Context, key string, fn func() (auth.Tenant, error)) (auth.Tenant, error) {
+	now := t.nowFn()
+
+	t.mu.Lock()
+	c, ok := t.cache[key]
+	t.mu.Unlock()
+
+	if ok && now.Before(c.expiresAt) {
+		return c.tenant, c.err
+	}
+
+	tenant, err := fn()
+	if err != nil && auth.ErrorCode(err) != auth.ErrTenantNotFound {
+		return auth.Tenant{}, err
+	}
+
+	t.mu.Lock()
+	if len(t.cache) >= maxTenantCacheSize {
+		t.cache = make(map[string]cachedTenant)
+	}
+	t.cache[key] = cachedTenant{tenant: tenant, err: err, expiresAt: now.Add(tenantCacheTTL)}
+	t.mu.Unlock()
+
+	return tenant, err
+}
+
+// hostname strips the port of the host.
+func hostname(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return strings.ToLower(host)
+}
@@ -0,0 +1,283 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/mock"
+)
+
+func TestTenantResolver(t *testing.T) {
+	tenants := map[string]auth.Tenant{
+		"default": {ID: "default"},
+		"shop":    {ID: "shop", Hosts: []string{"shop.example.org"}},
+	}
+
+	cases := []struct {
+		name       string
+		host       string
+		header     string
+		wantTenant string
+		wantStatus int
+	}{
+		{
+			name:       "by header",
+			host:       "shop.example.org",
+			header:     "default",
+			wantTenant: "default",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "by host",
+			host:       "Shop.Example.org:8080",
+			wantTenant: "shop",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "default",
+			host:       "localhost:8080",
+			wantTenant: "default",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "error - unknown tenant in header",
+			host:       "shop.example.org",
+			header:     "unknown",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &mock.TenantRepositoryMock{
+				ByIDFunc: func(ctx context.Context, id string) (auth.Tenant, error) {
+					tenant, ok := tenants[id]
+					if !ok {
+						return auth.Tenant{}, auth.NewError(auth.ErrTenantNotFound, "Tenant not found")
+					}
+
+					return tenant, nil
+				},
+				ByHostFunc: func(ctx context.Context, host string) (auth.Tenant, error) {
+					for _, tenant := range tenants {
+						for _, h := range tenant.Hosts {
+							if h == host {
+								return tenant, nil
+							}
+						}
+					}
+
+					return auth.Tenant{}, auth.NewError(auth.ErrTenantNotFound, "Tenant not found")
+				},
+			}
+
+			var gotTenant string
+
+			e := echo.New()
+			e.HTTPErrorHandler = customHTTPErrorHandler
+			e.Use(newTenantResolver(repo, nowFunc).middleware)
+			e.GET("/", func(c echo.Context) error {
+				gotTenant = auth.TenantFromContext(c.Request().Context()).ID
+				return c.NoContent(http.StatusOK)
+			})
+
+			// The second request is served from the cache.
+			for i := 0; i < 2; i++ {
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.Host = tc.host
+				if tc.header != "" {
+					req.Header.Set(HeaderTenantID, tc.header)
+				}
+
+				rec := httptest.NewRecorder()
+				e.ServeHTTP(rec, req)
+
+				if diff := cmp.Diff(tc.wantStatus, rec.Code); diff != "" {
+					t.Fatal(diff)
+				}
+
+				if diff := cmp.Diff(tc.wantTenant, gotTenant); diff != "" {
+					t.Fatal(diff)
+				}
+			}
+
+			if len(repo.ByIDCalls())+len(repo.ByHostCalls()) > 2 {
+				t.Errorf("tenant is not cached: %d ByID and %d ByHost calls", len(repo.ByIDCalls()), len(repo.ByHostCalls()))
+			}
+		})
+	}
+}
+
+func TestCredentialService_TokenTTL(t *testing.T) {
+	hash, err := testHasher.Hash("password_12345_1122")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := auth.ContextWithTenant(context.Background(), auth.Tenant{
+		ID:       "shop",
+		Settings: auth.TenantSettings{TokenTTL: time.Hour},
+	})
+
+	cases := []struct {
+		name      string
+		stored    auth.Credential
+		wantToken string
+		wantExp   time.Time
+		wantSaved bool
+	}{
+		{
+			name:      "valid token",
+			stored:    auth.Credential{ID: 1, Password: hash, Token: "token", TokenExpiresAt: now.Add(time.Minute)},
+			wantToken: "token",
+			wantExp:   now.Add(time.Minute),
+		},
+		{
+			name:      "expired token is replaced",
+			stored:    auth.Credential{ID: 1, Password: hash, Token: "token", TokenExpiresAt: now},
+			wantToken: "new_token",
+			wantExp:   now.Add(time.Hour),
+			wantSaved: true,
+		},
+		{
+			name:      "token without expiration gets one",
+			stored:    auth.Credential{ID: 1, Password: hash, Token: "token"},
+			wantToken: "token",
+			wantExp:   now.Add(time.Hour),
+			wantSaved: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			credRep := &mock.CredentialRepositoryMock{
+				ByEmailFunc: func(ctx context.Context, email string) (auth.Credential, error) {
+					return tc.stored, nil
+				},
+				UpdateFunc: func(ctx context.Context, c *auth.Credential) error {
+					return nil
+				},
+			}
+
+			s := NewCredentialService(credRep, nowFunc, func(n int) (string, error) {
+				return "new_token", nil
+			})
+
+			cred, err := s.Auth(ctx, "example@example.org", "password_12345_1122")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tc.wantToken, cred.Token); diff != "" {
+				t.Error(diff)
+			}
+
+			if diff := cmp.Diff(tc.wantExp, cred.TokenExpiresAt); diff != "" {
+				t.Error(diff)
+			}
+
+			if diff := cmp.Diff(tc.wantSaved, len(credRep.UpdateCalls()) == 1); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestCredentialService_ByToken_Expired(t *testing.T) {
+	s := NewCredentialService(&mock.CredentialRepositoryMock{
+		ByTokenFunc: func(ctx context.Context, token string) (auth.Credential, error) {
+			return auth.Credential{ID: 1, Token: token, TokenExpiresAt: now}, nil
+		},
+	}, nowFunc, nil)
+
+	_, err := s.ByToken(context.Background(), "token")
+
+	require.Equal(t, auth.NewError(auth.ErrTokenExpired, "Token expired"), err)
+}
+
+func TestCredentialService_Register_PasswordPolicy(t *testing.T) {
+	policy := auth.PasswordPolicy{
+		MinLength:    8,
+		MaxLength:    16,
+		RequireUpper: true,
+		RequireDigit: true,
+	}
+
+	cases := []struct {
+		name    string
+		passwd  string
+		wantErr error
+	}{
+		{
+			name:   "valid",
+			passwd: "Password1",
+		},
+		{
+			name:    "too short",
+			passwd:  "Pass1",
+			wantErr: auth.NewError(auth.ErrPasswordPolicy, "Password must be at least 8 characters long."),
+		},
+		{
+			name:    "too long",
+			passwd:  "Password1Password1",
+			wantErr: auth.NewError(auth.ErrPasswordPolicy, "Password must be at most 16 characters long."),
+		},
+		{
+			name:    "no uppercase",
+			passwd:  "password1",
+			wantErr: auth.NewError(auth.ErrPasswordPolicy, "Password must contain an uppercase letter."),
+		},
+		{
+			name:    "no digit",
+			passwd:  "Password",
+			wantErr: auth.NewError(auth.ErrPasswordPolicy, "Password must contain a digit."),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := auth.ContextWithTenant(context.Background(), auth.Tenant{
+				ID:       "shop",
+				Settings: auth.TenantSettings{PasswordPolicy: policy, TokenTTL: time.Hour},
+			})
+
+			credRep := &mock.CredentialRepositoryMock{
+				ByEmailFunc: func(ctx context.Context, email string) (auth.Credential, error) {
+					return auth.Credential{}, auth.NewError(auth.ErrCredNotFound, "Credential not found")
+				},
+				CreateFunc: func(ctx context.Context, c *auth.Credential) error {
+					return nil
+				},
+			}
+
+			s := NewCredentialService(credRep, nowFunc, func(n int) (string, error) {
+				return "token", nil
+			})
+
+			cred := auth.Credential{Email: "example@example.org", Password: tc.passwd}
+			err := s.Register(ctx, &cred)
+
+			require.Equal(t, tc.wantErr, err)
+
+			if tc.wantErr != nil {
+				if len(credRep.CreateCalls()) != 0 {
+					t.Fatal("credential shouldn't be created")
+				}
+
+				return
+			}
+
+			if diff := cmp.Diff(now.Add(time.Hour), cred.TokenExpiresAt); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	auth "github.com/kl09/auth-go"
+)
+
+// HeaderAPIKey is a header authenticating a request with an API key instead of a token.
+const HeaderAPIKey = "X-API-Key"
+
+type (
+	credentialKey struct{}
+	apiKeyKey     struct{}
+)
+
+// userAuth allows only requests of an active credential authenticated by an API key in the X-API-Key header
+// or a token in the Authorization header and puts the credential into the request context.
+func userAuth(credService auth.CredentialService, apiKeys auth.APIKeyService) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			ctx := req.Context()
+
+			var (
+				cred auth.Credential
+				err  error
+			)
+
+			if key := req.Header.Get(HeaderAPIKey); key != "" && apiKeys != nil {
+				var k auth.APIKey
+
+				cred, k, err = apiKeys.Authenticate(ctx, key)
+				if err != nil {
+					return err
+				}
+
+				ctx = context.WithValue(ctx, apiKeyKey{}, k)
+			} else {
+				token := strings.TrimPrefix(req.Header.Get(echo.HeaderAuthorization), "Bearer ")
+				if token == "" {
+					return echo.NewHTTPError(http.StatusUnauthorized)
+				}
+
+				cred, err = credService.ByToken(ctx, token)
+				if err != nil {
+					if auth.ErrorHas(err, auth.ErrCredNotFound) != nil {
+						return echo.NewHTTPError(http.StatusUnauthorized)
+					}
+
+					return err
+				}
+			}
+
+			c.SetRequest(req.WithContext(context.WithValue(ctx, credentialKey{}, cred)))
+
+			return next(c)
+		}
+	}
+}
+
+// requireScope allows requests authenticated by an API key only if its scopes allow the action on the resource,
+// requests authenticated by a token are allowed.
+func requireScope(action, resource string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			k, ok := c.Request().Context().Value(apiKeyKey{}).(auth.APIKey)
+			if ok && !auth.Allowed(k.Scopes, action, resource) {
+				return auth.NewError(auth.ErrPermissionDenied, "API key scopes don't allow "+action+" on "+resource+".")
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// tokenOnly allows only requests authenticated by a token, e.g. to not let API keys manage API keys.
+func tokenOnly(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if _, ok := c.Request().Context().Value(apiKeyKey{}).(auth.APIKey); ok {
+			return auth.NewError(auth.ErrPermissionDenied, "Token is required.")
+		}
+
+		return next(c)
+	}
+}
+
+// credentialFromContext returns the credential authenticated by userAuth.
+func credentialFromContext(ctx context.Context) auth.Credential {
+	cred, _ := ctx.Value(credentialKey{}).(auth.Credential)
+	return cred
+}
@@ -0,0 +1,186 @@
+// Package audit implements decorators of the audit log.
+package audit
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/logging"
+)
+
+const (
+	defaultBatchSize     = 100
+	defaultFlushInterval = time.Second
+	defaultQueueSize     = 10000
+)
+
+// BatchAppender is an AuditLog able to append many entries at once.
+type BatchAppender interface {
+	auth.AuditLog
+	// AppendBatch records new entries in the tenant of the context.
+	AppendBatch(ctx context.Context, entries []*auth.AuditEntry) error
+}
+
+// BatchLog appends entries of frequent events, e.g. token use, asynchronously in batches,
+// so recording them doesn't cost a query on every request. Entries of other events are appended
+// by the wrapped log synchronously. Queued entries are appended by Run, they are rejected if the queue is full.
+type BatchLog struct {
+	BatchAppender
+
+	events        map[string]struct{}
+	batchSize     int
+	flushInterval time.Duration
+	queueSize     int
+	logger        zerolog.Logger
+
+	queue chan queued
+}
+
+type queued struct {
+	tenant auth.Tenant
+	entry  *auth.AuditEntry
+}
+
+// ConfigOption configures the BatchLog.
+type ConfigOption func(l *BatchLog)
+
+// WithBatchSize configures the max number of entries appended at once, the default is used if n isn't positive.
+func WithBatchSize(n int) ConfigOption {
+	return func(l *BatchLog) {
+		if n > 0 {
+			l.batchSize = n
+		}
+	}
+}
+
+// WithFlushInterval configures how long entries may wait for a full batch, the default is used if d isn't positive.
+func WithFlushInterval(d time.Duration) ConfigOption {
+	return func(l *BatchLog) {
+		if d > 0 {
+			l.flushInterval = d
+		}
+	}
+}
+
+// WithQueueSize configures the max number of queued entries, the default is used if n isn't positive.
+func WithQueueSize(n int) ConfigOption {
+	return func(l *BatchLog) {
+		if n > 0 {
+			l.queueSize = n
+		}
+	}
+}
+
+// WithLogger configures a logger of failed appends.
+func WithLogger(logger zerolog.Logger) ConfigOption {
+	return func(l *BatchLog) {
+		l.logger = logger
+	}
+}
+
+// NewBatchLog creates a new BatchLog appending entries of the events to next in batches.
+func NewBatchLog(next BatchAppender, events []string, options ...ConfigOption) *BatchLog {
+	l := &BatchLog{
+		BatchAppender: next,
+		events:        make(map[string]struct{}, len(events)),
+		batchSize:     defaultBatchSize,
+		flushInterval: defaultFlushInterval,
+		queueSize:     defaultQueueSize,
+		logger:        zerolog.New(io.Discard),
+	}
+
+	for _, e := range events {
+		l.events[e] = struct{}{}
+	}
+
+	for _, opt := range options {
+		opt(l)
+	}
+
+	l.queue = make(chan queued, l.queueSize)
+
+	return l
+}
+
+// Append queues the entry of a batched event or appends an entry of another one.
+func (l *BatchLog) Append(ctx context.Context, e *auth.AuditEntry) error {
+	if _, ok := l.events[e.Event]; !ok {
+		return l.BatchAppender.Append(ctx, e)
+	}
+
+	select {
+	case l.queue <- queued{tenant: auth.TenantFromContext(ctx), entry: e}:
+		return nil
+	default:
+		return auth.NewError(auth.ErrInternal, "Audit log queue is full")
+	}
+}
+
+// Run appends queued entries once a batch is full or the flush interval passes until ctx is done,
+// the remaining entries are appended before it returns. It must be stopped after the last Append.
+func (l *BatchLog) Run(ctx context.Context) error {
+	ticker := time.NewTicker(l.flushInterval)
+	defer ticker.Stop()
+
+	// A flush in progress isn't interrupted, queries are limited by the timeout of the storage.
+	flushCtx := context.WithoutCancel(ctx)
+	batch := make([]queued, 0, l.batchSize)
+
+	for {
+		select {
+		case q := <-l.queue:
+			batch = append(batch, q)
+			if len(batch) < l.batchSize {
+				continue
+			}
+		case <-ticker.C:
+		case <-ctx.Done():
+			for {
+				select {
+				case q := <-l.queue:
+					batch = append(batch, q)
+				default:
+					l.flush(flushCtx, batch)
+					return nil
+				}
+			}
+		}
+
+		l.flush(flushCtx, batch)
+		batch = batch[:0]
+	}
+}
+
+// flush appends the entries grouped by tenant, entries of a failed append are logged as lost.
+func (l *BatchLog) flush(ctx context.Context, batch []queued) {
+	if len(batch) == 0 {
+		return
+	}
+
+	var (
+		tenants  []auth.Tenant
+		byTenant = make(map[string][]*auth.AuditEntry)
+	)
+
+	for _, q := range batch {
+		if _, ok := byTenant[q.tenant.ID]; !ok {
+			tenants = append(tenants, q.tenant)
+		}
+
+		byTenant[q.tenant.ID] = append(byTenant[q.tenant.ID], q.entry)
+	}
+
+	logger := logging.FromContext(ctx, &l.logger)
+
+	for _, t := range tenants {
+		entries := byTenant[t.ID]
+
+		if err := l.AppendBatch(auth.ContextWithTenant(ctx, t), entries); err != nil {
+			logger.Error().Err(err).Str("tenant_id", t.ID).Int("entries", len(entries)).Msg("audit log batch append failed")
+		}
+	}
+}
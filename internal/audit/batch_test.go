@@ -0,0 +1,133 @@
+package audit_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/audit"
+	"github.com/kl09/auth-go/internal/mock"
+)
+
+// batchLog records batches appended by tenants.
+type batchLog struct {
+	*mock.AuditLogMock
+
+	mu      sync.Mutex
+	batches map[string][][]string
+	flushed chan struct{}
+}
+
+func newBatchLog() *batchLog {
+	return &batchLog{
+		AuditLogMock: &mock.AuditLogMock{
+			AppendFunc: func(ctx context.Context, e *auth.AuditEntry) error {
+				return nil
+			},
+		},
+		batches: make(map[string][][]string),
+		flushed: make(chan struct{}, 10),
+	}
+}
+
+func (l *batchLog) AppendBatch(ctx context.Context, entries []*auth.AuditEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var events []string
+	for _, e := range entries {
+		events = append(events, e.Event)
+	}
+
+	tenantID := auth.TenantFromContext(ctx).ID
+	l.batches[tenantID] = append(l.batches[tenantID], events)
+	l.flushed <- struct{}{}
+
+	return nil
+}
+
+func (l *batchLog) Batches() map[string][][]string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.batches
+}
+
+var batched = []string{auth.AuditTokenUse, auth.AuditAPIKeyUse}
+
+func TestBatchLog_Append(t *testing.T) {
+	ctx := context.Background()
+	other := auth.ContextWithTenant(ctx, auth.Tenant{ID: "other"})
+	next := newBatchLog()
+	l := audit.NewBatchLog(next, batched, audit.WithBatchSize(3), audit.WithFlushInterval(time.Hour))
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error)
+
+	go func() {
+		done <- l.Run(runCtx)
+	}()
+
+	require.Nil(t, l.Append(ctx, &auth.AuditEntry{Event: auth.AuditLoginSuccess}))
+	require.Len(t, next.AppendCalls(), 1, "other events are appended synchronously")
+
+	require.Nil(t, l.Append(ctx, &auth.AuditEntry{Event: auth.AuditTokenUse}))
+	require.Nil(t, l.Append(other, &auth.AuditEntry{Event: auth.AuditAPIKeyUse}))
+	require.Nil(t, l.Append(ctx, &auth.AuditEntry{Event: auth.AuditAPIKeyUse}))
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-next.flushed:
+		case <-time.After(5 * time.Second):
+			t.Fatal("full batch wasn't appended")
+		}
+	}
+
+	require.Nil(t, l.Append(ctx, &auth.AuditEntry{Event: auth.AuditTokenUse}))
+
+	cancel()
+	require.Nil(t, <-done)
+
+	assert.Len(t, next.AppendCalls(), 1)
+	assert.Equal(t, map[string][][]string{
+		auth.DefaultTenantID: {{auth.AuditTokenUse, auth.AuditAPIKeyUse}, {auth.AuditTokenUse}},
+		"other":              {{auth.AuditAPIKeyUse}},
+	}, next.Batches())
+}
+
+func TestBatchLog_FlushInterval(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	next := newBatchLog()
+	l := audit.NewBatchLog(next, batched, audit.WithFlushInterval(10*time.Millisecond))
+
+	go func() {
+		_ = l.Run(ctx)
+	}()
+
+	require.Nil(t, l.Append(ctx, &auth.AuditEntry{Event: auth.AuditTokenUse}))
+
+	select {
+	case <-next.flushed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("batch wasn't appended")
+	}
+
+	assert.Equal(t, map[string][][]string{auth.DefaultTenantID: {{auth.AuditTokenUse}}}, next.Batches())
+}
+
+func TestBatchLog_QueueFull(t *testing.T) {
+	ctx := context.Background()
+	l := audit.NewBatchLog(newBatchLog(), batched, audit.WithQueueSize(1))
+
+	require.Nil(t, l.Append(ctx, &auth.AuditEntry{Event: auth.AuditTokenUse}))
+
+	err := l.Append(ctx, &auth.AuditEntry{Event: auth.AuditTokenUse})
+	require.Equal(t, auth.ErrInternal, auth.ErrorCode(err))
+}
@@ -0,0 +1,330 @@
+// Package cache implements caching decorators of repositories.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	auth "github.com/kl09/auth-go"
+)
+
+const (
+	defaultSize        = 10000
+	defaultTTL         = 30 * time.Second
+	defaultNegativeTTL = 5 * time.Second
+
+	// credentialTokenCache is a name of the cache of credentials by token in metrics.
+	credentialTokenCache = "credential_token"
+)
+
+// Metrics collects hits and misses of caches.
+type Metrics interface {
+	// ObserveCacheLookup records a lookup in the cache, a cached not found is a hit too.
+	ObserveCacheLookup(cache string, hit bool)
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveCacheLookup(string, bool) {}
+
+// CredentialRepository caches lookups of credentials by token in a bounded LRU,
+// unknown tokens are cached too for a shorter time. Concurrent misses of a token share a single lookup.
+// Other methods are passed to the wrapped repository, writes evict the cached entries of the credential,
+// so all writes of the process must go through the CredentialRepository. Changes made by other processes
+// are evicted with Evict or Flush, otherwise they are seen once the cached entries expire.
+type CredentialRepository struct {
+	auth.CredentialRepository
+
+	nowFn       func() time.Time
+	size        int
+	ttl         time.Duration
+	negativeTTL time.Duration
+	metrics     Metrics
+
+	group singleflight.Group
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// byID keeps the keys of the cached tokens of a credential, the token changes on revoke,
+	// so updates find the entries of the old ones by id.
+	byID map[credentialKey]map[string]struct{}
+	lru  *list.List
+	// generation changes on every write, lookups started before it don't store their results.
+	generation uint64
+}
+
+type credentialKey struct {
+	tenantID string
+	id       int
+}
+
+type entry struct {
+	key       string
+	tenantID  string
+	cred      auth.Credential
+	found     bool
+	expiresAt time.Time
+}
+
+// ConfigOption configures the CredentialRepository.
+type ConfigOption func(r *CredentialRepository)
+
+// WithSize configures the max number of cached tokens, the default is used if n isn't positive.
+func WithSize(n int) ConfigOption {
+	return func(r *CredentialRepository) {
+		if n > 0 {
+			r.size = n
+		}
+	}
+}
+
+// WithTTL configures how long credentials are cached, the default is used if d isn't positive.
+func WithTTL(d time.Duration) ConfigOption {
+	return func(r *CredentialRepository) {
+		if d > 0 {
+			r.ttl = d
+		}
+	}
+}
+
+// WithNegativeTTL configures how long unknown tokens are cached, the default is used if d isn't positive.
+func WithNegativeTTL(d time.Duration) ConfigOption {
+	return func(r *CredentialRepository) {
+		if d > 0 {
+			r.negativeTTL = d
+		}
+	}
+}
+
+// WithMetrics configures metrics of hits and misses.
+func WithMetrics(m Metrics) ConfigOption {
+	return func(r *CredentialRepository) {
+		r.metrics = m
+	}
+}
+
+// NewCredentialRepository creates a new CredentialRepository caching lookups of next.
+func NewCredentialRepository(
+	next auth.CredentialRepository,
+	nowFn func() time.Time,
+	options ...ConfigOption,
+) *CredentialRepository {
+	r := &CredentialRepository{
+		CredentialRepository: next,
+		nowFn:                nowFn,
+		size:                 defaultSize,
+		ttl:                  defaultTTL,
+		negativeTTL:          defaultNegativeTTL,
+		metrics:              noopMetrics{},
+		entries:              make(map[string]*list.Element),
+		byID:                 make(map[credentialKey]map[string]struct{}),
+		lru:                  list.New(),
+	}
+
+	for _, opt := range options {
+		opt(r)
+	}
+
+	return r
+}
+
+// ByToken returns a Credential by token from the cache or the wrapped repository.
+func (r *CredentialRepository) ByToken(ctx context.Context, token string) (auth.Credential, error) {
+	if err := ctx.Err(); err != nil {
+		return auth.Credential{}, contextError(err)
+	}
+
+	tenantID := auth.TenantFromContext(ctx).ID
+	key := tokenKey(tenantID, token)
+
+	if e, ok := r.get(key); ok {
+		r.metrics.ObserveCacheLookup(credentialTokenCache, true)
+
+		if !e.found {
+			return auth.Credential{}, auth.NewError(auth.ErrCredNotFound, "Credential not found")
+		}
+
+		return e.cred, nil
+	}
+
+	r.metrics.ObserveCacheLookup(credentialTokenCache, false)
+
+	// The shared lookup must not fail for all callers when the one that started it goes away,
+	// every caller waits for it as long as its own context allows.
+	ch := r.group.DoChan(key, func() (any, error) {
+		return r.load(context.WithoutCancel(ctx), tenantID, key, token)
+	})
+
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return auth.Credential{}, res.Err
+		}
+
+		return res.Val.(auth.Credential), nil
+	case <-ctx.Done():
+		return auth.Credential{}, contextError(ctx.Err())
+	}
+}
+
+// Create creates a new Credential evicting its token cached as unknown.
+func (r *CredentialRepository) Create(ctx context.Context, cred *auth.Credential) error {
+	err := r.CredentialRepository.Create(ctx, cred)
+	r.evict(auth.TenantFromContext(ctx).ID, cred.ID, cred.Token)
+
+	return err
+}
+
+// Update updates a Credential by id evicting its old and new tokens.
+func (r *CredentialRepository) Update(ctx context.Context, cred *auth.Credential) error {
+	err := r.CredentialRepository.Update(ctx, cred)
+	r.evict(auth.TenantFromContext(ctx).ID, cred.ID, cred.Token)
+
+	return err
+}
+
+// Delete deletes a Credential by id evicting its token.
+func (r *CredentialRepository) Delete(ctx context.Context, id int) error {
+	err := r.CredentialRepository.Delete(ctx, id)
+	r.evict(auth.TenantFromContext(ctx).ID, id, "")
+
+	return err
+}
+
+// load looks the token up in the wrapped repository and caches the result unless a write happened meanwhile.
+func (r *CredentialRepository) load(ctx context.Context, tenantID, key, token string) (auth.Credential, error) {
+	r.mu.Lock()
+	generation := r.generation
+	r.mu.Unlock()
+
+	cred, err := r.CredentialRepository.ByToken(ctx, token)
+
+	switch {
+	case err == nil:
+		r.set(generation, &entry{key: key, tenantID: tenantID, cred: cred, found: true, expiresAt: r.nowFn().Add(r.ttl)})
+	case auth.ErrorHas(err, auth.ErrCredNotFound) != nil:
+		r.set(generation, &entry{key: key, tenantID: tenantID, expiresAt: r.nowFn().Add(r.negativeTTL)})
+	}
+
+	return cred, err
+}
+
+// get returns an unexpired entry by key.
+func (r *CredentialRepository) get(key string) (entry, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	el, ok := r.entries[key]
+	if !ok {
+		return entry{}, false
+	}
+
+	e := el.Value.(*entry)
+	if !r.nowFn().Before(e.expiresAt) {
+		r.remove(el)
+		return entry{}, false
+	}
+
+	r.lru.MoveToFront(el)
+
+	return *e, true
+}
+
+// set caches the entry if there were no writes since the generation, the least recently used entries
+// are removed over the size.
+func (r *CredentialRepository) set(generation uint64, e *entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if generation != r.generation {
+		return
+	}
+
+	if el, ok := r.entries[e.key]; ok {
+		r.remove(el)
+	}
+
+	r.entries[e.key] = r.lru.PushFront(e)
+
+	if e.found {
+		id := credentialKey{tenantID: e.tenantID, id: e.cred.ID}
+		if r.byID[id] == nil {
+			r.byID[id] = make(map[string]struct{})
+		}
+
+		r.byID[id][e.key] = struct{}{}
+	}
+
+	for r.lru.Len() > r.size {
+		r.remove(r.lru.Back())
+	}
+}
+
+// Evict removes the cached credential of the tenant by id, it is called for changes made by other processes.
+func (r *CredentialRepository) Evict(tenantID string, id int) {
+	r.evict(tenantID, id, "")
+}
+
+// Flush removes all cached entries.
+func (r *CredentialRepository) Flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.generation++
+	r.entries = make(map[string]*list.Element)
+	r.byID = make(map[credentialKey]map[string]struct{})
+	r.lru.Init()
+}
+
+// evict removes the entries of the credential and the token of the tenant.
+func (r *CredentialRepository) evict(tenantID string, id int, token string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.generation++
+
+	for key := range r.byID[credentialKey{tenantID: tenantID, id: id}] {
+		r.remove(r.entries[key])
+	}
+
+	if el, ok := r.entries[tokenKey(tenantID, token)]; ok && token != "" {
+		r.remove(el)
+	}
+}
+
+// remove removes the entry of the list, the lock must be held.
+func (r *CredentialRepository) remove(el *list.Element) {
+	e := el.Value.(*entry)
+
+	r.lru.Remove(el)
+	delete(r.entries, e.key)
+
+	if e.found {
+		id := credentialKey{tenantID: e.tenantID, id: e.cred.ID}
+
+		delete(r.byID[id], e.key)
+
+		if len(r.byID[id]) == 0 {
+			delete(r.byID, id)
+		}
+	}
+}
+
+// tokenKey returns a key of the token, tokens are unique across tenants, but lookups are scoped by one.
+func tokenKey(tenantID, token string) string {
+	return tenantID + "\x00" + token
+}
+
+// contextError converts context errors like repositories do.
+func contextError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return auth.WrapError(err, auth.ErrTimeout, "Query timed out")
+	}
+
+	return err
+}
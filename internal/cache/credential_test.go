@@ -0,0 +1,364 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/cache"
+	"github.com/kl09/auth-go/internal/memory"
+	"github.com/kl09/auth-go/internal/mock"
+	"github.com/kl09/auth-go/internal/repotest"
+)
+
+var now = time.Date(2020, time.April, 15, 10, 11, 12, 0, time.UTC)
+
+type metricsMock struct {
+	mu     sync.Mutex
+	hits   int
+	misses int
+}
+
+func (m *metricsMock) ObserveCacheLookup(cache string, hit bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if hit {
+		m.hits++
+	} else {
+		m.misses++
+	}
+}
+
+// clock is a time source moved by tests.
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *clock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+// tokenRepository returns a mock knowing credentials by tokens, the id of a credential is its index.
+func tokenRepository(tokens ...string) *mock.CredentialRepositoryMock {
+	return &mock.CredentialRepositoryMock{
+		ByTokenFunc: func(ctx context.Context, token string) (auth.Credential, error) {
+			for i, t := range tokens {
+				if t == token {
+					return auth.Credential{ID: i + 1, Token: token}, nil
+				}
+			}
+
+			return auth.Credential{}, auth.NewError(auth.ErrCredNotFound, "Credential not found")
+		},
+		CreateFunc: func(ctx context.Context, c *auth.Credential) error {
+			return nil
+		},
+		UpdateFunc: func(ctx context.Context, c *auth.Credential) error {
+			return nil
+		},
+		DeleteFunc: func(ctx context.Context, id int) error {
+			return nil
+		},
+	}
+}
+
+func TestCredentialRepository_Conformance(t *testing.T) {
+	repotest.TestCredentialRepository(t, func(t *testing.T) auth.CredentialRepository {
+		return cache.NewCredentialRepository(memory.NewCredentialRepository(), time.Now)
+	})
+}
+
+func TestCredentialRepository_ByToken(t *testing.T) {
+	ctx := context.Background()
+	next := tokenRepository("token1")
+	m := &metricsMock{}
+	r := cache.NewCredentialRepository(next, func() time.Time { return now }, cache.WithMetrics(m))
+
+	for i := 0; i < 3; i++ {
+		cred, err := r.ByToken(ctx, "token1")
+		require.Nil(t, err)
+		assert.Equal(t, auth.Credential{ID: 1, Token: "token1"}, cred)
+
+		_, err = r.ByToken(ctx, "unknown")
+		require.Equal(t, auth.ErrCredNotFound, auth.ErrorCode(err))
+	}
+
+	assert.Len(t, next.ByTokenCalls(), 2)
+	assert.Equal(t, 4, m.hits)
+	assert.Equal(t, 2, m.misses)
+
+	// Tokens are scoped by tenants.
+	_, err := r.ByToken(auth.ContextWithTenant(ctx, auth.Tenant{ID: "other"}), "token1")
+	require.Nil(t, err)
+	assert.Len(t, next.ByTokenCalls(), 3)
+}
+
+func TestCredentialRepository_ByToken_TTL(t *testing.T) {
+	ctx := context.Background()
+	next := tokenRepository("token1")
+	c := &clock{now: now}
+	r := cache.NewCredentialRepository(next, c.Now, cache.WithTTL(time.Minute), cache.WithNegativeTTL(time.Second))
+
+	lookup := func() {
+		_, _ = r.ByToken(ctx, "token1")
+		_, _ = r.ByToken(ctx, "unknown")
+	}
+
+	lookup()
+	require.Len(t, next.ByTokenCalls(), 2)
+
+	c.Add(time.Second)
+	lookup()
+	require.Len(t, next.ByTokenCalls(), 3, "the unknown token expires")
+
+	c.Add(time.Minute)
+	lookup()
+	require.Len(t, next.ByTokenCalls(), 5, "both tokens expire")
+}
+
+func TestCredentialRepository_ByToken_LRU(t *testing.T) {
+	ctx := context.Background()
+	next := tokenRepository("token1", "token2", "token3")
+	r := cache.NewCredentialRepository(next, func() time.Time { return now }, cache.WithSize(2))
+
+	for _, token := range []string{"token1", "token2", "token1", "token3", "token1", "token2"} {
+		_, err := r.ByToken(ctx, token)
+		require.Nil(t, err)
+	}
+
+	var calls []string
+	for _, c := range next.ByTokenCalls() {
+		calls = append(calls, c.Token)
+	}
+
+	// token2 is the least recently used one when token3 is added.
+	assert.Equal(t, []string{"token1", "token2", "token3", "token2"}, calls)
+}
+
+func TestCredentialRepository_ByToken_Errors(t *testing.T) {
+	ctx := context.Background()
+	next := &mock.CredentialRepositoryMock{
+		ByTokenFunc: func(ctx context.Context, token string) (auth.Credential, error) {
+			return auth.Credential{}, errors.New("connection refused")
+		},
+	}
+	r := cache.NewCredentialRepository(next, func() time.Time { return now })
+
+	for i := 0; i < 2; i++ {
+		_, err := r.ByToken(ctx, "token1")
+		require.EqualError(t, err, "connection refused")
+	}
+
+	assert.Len(t, next.ByTokenCalls(), 2, "errors aren't cached")
+
+	expired, cancel := context.WithTimeout(ctx, -time.Second)
+	defer cancel()
+
+	_, err := r.ByToken(expired, "token1")
+	require.Equal(t, auth.ErrTimeout, auth.ErrorCode(err))
+	assert.Len(t, next.ByTokenCalls(), 2)
+}
+
+func TestCredentialRepository_Evict(t *testing.T) {
+	cases := []struct {
+		name  string
+		write func(ctx context.Context, r auth.CredentialRepository) error
+		token string
+	}{
+		{
+			name: "revoke",
+			write: func(ctx context.Context, r auth.CredentialRepository) error {
+				return r.Update(ctx, &auth.Credential{ID: 1, Token: "new"})
+			},
+			token: "token1",
+		},
+		{
+			name: "update",
+			write: func(ctx context.Context, r auth.CredentialRepository) error {
+				return r.Update(ctx, &auth.Credential{ID: 1, Token: "token1", Password: "new"})
+			},
+			token: "token1",
+		},
+		{
+			name: "delete",
+			write: func(ctx context.Context, r auth.CredentialRepository) error {
+				return r.Delete(ctx, 1)
+			},
+			token: "token1",
+		},
+		{
+			name: "create of an unknown token",
+			write: func(ctx context.Context, r auth.CredentialRepository) error {
+				return r.Create(ctx, &auth.Credential{Token: "unknown"})
+			},
+			token: "unknown",
+		},
+		{
+			name: "update to an unknown token",
+			write: func(ctx context.Context, r auth.CredentialRepository) error {
+				return r.Update(ctx, &auth.Credential{ID: 2, Token: "unknown"})
+			},
+			token: "unknown",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			next := tokenRepository("token1")
+			r := cache.NewCredentialRepository(next, func() time.Time { return now })
+
+			_, _ = r.ByToken(ctx, tc.token)
+			require.Nil(t, tc.write(ctx, r))
+			_, _ = r.ByToken(ctx, tc.token)
+
+			assert.Len(t, next.ByTokenCalls(), 2)
+		})
+	}
+}
+
+func TestCredentialRepository_EvictFlush(t *testing.T) {
+	ctx := context.Background()
+	next := tokenRepository("token1", "token2")
+	r := cache.NewCredentialRepository(next, func() time.Time { return now })
+
+	lookup := func() {
+		for _, token := range []string{"token1", "token2"} {
+			_, err := r.ByToken(ctx, token)
+			require.Nil(t, err)
+		}
+	}
+
+	lookup()
+	require.Len(t, next.ByTokenCalls(), 2)
+
+	r.Evict("other", 1)
+	lookup()
+	require.Len(t, next.ByTokenCalls(), 2, "credentials of other tenants aren't evicted")
+
+	r.Evict(auth.DefaultTenantID, 1)
+	lookup()
+	require.Len(t, next.ByTokenCalls(), 3)
+
+	r.Flush()
+	lookup()
+	require.Len(t, next.ByTokenCalls(), 5)
+}
+
+func TestCredentialRepository_Evict_RevokedByOtherProcess(t *testing.T) {
+	ctx := context.Background()
+
+	// The token was revoked by another process, both the old and the new one are cached until the eviction.
+	next := &mock.CredentialRepositoryMock{
+		ByTokenFunc: func(ctx context.Context, token string) (auth.Credential, error) {
+			return auth.Credential{ID: 1, Token: token}, nil
+		},
+	}
+	r := cache.NewCredentialRepository(next, func() time.Time { return now })
+
+	lookup := func() {
+		for _, token := range []string{"old", "new"} {
+			_, err := r.ByToken(ctx, token)
+			require.Nil(t, err)
+		}
+	}
+
+	lookup()
+	lookup()
+	require.Len(t, next.ByTokenCalls(), 2)
+
+	r.Evict(auth.DefaultTenantID, 1)
+	lookup()
+	require.Len(t, next.ByTokenCalls(), 4, "both tokens are evicted")
+}
+
+func TestCredentialRepository_ByToken_Singleflight(t *testing.T) {
+	ctx := context.Background()
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	var once sync.Once
+
+	next := &mock.CredentialRepositoryMock{
+		ByTokenFunc: func(ctx context.Context, token string) (auth.Credential, error) {
+			once.Do(func() { close(started) })
+			<-release
+
+			return auth.Credential{ID: 1, Token: token}, nil
+		},
+	}
+	r := cache.NewCredentialRepository(next, func() time.Time { return now })
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			cred, err := r.ByToken(ctx, "token1")
+			assert.Nil(t, err)
+			assert.Equal(t, 1, cred.ID)
+		}()
+	}
+
+	<-started
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Len(t, next.ByTokenCalls(), 1)
+}
+
+func TestCredentialRepository_ByToken_WriteDuringLookup(t *testing.T) {
+	ctx := context.Background()
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+
+	next := tokenRepository("token1")
+	byToken := next.ByTokenFunc
+	next.ByTokenFunc = func(ctx context.Context, token string) (auth.Credential, error) {
+		started <- struct{}{}
+		<-release
+
+		return byToken(ctx, token)
+	}
+	r := cache.NewCredentialRepository(next, func() time.Time { return now })
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		_, err := r.ByToken(ctx, "token1")
+		assert.Nil(t, err)
+	}()
+
+	<-started
+	require.Nil(t, r.Update(ctx, &auth.Credential{ID: 1, Token: "new"}))
+	close(release)
+	<-done
+
+	// The credential read before the revoke isn't cached.
+	_, _ = r.ByToken(ctx, "token1")
+	assert.Len(t, next.ByTokenCalls(), 2)
+}
@@ -0,0 +1,116 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	statusOK       = "ok"
+	statusFailed   = "failed"
+	statusDraining = "draining"
+)
+
+// Check is a named check of a dependency.
+type Check struct {
+	Name string
+	Fn   func(ctx context.Context) error
+}
+
+// Health serves liveness and readiness probes.
+type Health struct {
+	readiness *Readiness
+	timeout   time.Duration
+	checks    []Check
+}
+
+// New creates a new Health running checks with the timeout.
+func New(readiness *Readiness, timeout time.Duration, checks ...Check) *Health {
+	return &Health{
+		readiness: readiness,
+		timeout:   timeout,
+		checks:    checks,
+	}
+}
+
+type checkResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type report struct {
+	Status string                 `json:"status"`
+	Checks map[string]checkResult `json:"checks,omitempty"`
+}
+
+// Liveness reports that the process is alive.
+func (h *Health) Liveness(w http.ResponseWriter, r *http.Request) {
+	writeReport(w, http.StatusOK, report{Status: statusOK})
+}
+
+// Readiness reports whether the app and its dependencies are able to serve traffic.
+func (h *Health) Readiness(w http.ResponseWriter, r *http.Request) {
+	rep := report{
+		Status: statusOK,
+		Checks: h.runChecks(r.Context()),
+	}
+
+	for _, res := range rep.Checks {
+		if res.Status != statusOK {
+			rep.Status = statusFailed
+		}
+	}
+
+	if h.readiness.Draining() {
+		rep.Status = statusDraining
+	}
+
+	status := http.StatusOK
+	if rep.Status != statusOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	writeReport(w, status, rep)
+}
+
+// runChecks runs all checks concurrently.
+func (h *Health) runChecks(ctx context.Context) map[string]checkResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]checkResult, len(h.checks))
+	)
+
+	for _, c := range h.checks {
+		wg.Add(1)
+
+		go func(c Check) {
+			defer wg.Done()
+
+			res := checkResult{Status: statusOK}
+			if err := c.Fn(ctx); err != nil {
+				res = checkResult{Status: statusFailed, Error: err.Error()}
+			}
+
+			mu.Lock()
+			results[c.Name] = res
+			mu.Unlock()
+		}(c)
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+func writeReport(w http.ResponseWriter, status int, rep report) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(rep)
+}
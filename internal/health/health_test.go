@@ -0,0 +1,107 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/kl09/auth-go/internal/health"
+)
+
+func TestHealth_Liveness(t *testing.T) {
+	h := health.New(health.NewReadiness(), time.Second)
+
+	rec := httptest.NewRecorder()
+	h.Liveness(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if diff := cmp.Diff(http.StatusOK, rec.Code); diff != "" {
+		t.Error(diff)
+	}
+
+	if diff := cmp.Diff(`{"status":"ok"}`+"\n", rec.Body.String()); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestHealth_Readiness(t *testing.T) {
+	okCheck := health.Check{
+		Name: "db",
+		Fn: func(ctx context.Context) error {
+			return nil
+		},
+	}
+	failedCheck := health.Check{
+		Name: "db",
+		Fn: func(ctx context.Context) error {
+			return errors.New("connection refused")
+		},
+	}
+	slowCheck := health.Check{
+		Name: "db",
+		Fn: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+
+	cases := []struct {
+		name       string
+		draining   bool
+		checks     []health.Check
+		wantResp   string
+		wantStatus int
+	}{
+		{
+			name:       "success",
+			checks:     []health.Check{okCheck},
+			wantResp:   `{"status":"ok","checks":{"db":{"status":"ok"}}}` + "\n",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "error - check failed",
+			checks:     []health.Check{failedCheck},
+			wantResp:   `{"status":"failed","checks":{"db":{"status":"failed","error":"connection refused"}}}` + "\n",
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "error - check timed out",
+			checks:     []health.Check{slowCheck},
+			wantResp:   `{"status":"failed","checks":{"db":{"status":"failed","error":"context deadline exceeded"}}}` + "\n",
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "error - draining",
+			draining:   true,
+			checks:     []health.Check{okCheck},
+			wantResp:   `{"status":"draining","checks":{"db":{"status":"ok"}}}` + "\n",
+			wantStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := health.NewReadiness()
+			if tc.draining {
+				r.Drain()
+			}
+
+			h := health.New(r, 10*time.Millisecond, tc.checks...)
+
+			rec := httptest.NewRecorder()
+			h.Readiness(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if diff := cmp.Diff(tc.wantStatus, rec.Code); diff != "" {
+				t.Error(diff)
+			}
+
+			if diff := cmp.Diff(tc.wantResp, rec.Body.String()); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
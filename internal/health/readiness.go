@@ -0,0 +1,23 @@
+package health
+
+import "sync/atomic"
+
+// Readiness reports whether the app accepts new traffic.
+type Readiness struct {
+	draining atomic.Bool
+}
+
+// NewReadiness creates a new Readiness.
+func NewReadiness() *Readiness {
+	return &Readiness{}
+}
+
+// Drain marks the app as going away, so it stops receiving new traffic.
+func (r *Readiness) Drain() {
+	r.draining.Store(true)
+}
+
+// Draining returns true after Drain was called.
+func (r *Readiness) Draining() bool {
+	return r.draining.Load()
+}
@@ -0,0 +1,17 @@
+package health_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kl09/auth-go/internal/health"
+)
+
+func TestReadiness_Drain(t *testing.T) {
+	r := health.NewReadiness()
+	require.False(t, r.Draining())
+
+	r.Drain()
+	require.True(t, r.Draining())
+}
@@ -0,0 +1,22 @@
+// Package httputil implements helpers of HTTP middlewares.
+package httputil
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RouteUnmatched is the route of requests not matched by any route.
+const RouteUnmatched = "unmatched"
+
+// Route returns the route of the request, e.g. to name its metrics, spans or log entries.
+// Unmatched requests keep the raw path, which may carry a token, so RouteUnmatched is returned for them.
+func Route(c echo.Context) string {
+	route := c.Path()
+	if route == "" || (c.Response().Status == http.StatusNotFound && route == c.Request().URL.Path) {
+		return RouteUnmatched
+	}
+
+	return route
+}
@@ -0,0 +1,49 @@
+package httputil_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kl09/auth-go/internal/httputil"
+)
+
+func TestRoute(t *testing.T) {
+	cases := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "matched", path: "/v1/users-by-token/secret", want: "/v1/users-by-token/:token"},
+		{name: "unmatched", path: "/v1/unknown/secret", want: httputil.RouteUnmatched},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			e.GET("/v1/users-by-token/:token", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+
+			var route string
+			e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					if err := next(c); err != nil {
+						c.Error(err)
+					}
+
+					route = httputil.Route(c)
+
+					return nil
+				}
+			})
+
+			e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			assert.Equal(t, tc.want, route)
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package logging
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/kl09/auth-go/internal/httputil"
+)
+
+const (
+	// HeaderRequestID is a header carrying the request ID.
+	HeaderRequestID = "X-Request-ID"
+
+	requestIDLength    = 32
+	maxRequestIDLength = 128
+)
+
+// FromContext returns the logger of the request or fallback if ctx has none.
+func FromContext(ctx context.Context, fallback *zerolog.Logger) *zerolog.Logger {
+	l := zerolog.Ctx(ctx)
+	if l.GetLevel() == zerolog.Disabled {
+		return fallback
+	}
+
+	return l
+}
+
+// SetCredentialID adds the credential ID to all further log entries of the request.
+func SetCredentialID(ctx context.Context, id int) {
+	l := zerolog.Ctx(ctx)
+	if l.GetLevel() == zerolog.Disabled {
+		return
+	}
+
+	l.UpdateContext(func(c zerolog.Context) zerolog.Context {
+		return c.Int("credential_id", id)
+	})
+}
+
+// SetTenantID adds the tenant ID to all further log entries of the request.
+func SetTenantID(ctx context.Context, id string) {
+	l := zerolog.Ctx(ctx)
+	if l.GetLevel() == zerolog.Disabled {
+		return
+	}
+
+	l.UpdateContext(func(c zerolog.Context) zerolog.Context {
+		return c.Str("tenant_id", id)
+	})
+}
+
+// Middleware returns echo middleware putting a request scoped logger into the request context
+// and writing an access log entry once the request is done.
+// The request ID is taken from the X-Request-ID header or generated.
+func Middleware(logger zerolog.Logger, generatorFn func(n int) (string, error)) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			started := time.Now()
+			req := c.Request()
+
+			requestID := req.Header.Get(HeaderRequestID)
+			if requestID == "" || len(requestID) > maxRequestIDLength {
+				var err error
+
+				requestID, err = generatorFn(requestIDLength)
+				if err != nil {
+					return err
+				}
+			}
+
+			c.Response().Header().Set(HeaderRequestID, requestID)
+
+			lc := logger.With().Str("request_id", requestID)
+			if sc := trace.SpanContextFromContext(req.Context()); sc.HasTraceID() {
+				lc = lc.Str("trace_id", sc.TraceID().String())
+			}
+
+			l := lc.Logger()
+			c.SetRequest(req.WithContext(l.WithContext(req.Context())))
+
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+
+			route := httputil.Route(c)
+
+			status := c.Response().Status
+
+			e := l.Info()
+			if status >= http.StatusInternalServerError {
+				e = l.Error()
+			}
+
+			e.Str("method", req.Method).
+				Str("route", route).
+				Int("status", status).
+				Dur("latency", time.Since(started)).
+				Str("remote_ip", c.RealIP()).
+				Msg("request")
+
+			return nil
+		}
+	}
+}
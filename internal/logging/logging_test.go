@@ -0,0 +1,158 @@
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kl09/auth-go/internal/logging"
+)
+
+func generator(n int) (string, error) {
+	return strings.Repeat("a", n), nil
+}
+
+func logEntries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var entries []map[string]interface{}
+
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		entry := make(map[string]interface{})
+		require.Nil(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+func TestMiddleware(t *testing.T) {
+	cases := []struct {
+		name          string
+		requestID     string
+		wantRequestID string
+	}{
+		{
+			name:          "request id from upstream",
+			requestID:     "upstream-id",
+			wantRequestID: "upstream-id",
+		},
+		{
+			name:          "generated request id",
+			wantRequestID: strings.Repeat("a", 32),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			e := echo.New()
+			e.Use(logging.Middleware(zerolog.New(buf), generator))
+			e.GET("/v1/users-by-token/:token", func(c echo.Context) error {
+				logging.SetCredentialID(c.Request().Context(), 7)
+				zerolog.Ctx(c.Request().Context()).Info().Msg("handler")
+
+				return c.NoContent(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/users-by-token/12345", nil)
+			if tc.requestID != "" {
+				req.Header.Set(logging.HeaderRequestID, tc.requestID)
+			}
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			require.Equal(t, tc.wantRequestID, rec.Header().Get(logging.HeaderRequestID))
+
+			entries := logEntries(t, buf)
+			require.Len(t, entries, 2)
+
+			require.Equal(t, "handler", entries[0]["message"])
+			require.Equal(t, tc.wantRequestID, entries[0]["request_id"])
+
+			access := entries[1]
+			require.Equal(t, "request", access["message"])
+			require.Equal(t, tc.wantRequestID, access["request_id"])
+			require.Equal(t, "GET", access["method"])
+			require.Equal(t, "/v1/users-by-token/:token", access["route"])
+			require.Equal(t, float64(http.StatusOK), access["status"])
+			require.Equal(t, float64(7), access["credential_id"])
+			require.Contains(t, access, "latency")
+		})
+	}
+}
+
+func TestMiddleware_Unmatched(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	e := echo.New()
+	e.Use(logging.Middleware(zerolog.New(buf), generator))
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/bad_url/secret", nil))
+
+	entries := logEntries(t, buf)
+	require.Len(t, entries, 1)
+	require.Equal(t, "unmatched", entries[0]["route"])
+	require.Equal(t, float64(http.StatusNotFound), entries[0]["status"])
+}
+
+func TestRedact(t *testing.T) {
+	token := strings.Repeat("Ab1-", 32)
+
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "sensitive fields",
+			in:   `{"email":"example@example.org","password":"12\"345","token":"abc","message":"ok"}`,
+			want: `{"email":"[REDACTED]","password":"[REDACTED]","token":"[REDACTED]","message":"ok"}`,
+		},
+		{
+			name: "email in message",
+			in:   `{"message":"user example.user+1@example.org not found"}`,
+			want: `{"message":"user [REDACTED] not found"}`,
+		},
+		{
+			name: "token in message",
+			in:   `{"message":"token ` + token + ` not found"}`,
+			want: `{"message":"token [REDACTED] not found"}`,
+		},
+		{
+			name: "api key in message",
+			in:   `{"message":"key ak_0123456789Ab_0123456789abcdefghij0123456789ABCDEFGHIJ is invalid"}`,
+			want: `{"message":"key [REDACTED] is invalid"}`,
+		},
+		{
+			name: "nothing to redact",
+			in:   `{"request_id":"aaaa","credential_id":1}`,
+			want: `{"request_id":"aaaa","credential_id":1}`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, string(logging.Redact([]byte(tc.in))))
+		})
+	}
+}
+
+func TestNewRedactWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := zerolog.New(logging.NewRedactWriter(buf))
+
+	l.Info().Str("email", "example@example.org").Msg("registered")
+
+	require.Equal(t, `{"level":"info","email":"[REDACTED]","message":"registered"}`+"\n", buf.String())
+}
@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"io"
+	"regexp"
+)
+
+const redacted = "[REDACTED]"
+
+var (
+	// sensitiveFieldRe matches JSON string fields which are never logged as is.
+	sensitiveFieldRe = regexp.MustCompile(`"(email|email_tmp|password|token|authorization|x_api_key|key|verification_code)":"(?:[^"\\]|\\.)*"`)
+	// emailRe matches emails anywhere in a log entry.
+	emailRe = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	// tokenRe matches long random strings like credential tokens.
+	tokenRe = regexp.MustCompile(`[0-9A-Za-z\-_]{64,}`)
+	// apiKeyRe matches API keys which are shorter than tokens.
+	apiKeyRe = regexp.MustCompile(`ak_[0-9A-Za-z\-]+_[0-9A-Za-z\-]+`)
+)
+
+type redactWriter struct {
+	w io.Writer
+}
+
+// NewRedactWriter returns a writer masking emails, tokens and passwords
+// in JSON log entries before writing them to w.
+func NewRedactWriter(w io.Writer) io.Writer {
+	return redactWriter{w: w}
+}
+
+// Write writes a redacted entry to the underlying writer.
+func (r redactWriter) Write(p []byte) (int, error) {
+	if _, err := r.w.Write(Redact(p)); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Redact masks emails, tokens and passwords in a JSON log entry.
+func Redact(p []byte) []byte {
+	p = sensitiveFieldRe.ReplaceAll(p, []byte(`"$1":"`+redacted+`"`))
+	p = emailRe.ReplaceAll(p, []byte(redacted))
+	p = apiKeyRe.ReplaceAll(p, []byte(redacted))
+
+	return tokenRe.ReplaceAll(p, []byte(redacted))
+}
@@ -0,0 +1,258 @@
+// Package memory implements repositories keeping data in memory, for development and tests.
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	auth "github.com/kl09/auth-go"
+)
+
+const defaultSearchLimit = 100
+
+// CredentialRepository is a thread-safe repository for credentials in memory.
+// It enforces the unique constraints of the Postgres one: the email and the pending email change within a tenant
+// and the token across tenants.
+type CredentialRepository struct {
+	mu     sync.RWMutex
+	creds  map[int]auth.Credential
+	lastID int
+}
+
+// NewCredentialRepository creates a new CredentialRepository.
+func NewCredentialRepository() *CredentialRepository {
+	return &CredentialRepository{
+		creds: make(map[int]auth.Credential),
+	}
+}
+
+// ByToken returns a Credential by token.
+func (r *CredentialRepository) ByToken(ctx context.Context, token string) (auth.Credential, error) {
+	return r.find(ctx, func(c auth.Credential) bool {
+		return c.Token == token
+	})
+}
+
+// ByID returns a Credential by id.
+func (r *CredentialRepository) ByID(ctx context.Context, id int) (auth.Credential, error) {
+	return r.find(ctx, func(c auth.Credential) bool {
+		return c.ID == id
+	})
+}
+
+// ByEmail returns a Credential by email.
+func (r *CredentialRepository) ByEmail(ctx context.Context, email string) (auth.Credential, error) {
+	return r.find(ctx, func(c auth.Credential) bool {
+		return c.Email == email
+	})
+}
+
+// Create creates a new Credential in the tenant of the context.
+func (r *CredentialRepository) Create(ctx context.Context, cred *auth.Credential) error {
+	if err := ctx.Err(); err != nil {
+		return queryError(err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := *cred
+	c.ID = 0
+	c.TenantID = tenantID(ctx)
+	c.Status = status(c.Status)
+
+	if err := r.checkUnique(c); err != nil {
+		return err
+	}
+
+	r.lastID++
+	c.ID = r.lastID
+	r.creds[c.ID] = c
+
+	cred.ID = c.ID
+	cred.TenantID = c.TenantID
+
+	return nil
+}
+
+// Search returns Credentials matching the filter ordered by id.
+func (r *CredentialRepository) Search(ctx context.Context, f auth.CredentialFilter) ([]auth.Credential, error) {
+	if f.Limit <= 0 {
+		f.Limit = defaultSearchLimit
+	}
+
+	email := strings.ToLower(f.Email)
+
+	return r.filter(ctx, f.Limit, f.Offset, func(c auth.Credential) bool {
+		return c.TenantID == tenantID(ctx) && (email == "" ||
+			strings.Contains(strings.ToLower(c.Email), email) || strings.Contains(strings.ToLower(c.EmailTmp), email))
+	}, func(a, b auth.Credential) bool {
+		return a.ID < b.ID
+	})
+}
+
+// PendingDeletion returns Credentials of all tenants pending deletion with StatusUntil before the time
+// ordered by StatusUntil.
+func (r *CredentialRepository) PendingDeletion(ctx context.Context, before time.Time, limit int) ([]auth.Credential, error) {
+	return r.filter(ctx, limit, 0, func(c auth.Credential) bool {
+		return c.Status == auth.StatusPendingDeletion && !c.StatusUntil.IsZero() && c.StatusUntil.Before(before)
+	}, func(a, b auth.Credential) bool {
+		return a.StatusUntil.Before(b.StatusUntil)
+	})
+}
+
+// Update updates a Credential by id.
+func (r *CredentialRepository) Update(ctx context.Context, cred *auth.Credential) error {
+	if err := ctx.Err(); err != nil {
+		return queryError(err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.creds[cred.ID]
+	if !ok || stored.TenantID != tenantID(ctx) {
+		return notFound()
+	}
+
+	c := *cred
+	c.TenantID = stored.TenantID
+	c.Status = status(c.Status)
+	// The creation time isn't updated like in Postgres.
+	c.CreatedAt = stored.CreatedAt
+
+	if err := r.checkUnique(c); err != nil {
+		return err
+	}
+
+	r.creds[c.ID] = c
+
+	return nil
+}
+
+// Delete deletes a Credential by id.
+func (r *CredentialRepository) Delete(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return queryError(err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.creds[id]
+	if !ok || stored.TenantID != tenantID(ctx) {
+		return notFound()
+	}
+
+	delete(r.creds, id)
+
+	return nil
+}
+
+// find returns the Credential of the tenant of the context matching fn.
+func (r *CredentialRepository) find(ctx context.Context, fn func(c auth.Credential) bool) (auth.Credential, error) {
+	if err := ctx.Err(); err != nil {
+		return auth.Credential{}, queryError(err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, c := range r.creds {
+		if c.TenantID == tenantID(ctx) && fn(c) {
+			return c, nil
+		}
+	}
+
+	return auth.Credential{}, notFound()
+}
+
+// filter returns Credentials matching fn ordered by less.
+func (r *CredentialRepository) filter(
+	ctx context.Context,
+	limit, offset int,
+	fn func(c auth.Credential) bool,
+	less func(a, b auth.Credential) bool,
+) ([]auth.Credential, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, queryError(err)
+	}
+
+	r.mu.RLock()
+
+	creds := make([]auth.Credential, 0)
+
+	for _, c := range r.creds {
+		if fn(c) {
+			creds = append(creds, c)
+		}
+	}
+
+	r.mu.RUnlock()
+
+	sort.Slice(creds, func(i, j int) bool {
+		return less(creds[i], creds[j])
+	})
+
+	if offset >= len(creds) {
+		return creds[:0], nil
+	}
+
+	creds = creds[offset:]
+	if len(creds) > limit {
+		creds = creds[:limit]
+	}
+
+	return creds, nil
+}
+
+// checkUnique checks the unique constraints against other Credentials, the lock must be held.
+func (r *CredentialRepository) checkUnique(c auth.Credential) error {
+	for _, other := range r.creds {
+		if other.ID == c.ID {
+			continue
+		}
+
+		if other.TenantID == c.TenantID &&
+			(other.Email == c.Email || (c.EmailTmp != "" && other.EmailTmp == c.EmailTmp)) {
+			return auth.NewError(auth.ErrEmailExists, "User with this email already exists.")
+		}
+
+		if other.Token == c.Token {
+			return auth.NewError(auth.ErrInternal, "Token already exists.")
+		}
+	}
+
+	return nil
+}
+
+func notFound() error {
+	return auth.NewError(auth.ErrCredNotFound, "Credential not found")
+}
+
+// tenantID returns the tenant of the context all methods are scoped by.
+func tenantID(ctx context.Context) string {
+	return auth.TenantFromContext(ctx).ID
+}
+
+// status defaults an empty status to active.
+func status(s string) string {
+	if s == "" {
+		return auth.StatusActive
+	}
+
+	return s
+}
+
+// queryError converts context errors like the Postgres repository does.
+func queryError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return auth.WrapError(err, auth.ErrTimeout, "Query timed out")
+	}
+
+	return err
+}
@@ -0,0 +1,15 @@
+package memory_test
+
+import (
+	"testing"
+
+	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/memory"
+	"github.com/kl09/auth-go/internal/repotest"
+)
+
+func TestCredentialRepository(t *testing.T) {
+	repotest.TestCredentialRepository(t, func(t *testing.T) auth.CredentialRepository {
+		return memory.NewCredentialRepository()
+	})
+}
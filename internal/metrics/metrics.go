@@ -0,0 +1,184 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/httputil"
+)
+
+const (
+	namespace = "auth"
+
+	resultSuccess = "success"
+	resultHit     = "hit"
+	resultMiss    = "miss"
+)
+
+// Metrics collects Prometheus metrics of the app.
+type Metrics struct {
+	registry     *prometheus.Registry
+	httpDuration *prometheus.HistogramVec
+	outcomes     *prometheus.CounterVec
+	hashDuration prometheus.Histogram
+	cacheLookups *prometheus.CounterVec
+}
+
+// New creates Metrics with Go runtime and process collectors registered.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of HTTP requests by route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		outcomes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "credential",
+			Name:      "operations_total",
+			Help:      "Number of credential operations by result, which is success or an error code.",
+		}, []string{"operation", "result"}),
+		hashDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "password",
+			Name:      "hash_duration_seconds",
+			Help:      "Duration of password hashing and comparison.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 10),
+		}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "cache",
+			Name:      "lookups_total",
+			Help:      "Number of cache lookups by cache and result, which is hit or miss.",
+		}, []string{"cache", "result"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpDuration,
+		m.outcomes,
+		m.hashDuration,
+		m.cacheLookups,
+	)
+
+	return m
+}
+
+// Handler returns a handler exposing the metrics.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware returns echo middleware measuring the duration of requests.
+func (m *Metrics) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			started := time.Now()
+
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+
+			route := httputil.Route(c)
+
+			m.httpDuration.WithLabelValues(
+				c.Request().Method,
+				route,
+				strconv.Itoa(c.Response().Status),
+			).Observe(time.Since(started).Seconds())
+
+			return nil
+		}
+	}
+}
+
+// ObserveHashing records the duration of a password hashing.
+func (m *Metrics) ObserveHashing(d time.Duration) {
+	m.hashDuration.Observe(d.Seconds())
+}
+
+// ObserveOutcome records the result of an operation labeled by auth error code.
+func (m *Metrics) ObserveOutcome(operation string, err error) {
+	result := resultSuccess
+	if err != nil {
+		result = auth.ErrorCode(err)
+	}
+
+	m.outcomes.WithLabelValues(operation, result).Inc()
+}
+
+// ObserveCacheLookup records a hit or a miss of the cache.
+func (m *Metrics) ObserveCacheLookup(cache string, hit bool) {
+	result := resultMiss
+	if hit {
+		result = resultHit
+	}
+
+	m.cacheLookups.WithLabelValues(cache, result).Inc()
+}
+
+// RegisterPool registers gauges and counters of the Postgres connection pool.
+func (m *Metrics) RegisterPool(stat func() *pgxpool.Stat) {
+	gauge := func(name, help string, fn func(s *pgxpool.Stat) float64) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "db_pool",
+			Name:      name,
+			Help:      help,
+		}, func() float64 {
+			return fn(stat())
+		})
+	}
+	counter := func(name, help string, fn func(s *pgxpool.Stat) float64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "db_pool",
+			Name:      name,
+			Help:      help,
+		}, func() float64 {
+			return fn(stat())
+		})
+	}
+
+	m.registry.MustRegister(
+		gauge("max_conns", "Max size of the pool.", func(s *pgxpool.Stat) float64 {
+			return float64(s.MaxConns())
+		}),
+		gauge("total_conns", "Number of open connections.", func(s *pgxpool.Stat) float64 {
+			return float64(s.TotalConns())
+		}),
+		gauge("idle_conns", "Number of idle connections.", func(s *pgxpool.Stat) float64 {
+			return float64(s.IdleConns())
+		}),
+		gauge("acquired_conns", "Number of connections in use.", func(s *pgxpool.Stat) float64 {
+			return float64(s.AcquiredConns())
+		}),
+		counter("acquires_total", "Number of successful acquires from the pool.", func(s *pgxpool.Stat) float64 {
+			return float64(s.AcquireCount())
+		}),
+		counter("empty_acquires_total", "Number of acquires waited for a free connection.", func(s *pgxpool.Stat) float64 {
+			return float64(s.EmptyAcquireCount())
+		}),
+		counter("canceled_acquires_total", "Number of acquires canceled by a context.", func(s *pgxpool.Stat) float64 {
+			return float64(s.CanceledAcquireCount())
+		}),
+		counter("acquire_wait_seconds_total", "Total time spent acquiring connections.", func(s *pgxpool.Stat) float64 {
+			return s.AcquireDuration().Seconds()
+		}),
+		counter("new_conns_total", "Number of connections opened.", func(s *pgxpool.Stat) float64 {
+			return float64(s.NewConnsCount())
+		}),
+	)
+}
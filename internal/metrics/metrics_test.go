@@ -0,0 +1,76 @@
+package metrics_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/metrics"
+)
+
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	b, err := ioutil.ReadAll(rec.Body)
+	require.Nil(t, err)
+
+	return string(b)
+}
+
+func TestMetrics_Middleware(t *testing.T) {
+	m := metrics.New()
+
+	e := echo.New()
+	e.Use(m.Middleware())
+	e.GET("/v1/users-by-token/:token", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	for _, path := range []string{"/v1/users-by-token/1", "/v1/users-by-token/2", "/bad_url"} {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	out := scrape(t, m)
+	require.Contains(t, out, `auth_http_request_duration_seconds_count{method="GET",route="/v1/users-by-token/:token",status="200"} 2`)
+	require.Contains(t, out, `auth_http_request_duration_seconds_count{method="GET",route="unmatched",status="404"} 1`)
+}
+
+func TestMetrics_ObserveOutcome(t *testing.T) {
+	m := metrics.New()
+
+	m.ObserveOutcome("auth", nil)
+	m.ObserveOutcome("auth", auth.NewError(auth.ErrAuth, "Auth failed"))
+	m.ObserveOutcome("auth", auth.NewError(auth.ErrAuth, "Auth failed"))
+	m.ObserveOutcome("register", errors.New("some error"))
+	m.ObserveHashing(50 * time.Millisecond)
+
+	out := scrape(t, m)
+	require.Contains(t, out, `auth_credential_operations_total{operation="auth",result="success"} 1`)
+	require.Contains(t, out, `auth_credential_operations_total{operation="auth",result="auth_failed"} 2`)
+	require.Contains(t, out, `auth_credential_operations_total{operation="register",result="internal"} 1`)
+	require.True(t, strings.Contains(out, "auth_password_hash_duration_seconds_count 1"))
+}
+
+func TestMetrics_ObserveCacheLookup(t *testing.T) {
+	m := metrics.New()
+
+	m.ObserveCacheLookup("credential_token", true)
+	m.ObserveCacheLookup("credential_token", true)
+	m.ObserveCacheLookup("credential_token", false)
+
+	out := scrape(t, m)
+	require.Contains(t, out, `auth_cache_lookups_total{cache="credential_token",result="hit"} 2`)
+	require.Contains(t, out, `auth_cache_lookups_total{cache="credential_token",result="miss"} 1`)
+}
@@ -0,0 +1,295 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mock
+
+import (
+	"context"
+	"github.com/kl09/auth-go"
+	"sync"
+	"time"
+)
+
+// Ensure, that APIKeyRepositoryMock does implement auth.APIKeyRepository.
+// If this is not the case, regenerate this file with moq.
+var _ auth.APIKeyRepository = &APIKeyRepositoryMock{}
+
+// APIKeyRepositoryMock is a mock implementation of auth.APIKeyRepository.
+//
+//	func TestSomethingThatUsesAPIKeyRepository(t *testing.T) {
+//
+//		// make and configure a mocked auth.APIKeyRepository
+//		mockedAPIKeyRepository := &APIKeyRepositoryMock{
+//			ByCredentialFunc: func(ctx context.Context, credID int) ([]auth.APIKey, error) {
+//				panic("mock out the ByCredential method")
+//			},
+//			ByPrefixFunc: func(ctx context.Context, prefix string) (auth.APIKey, error) {
+//				panic("mock out the ByPrefix method")
+//			},
+//			CreateFunc: func(ctx context.Context, k *auth.APIKey) error {
+//				panic("mock out the Create method")
+//			},
+//			DeleteFunc: func(ctx context.Context, credID int, id int) error {
+//				panic("mock out the Delete method")
+//			},
+//			TouchFunc: func(ctx context.Context, id int, at time.Time) error {
+//				panic("mock out the Touch method")
+//			},
+//		}
+//
+//		// use mockedAPIKeyRepository in code that requires auth.APIKeyRepository
+//		// and then make assertions.
+//
+//	}
+type APIKeyRepositoryMock struct {
+	// ByCredentialFunc mocks the ByCredential method.
+	ByCredentialFunc func(ctx context.Context, credID int) ([]auth.APIKey, error)
+
+	// ByPrefixFunc mocks the ByPrefix method.
+	ByPrefixFunc func(ctx context.Context, prefix string) (auth.APIKey, error)
+
+	// CreateFunc mocks the Create method.
+	CreateFunc func(ctx context.Context, k *auth.APIKey) error
+
+	// DeleteFunc mocks the Delete method.
+	DeleteFunc func(ctx context.Context, credID int, id int) error
+
+	// TouchFunc mocks the Touch method.
+	TouchFunc func(ctx context.Context, id int, at time.Time) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// ByCredential holds details about calls to the ByCredential method.
+		ByCredential []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// CredID is the credID argument value.
+			CredID int
+		}
+		// ByPrefix holds details about calls to the ByPrefix method.
+		ByPrefix []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Prefix is the prefix argument value.
+			Prefix string
+		}
+		// Create holds details about calls to the Create method.
+		Create []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// K is the k argument value.
+			K *auth.APIKey
+		}
+		// Delete holds details about calls to the Delete method.
+		Delete []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// CredID is the credID argument value.
+			CredID int
+			// ID is the id argument value.
+			ID int
+		}
+		// Touch holds details about calls to the Touch method.
+		Touch []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ID is the id argument value.
+			ID int
+			// At is the at argument value.
+			At time.Time
+		}
+	}
+	lockByCredential sync.RWMutex
+	lockByPrefix     sync.RWMutex
+	lockCreate       sync.RWMutex
+	lockDelete       sync.RWMutex
+	lockTouch        sync.RWMutex
+}
+
+// ByCredential calls ByCredentialFunc.
+func (mock *APIKeyRepositoryMock) ByCredential(ctx context.Context, credID int) ([]auth.APIKey, error) {
+	if mock.ByCredentialFunc == nil {
+		panic("APIKeyRepositoryMock.ByCredentialFunc: method is nil but APIKeyRepository.ByCredential was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		CredID int
+	}{
+		Ctx:    ctx,
+		CredID: credID,
+	}
+	mock.lockByCredential.Lock()
+	mock.calls.ByCredential = append(mock.calls.ByCredential, callInfo)
+	mock.lockByCredential.Unlock()
+	return mock.ByCredentialFunc(ctx, credID)
+}
+
+// ByCredentialCalls gets all the calls that were made to ByCredential.
+// Check the length with:
+//
+//	len(mockedAPIKeyRepository.ByCredentialCalls())
+func (mock *APIKeyRepositoryMock) ByCredentialCalls() []struct {
+	Ctx    context.Context
+	CredID int
+} {
+	var calls []struct {
+		Ctx    context.Context
+		CredID int
+	}
+	mock.lockByCredential.RLock()
+	calls = mock.calls.ByCredential
+	mock.lockByCredential.RUnlock()
+	return calls
+}
+
+// ByPrefix calls ByPrefixFunc.
+func (mock *APIKeyRepositoryMock) ByPrefix(ctx context.Context, prefix string) (auth.APIKey, error) {
+	if mock.ByPrefixFunc == nil {
+		panic("APIKeyRepositoryMock.ByPrefixFunc: method is nil but APIKeyRepository.ByPrefix was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Prefix string
+	}{
+		Ctx:    ctx,
+		Prefix: prefix,
+	}
+	mock.lockByPrefix.Lock()
+	mock.calls.ByPrefix = append(mock.calls.ByPrefix, callInfo)
+	mock.lockByPrefix.Unlock()
+	return mock.ByPrefixFunc(ctx, prefix)
+}
+
+// ByPrefixCalls gets all the calls that were made to ByPrefix.
+// Check the length with:
+//
+//	len(mockedAPIKeyRepository.ByPrefixCalls())
+func (mock *APIKeyRepositoryMock) ByPrefixCalls() []struct {
+	Ctx    context.Context
+	Prefix string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Prefix string
+	}
+	mock.lockByPrefix.RLock()
+	calls = mock.calls.ByPrefix
+	mock.lockByPrefix.RUnlock()
+	return calls
+}
+
+// Create calls CreateFunc.
+func (mock *APIKeyRepositoryMock) Create(ctx context.Context, k *auth.APIKey) error {
+	if mock.CreateFunc == nil {
+		panic("APIKeyRepositoryMock.CreateFunc: method is nil but APIKeyRepository.Create was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		K   *auth.APIKey
+	}{
+		Ctx: ctx,
+		K:   k,
+	}
+	mock.lockCreate.Lock()
+	mock.calls.Create = append(mock.calls.Create, callInfo)
+	mock.lockCreate.Unlock()
+	return mock.CreateFunc(ctx, k)
+}
+
+// CreateCalls gets all the calls that were made to Create.
+// Check the length with:
+//
+//	len(mockedAPIKeyRepository.CreateCalls())
+func (mock *APIKeyRepositoryMock) CreateCalls() []struct {
+	Ctx context.Context
+	K   *auth.APIKey
+} {
+	var calls []struct {
+		Ctx context.Context
+		K   *auth.APIKey
+	}
+	mock.lockCreate.RLock()
+	calls = mock.calls.Create
+	mock.lockCreate.RUnlock()
+	return calls
+}
+
+// Delete calls DeleteFunc.
+func (mock *APIKeyRepositoryMock) Delete(ctx context.Context, credID int, id int) error {
+	if mock.DeleteFunc == nil {
+		panic("APIKeyRepositoryMock.DeleteFunc: method is nil but APIKeyRepository.Delete was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		CredID int
+		ID     int
+	}{
+		Ctx:    ctx,
+		CredID: credID,
+		ID:     id,
+	}
+	mock.lockDelete.Lock()
+	mock.calls.Delete = append(mock.calls.Delete, callInfo)
+	mock.lockDelete.Unlock()
+	return mock.DeleteFunc(ctx, credID, id)
+}
+
+// DeleteCalls gets all the calls that were made to Delete.
+// Check the length with:
+//
+//	len(mockedAPIKeyRepository.DeleteCalls())
+func (mock *APIKeyRepositoryMock) DeleteCalls() []struct {
+	Ctx    context.Context
+	CredID int
+	ID     int
+} {
+	var calls []struct {
+		Ctx    context.Context
+		CredID int
+		ID     int
+	}
+	mock.lockDelete.RLock()
+	calls = mock.calls.Delete
+	mock.lockDelete.RUnlock()
+	return calls
+}
+
+// Touch calls TouchFunc.
+func (mock *APIKeyRepositoryMock) Touch(ctx context.Context, id int, at time.Time) error {
+	if mock.TouchFunc == nil {
+		panic("APIKeyRepositoryMock.TouchFunc: method is nil but APIKeyRepository.Touch was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		ID  int
+		At  time.Time
+	}{
+		Ctx: ctx,
+		ID:  id,
+		At:  at,
+	}
+	mock.lockTouch.Lock()
+	mock.calls.Touch = append(mock.calls.Touch, callInfo)
+	mock.lockTouch.Unlock()
+	return mock.TouchFunc(ctx, id, at)
+}
+
+// TouchCalls gets all the calls that were made to Touch.
+// Check the length with:
+//
+//	len(mockedAPIKeyRepository.TouchCalls())
+func (mock *APIKeyRepositoryMock) TouchCalls() []struct {
+	Ctx context.Context
+	ID  int
+	At  time.Time
+} {
+	var calls []struct {
+		Ctx context.Context
+		ID  int
+		At  time.Time
+	}
+	mock.lockTouch.RLock()
+	calls = mock.calls.Touch
+	mock.lockTouch.RUnlock()
+	return calls
+}
@@ -0,0 +1,182 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mock
+
+import (
+	"context"
+	"github.com/kl09/auth-go"
+	"sync"
+)
+
+// Ensure, that AuditLogMock does implement auth.AuditLog.
+// If this is not the case, regenerate this file with moq.
+var _ auth.AuditLog = &AuditLogMock{}
+
+// AuditLogMock is a mock implementation of auth.AuditLog.
+//
+//	func TestSomethingThatUsesAuditLog(t *testing.T) {
+//
+//		// make and configure a mocked auth.AuditLog
+//		mockedAuditLog := &AuditLogMock{
+//			AnonymizeFunc: func(ctx context.Context, credID int) error {
+//				panic("mock out the Anonymize method")
+//			},
+//			AppendFunc: func(ctx context.Context, e *auth.AuditEntry) error {
+//				panic("mock out the Append method")
+//			},
+//			FindFunc: func(ctx context.Context, f auth.AuditFilter) ([]auth.AuditEntry, error) {
+//				panic("mock out the Find method")
+//			},
+//		}
+//
+//		// use mockedAuditLog in code that requires auth.AuditLog
+//		// and then make assertions.
+//
+//	}
+type AuditLogMock struct {
+	// AnonymizeFunc mocks the Anonymize method.
+	AnonymizeFunc func(ctx context.Context, credID int) error
+
+	// AppendFunc mocks the Append method.
+	AppendFunc func(ctx context.Context, e *auth.AuditEntry) error
+
+	// FindFunc mocks the Find method.
+	FindFunc func(ctx context.Context, f auth.AuditFilter) ([]auth.AuditEntry, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Anonymize holds details about calls to the Anonymize method.
+		Anonymize []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// CredID is the credID argument value.
+			CredID int
+		}
+		// Append holds details about calls to the Append method.
+		Append []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// E is the e argument value.
+			E *auth.AuditEntry
+		}
+		// Find holds details about calls to the Find method.
+		Find []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// F is the f argument value.
+			F auth.AuditFilter
+		}
+	}
+	lockAnonymize sync.RWMutex
+	lockAppend    sync.RWMutex
+	lockFind      sync.RWMutex
+}
+
+// Anonymize calls AnonymizeFunc.
+func (mock *AuditLogMock) Anonymize(ctx context.Context, credID int) error {
+	if mock.AnonymizeFunc == nil {
+		panic("AuditLogMock.AnonymizeFunc: method is nil but AuditLog.Anonymize was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		CredID int
+	}{
+		Ctx:    ctx,
+		CredID: credID,
+	}
+	mock.lockAnonymize.Lock()
+	mock.calls.Anonymize = append(mock.calls.Anonymize, callInfo)
+	mock.lockAnonymize.Unlock()
+	return mock.AnonymizeFunc(ctx, credID)
+}
+
+// AnonymizeCalls gets all the calls that were made to Anonymize.
+// Check the length with:
+//
+//	len(mockedAuditLog.AnonymizeCalls())
+func (mock *AuditLogMock) AnonymizeCalls() []struct {
+	Ctx    context.Context
+	CredID int
+} {
+	var calls []struct {
+		Ctx    context.Context
+		CredID int
+	}
+	mock.lockAnonymize.RLock()
+	calls = mock.calls.Anonymize
+	mock.lockAnonymize.RUnlock()
+	return calls
+}
+
+// Append calls AppendFunc.
+func (mock *AuditLogMock) Append(ctx context.Context, e *auth.AuditEntry) error {
+	if mock.AppendFunc == nil {
+		panic("AuditLogMock.AppendFunc: method is nil but AuditLog.Append was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		E   *auth.AuditEntry
+	}{
+		Ctx: ctx,
+		E:   e,
+	}
+	mock.lockAppend.Lock()
+	mock.calls.Append = append(mock.calls.Append, callInfo)
+	mock.lockAppend.Unlock()
+	return mock.AppendFunc(ctx, e)
+}
+
+// AppendCalls gets all the calls that were made to Append.
+// Check the length with:
+//
+//	len(mockedAuditLog.AppendCalls())
+func (mock *AuditLogMock) AppendCalls() []struct {
+	Ctx context.Context
+	E   *auth.AuditEntry
+} {
+	var calls []struct {
+		Ctx context.Context
+		E   *auth.AuditEntry
+	}
+	mock.lockAppend.RLock()
+	calls = mock.calls.Append
+	mock.lockAppend.RUnlock()
+	return calls
+}
+
+// Find calls FindFunc.
+func (mock *AuditLogMock) Find(ctx context.Context, f auth.AuditFilter) ([]auth.AuditEntry, error) {
+	if mock.FindFunc == nil {
+		panic("AuditLogMock.FindFunc: method is nil but AuditLog.Find was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		F   auth.AuditFilter
+	}{
+		Ctx: ctx,
+		F:   f,
+	}
+	mock.lockFind.Lock()
+	mock.calls.Find = append(mock.calls.Find, callInfo)
+	mock.lockFind.Unlock()
+	return mock.FindFunc(ctx, f)
+}
+
+// FindCalls gets all the calls that were made to Find.
+// Check the length with:
+//
+//	len(mockedAuditLog.FindCalls())
+func (mock *AuditLogMock) FindCalls() []struct {
+	Ctx context.Context
+	F   auth.AuditFilter
+} {
+	var calls []struct {
+		Ctx context.Context
+		F   auth.AuditFilter
+	}
+	mock.lockFind.RLock()
+	calls = mock.calls.Find
+	mock.lockFind.RUnlock()
+	return calls
+}
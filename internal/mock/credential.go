@@ -7,6 +7,7 @@ import (
 	"context"
 	"github.com/kl09/auth-go"
 	"sync"
+	"time"
 )
 
 // Ensure, that CredentialRepositoryMock does implement auth.CredentialRepository.
@@ -15,28 +16,40 @@ var _ auth.CredentialRepository = &CredentialRepositoryMock{}
 
 // CredentialRepositoryMock is a mock implementation of auth.CredentialRepository.
 //
-//     func TestSomethingThatUsesCredentialRepository(t *testing.T) {
+//	func TestSomethingThatUsesCredentialRepository(t *testing.T) {
 //
-//         // make and configure a mocked auth.CredentialRepository
-//         mockedCredentialRepository := &CredentialRepositoryMock{
-//             ByEmailFunc: func(ctx context.Context, email string) (auth.Credential, error) {
-// 	               panic("mock out the ByEmail method")
-//             },
-//             ByIDFunc: func(ctx context.Context, id int) (auth.Credential, error) {
-// 	               panic("mock out the ByID method")
-//             },
-//             ByTokenFunc: func(ctx context.Context, token string) (auth.Credential, error) {
-// 	               panic("mock out the ByToken method")
-//             },
-//             CreateFunc: func(ctx context.Context, c *auth.Credential) error {
-// 	               panic("mock out the Create method")
-//             },
-//         }
+//		// make and configure a mocked auth.CredentialRepository
+//		mockedCredentialRepository := &CredentialRepositoryMock{
+//			ByEmailFunc: func(ctx context.Context, email string) (auth.Credential, error) {
+//				panic("mock out the ByEmail method")
+//			},
+//			ByIDFunc: func(ctx context.Context, id int) (auth.Credential, error) {
+//				panic("mock out the ByID method")
+//			},
+//			ByTokenFunc: func(ctx context.Context, token string) (auth.Credential, error) {
+//				panic("mock out the ByToken method")
+//			},
+//			CreateFunc: func(ctx context.Context, c *auth.Credential) error {
+//				panic("mock out the Create method")
+//			},
+//			DeleteFunc: func(ctx context.Context, id int) error {
+//				panic("mock out the Delete method")
+//			},
+//			PendingDeletionFunc: func(ctx context.Context, before time.Time, limit int) ([]auth.Credential, error) {
+//				panic("mock out the PendingDeletion method")
+//			},
+//			SearchFunc: func(ctx context.Context, f auth.CredentialFilter) ([]auth.Credential, error) {
+//				panic("mock out the Search method")
+//			},
+//			UpdateFunc: func(ctx context.Context, c *auth.Credential) error {
+//				panic("mock out the Update method")
+//			},
+//		}
 //
-//         // use mockedCredentialRepository in code that requires auth.CredentialRepository
-//         // and then make assertions.
+//		// use mockedCredentialRepository in code that requires auth.CredentialRepository
+//		// and then make assertions.
 //
-//     }
+//	}
 type CredentialRepositoryMock struct {
 	// ByEmailFunc mocks the ByEmail method.
 	ByEmailFunc func(ctx context.Context, email string) (auth.Credential, error)
@@ -50,6 +63,18 @@ type CredentialRepositoryMock struct {
 	// CreateFunc mocks the Create method.
 	CreateFunc func(ctx context.Context, c *auth.Credential) error
 
+	// DeleteFunc mocks the Delete method.
+	DeleteFunc func(ctx context.Context, id int) error
+
+	// PendingDeletionFunc mocks the PendingDeletion method.
+	PendingDeletionFunc func(ctx context.Context, before time.Time, limit int) ([]auth.Credential, error)
+
+	// SearchFunc mocks the Search method.
+	SearchFunc func(ctx context.Context, f auth.CredentialFilter) ([]auth.Credential, error)
+
+	// UpdateFunc mocks the Update method.
+	UpdateFunc func(ctx context.Context, c *auth.Credential) error
+
 	// calls tracks calls to the methods.
 	calls struct {
 		// ByEmail holds details about calls to the ByEmail method.
@@ -80,11 +105,45 @@ type CredentialRepositoryMock struct {
 			// C is the c argument value.
 			C *auth.Credential
 		}
+		// Delete holds details about calls to the Delete method.
+		Delete []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ID is the id argument value.
+			ID int
+		}
+		// PendingDeletion holds details about calls to the PendingDeletion method.
+		PendingDeletion []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Before is the before argument value.
+			Before time.Time
+			// Limit is the limit argument value.
+			Limit int
+		}
+		// Search holds details about calls to the Search method.
+		Search []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// F is the f argument value.
+			F auth.CredentialFilter
+		}
+		// Update holds details about calls to the Update method.
+		Update []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// C is the c argument value.
+			C *auth.Credential
+		}
 	}
-	lockByEmail sync.RWMutex
-	lockByID    sync.RWMutex
-	lockByToken sync.RWMutex
-	lockCreate  sync.RWMutex
+	lockByEmail         sync.RWMutex
+	lockByID            sync.RWMutex
+	lockByToken         sync.RWMutex
+	lockCreate          sync.RWMutex
+	lockDelete          sync.RWMutex
+	lockPendingDeletion sync.RWMutex
+	lockSearch          sync.RWMutex
+	lockUpdate          sync.RWMutex
 }
 
 // ByEmail calls ByEmailFunc.
@@ -107,7 +166,8 @@ func (mock *CredentialRepositoryMock) ByEmail(ctx context.Context, email string)
 
 // ByEmailCalls gets all the calls that were made to ByEmail.
 // Check the length with:
-//     len(mockedCredentialRepository.ByEmailCalls())
+//
+//	len(mockedCredentialRepository.ByEmailCalls())
 func (mock *CredentialRepositoryMock) ByEmailCalls() []struct {
 	Ctx   context.Context
 	Email string
@@ -142,7 +202,8 @@ func (mock *CredentialRepositoryMock) ByID(ctx context.Context, id int) (auth.Cr
 
 // ByIDCalls gets all the calls that were made to ByID.
 // Check the length with:
-//     len(mockedCredentialRepository.ByIDCalls())
+//
+//	len(mockedCredentialRepository.ByIDCalls())
 func (mock *CredentialRepositoryMock) ByIDCalls() []struct {
 	Ctx context.Context
 	ID  int
@@ -177,7 +238,8 @@ func (mock *CredentialRepositoryMock) ByToken(ctx context.Context, token string)
 
 // ByTokenCalls gets all the calls that were made to ByToken.
 // Check the length with:
-//     len(mockedCredentialRepository.ByTokenCalls())
+//
+//	len(mockedCredentialRepository.ByTokenCalls())
 func (mock *CredentialRepositoryMock) ByTokenCalls() []struct {
 	Ctx   context.Context
 	Token string
@@ -212,7 +274,8 @@ func (mock *CredentialRepositoryMock) Create(ctx context.Context, c *auth.Creden
 
 // CreateCalls gets all the calls that were made to Create.
 // Check the length with:
-//     len(mockedCredentialRepository.CreateCalls())
+//
+//	len(mockedCredentialRepository.CreateCalls())
 func (mock *CredentialRepositoryMock) CreateCalls() []struct {
 	Ctx context.Context
 	C   *auth.Credential
@@ -226,3 +289,151 @@ func (mock *CredentialRepositoryMock) CreateCalls() []struct {
 	mock.lockCreate.RUnlock()
 	return calls
 }
+
+// Delete calls DeleteFunc.
+func (mock *CredentialRepositoryMock) Delete(ctx context.Context, id int) error {
+	if mock.DeleteFunc == nil {
+		panic("CredentialRepositoryMock.DeleteFunc: method is nil but CredentialRepository.Delete was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		ID  int
+	}{
+		Ctx: ctx,
+		ID:  id,
+	}
+	mock.lockDelete.Lock()
+	mock.calls.Delete = append(mock.calls.Delete, callInfo)
+	mock.lockDelete.Unlock()
+	return mock.DeleteFunc(ctx, id)
+}
+
+// DeleteCalls gets all the calls that were made to Delete.
+// Check the length with:
+//
+//	len(mockedCredentialRepository.DeleteCalls())
+func (mock *CredentialRepositoryMock) DeleteCalls() []struct {
+	Ctx context.Context
+	ID  int
+} {
+	var calls []struct {
+		Ctx context.Context
+		ID  int
+	}
+	mock.lockDelete.RLock()
+	calls = mock.calls.Delete
+	mock.lockDelete.RUnlock()
+	return calls
+}
+
+// PendingDeletion calls PendingDeletionFunc.
+func (mock *CredentialRepositoryMock) PendingDeletion(ctx context.Context, before time.Time, limit int) ([]auth.Credential, error) {
+	if mock.PendingDeletionFunc == nil {
+		panic("CredentialRepositoryMock.PendingDeletionFunc: method is nil but CredentialRepository.PendingDeletion was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Before time.Time
+		Limit  int
+	}{
+		Ctx:    ctx,
+		Before: before,
+		Limit:  limit,
+	}
+	mock.lockPendingDeletion.Lock()
+	mock.calls.PendingDeletion = append(mock.calls.PendingDeletion, callInfo)
+	mock.lockPendingDeletion.Unlock()
+	return mock.PendingDeletionFunc(ctx, before, limit)
+}
+
+// PendingDeletionCalls gets all the calls that were made to PendingDeletion.
+// Check the length with:
+//
+//	len(mockedCredentialRepository.PendingDeletionCalls())
+func (mock *CredentialRepositoryMock) PendingDeletionCalls() []struct {
+	Ctx    context.Context
+	Before time.Time
+	Limit  int
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Before time.Time
+		Limit  int
+	}
+	mock.lockPendingDeletion.RLock()
+	calls = mock.calls.PendingDeletion
+	mock.lockPendingDeletion.RUnlock()
+	return calls
+}
+
+// Search calls SearchFunc.
+func (mock *CredentialRepositoryMock) Search(ctx context.Context, f auth.CredentialFilter) ([]auth.Credential, error) {
+	if mock.SearchFunc == nil {
+		panic("CredentialRepositoryMock.SearchFunc: method is nil but CredentialRepository.Search was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		F   auth.CredentialFilter
+	}{
+		Ctx: ctx,
+		F:   f,
+	}
+	mock.lockSearch.Lock()
+	mock.calls.Search = append(mock.calls.Search, callInfo)
+	mock.lockSearch.Unlock()
+	return mock.SearchFunc(ctx, f)
+}
+
+// SearchCalls gets all the calls that were made to Search.
+// Check the length with:
+//
+//	len(mockedCredentialRepository.SearchCalls())
+func (mock *CredentialRepositoryMock) SearchCalls() []struct {
+	Ctx context.Context
+	F   auth.CredentialFilter
+} {
+	var calls []struct {
+		Ctx context.Context
+		F   auth.CredentialFilter
+	}
+	mock.lockSearch.RLock()
+	calls = mock.calls.Search
+	mock.lockSearch.RUnlock()
+	return calls
+}
+
+// Update calls UpdateFunc.
+func (mock *CredentialRepositoryMock) Update(ctx context.Context, c *auth.Credential) error {
+	if mock.UpdateFunc == nil {
+		panic("CredentialRepositoryMock.UpdateFunc: method is nil but CredentialRepository.Update was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		C   *auth.Credential
+	}{
+		Ctx: ctx,
+		C:   c,
+	}
+	mock.lockUpdate.Lock()
+	mock.calls.Update = append(mock.calls.Update, callInfo)
+	mock.lockUpdate.Unlock()
+	return mock.UpdateFunc(ctx, c)
+}
+
+// UpdateCalls gets all the calls that were made to Update.
+// Check the length with:
+//
+//	len(mockedCredentialRepository.UpdateCalls())
+func (mock *CredentialRepositoryMock) UpdateCalls() []struct {
+	Ctx context.Context
+	C   *auth.Credential
+} {
+	var calls []struct {
+		Ctx context.Context
+		C   *auth.Credential
+	}
+	mock.lockUpdate.RLock()
+	calls = mock.calls.Update
+	mock.lockUpdate.RUnlock()
+	return calls
+}
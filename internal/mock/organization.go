@@ -0,0 +1,407 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mock
+
+import (
+	"context"
+	"github.com/kl09/auth-go"
+	"sync"
+	"time"
+)
+
+// Ensure, that OrganizationRepositoryMock does implement auth.OrganizationRepository.
+// If this is not the case, regenerate this file with moq.
+var _ auth.OrganizationRepository = &OrganizationRepositoryMock{}
+
+// OrganizationRepositoryMock is a mock implementation of auth.OrganizationRepository.
+//
+//	func TestSomethingThatUsesOrganizationRepository(t *testing.T) {
+//
+//		// make and configure a mocked auth.OrganizationRepository
+//		mockedOrganizationRepository := &OrganizationRepositoryMock{
+//			AcceptInvitationFunc: func(ctx context.Context, id int, credID int, at time.Time) (auth.Membership, error) {
+//				panic("mock out the AcceptInvitation method")
+//			},
+//			ByCredentialFunc: func(ctx context.Context, credID int) ([]auth.Membership, error) {
+//				panic("mock out the ByCredential method")
+//			},
+//			CreateFunc: func(ctx context.Context, o *auth.Organization, ownerID int) error {
+//				panic("mock out the Create method")
+//			},
+//			CreateInvitationFunc: func(ctx context.Context, i *auth.Invitation) error {
+//				panic("mock out the CreateInvitation method")
+//			},
+//			DeleteInvitationsFunc: func(ctx context.Context, email string) error {
+//				panic("mock out the DeleteInvitations method")
+//			},
+//			InvitationFunc: func(ctx context.Context, id int) (auth.Invitation, error) {
+//				panic("mock out the Invitation method")
+//			},
+//			MemberFunc: func(ctx context.Context, orgID int, credID int) (auth.Membership, error) {
+//				panic("mock out the Member method")
+//			},
+//		}
+//
+//		// use mockedOrganizationRepository in code that requires auth.OrganizationRepository
+//		// and then make assertions.
+//
+//	}
+type OrganizationRepositoryMock struct {
+	// AcceptInvitationFunc mocks the AcceptInvitation method.
+	AcceptInvitationFunc func(ctx context.Context, id int, credID int, at time.Time) (auth.Membership, error)
+
+	// ByCredentialFunc mocks the ByCredential method.
+	ByCredentialFunc func(ctx context.Context, credID int) ([]auth.Membership, error)
+
+	// CreateFunc mocks the Create method.
+	CreateFunc func(ctx context.Context, o *auth.Organization, ownerID int) error
+
+	// CreateInvitationFunc mocks the CreateInvitation method.
+	CreateInvitationFunc func(ctx context.Context, i *auth.Invitation) error
+
+	// DeleteInvitationsFunc mocks the DeleteInvitations method.
+	DeleteInvitationsFunc func(ctx context.Context, email string) error
+
+	// InvitationFunc mocks the Invitation method.
+	InvitationFunc func(ctx context.Context, id int) (auth.Invitation, error)
+
+	// MemberFunc mocks the Member method.
+	MemberFunc func(ctx context.Context, orgID int, credID int) (auth.Membership, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// AcceptInvitation holds details about calls to the AcceptInvitation method.
+		AcceptInvitation []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ID is the id argument value.
+			ID int
+			// CredID is the credID argument value.
+			CredID int
+			// At is the at argument value.
+			At time.Time
+		}
+		// ByCredential holds details about calls to the ByCredential method.
+		ByCredential []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// CredID is the credID argument value.
+			CredID int
+		}
+		// Create holds details about calls to the Create method.
+		Create []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// O is the o argument value.
+			O *auth.Organization
+			// OwnerID is the ownerID argument value.
+			OwnerID int
+		}
+		// CreateInvitation holds details about calls to the CreateInvitation method.
+		CreateInvitation []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// I is the i argument value.
+			I *auth.Invitation
+		}
+		// DeleteInvitations holds details about calls to the DeleteInvitations method.
+		DeleteInvitations []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Email is the email argument value.
+			Email string
+		}
+		// Invitation holds details about calls to the Invitation method.
+		Invitation []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ID is the id argument value.
+			ID int
+		}
+		// Member holds details about calls to the Member method.
+		Member []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// OrgID is the orgID argument value.
+			OrgID int
+			// CredID is the credID argument value.
+			CredID int
+		}
+	}
+	lockAcceptInvitation  sync.RWMutex
+	lockByCredential      sync.RWMutex
+	lockCreate            sync.RWMutex
+	lockCreateInvitation  sync.RWMutex
+	lockDeleteInvitations sync.RWMutex
+	lockInvitation        sync.RWMutex
+	lockMember            sync.RWMutex
+}
+
+// AcceptInvitation calls AcceptInvitationFunc.
+func (mock *OrganizationRepositoryMock) AcceptInvitation(ctx context.Context, id int, credID int, at time.Time) (auth.Membership, error) {
+	if mock.AcceptInvitationFunc == nil {
+		panic("OrganizationRepositoryMock.AcceptInvitationFunc: method is nil but OrganizationRepository.AcceptInvitation was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		ID     int
+		CredID int
+		At     time.Time
+	}{
+		Ctx:    ctx,
+		ID:     id,
+		CredID: credID,
+		At:     at,
+	}
+	mock.lockAcceptInvitation.Lock()
+	mock.calls.AcceptInvitation = append(mock.calls.AcceptInvitation, callInfo)
+	mock.lockAcceptInvitation.Unlock()
+	return mock.AcceptInvitationFunc(ctx, id, credID, at)
+}
+
+// AcceptInvitationCalls gets all the calls that were made to AcceptInvitation.
+// Check the length with:
+//
+//	len(mockedOrganizationRepository.AcceptInvitationCalls())
+func (mock *OrganizationRepositoryMock) AcceptInvitationCalls() []struct {
+	Ctx    context.Context
+	ID     int
+	CredID int
+	At     time.Time
+} {
+	var calls []struct {
+		Ctx    context.Context
+		ID     int
+		CredID int
+		At     time.Time
+	}
+	mock.lockAcceptInvitation.RLock()
+	calls = mock.calls.AcceptInvitation
+	mock.lockAcceptInvitation.RUnlock()
+	return calls
+}
+
+// ByCredential calls ByCredentialFunc.
+func (mock *OrganizationRepositoryMock) ByCredential(ctx context.Context, credID int) ([]auth.Membership, error) {
+	if mock.ByCredentialFunc == nil {
+		panic("OrganizationRepositoryMock.ByCredentialFunc: method is nil but OrganizationRepository.ByCredential was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		CredID int
+	}{
+		Ctx:    ctx,
+		CredID: credID,
+	}
+	mock.lockByCredential.Lock()
+	mock.calls.ByCredential = append(mock.calls.ByCredential, callInfo)
+	mock.lockByCredential.Unlock()
+	return mock.ByCredentialFunc(ctx, credID)
+}
+
+// ByCredentialCalls gets all the calls that were made to ByCredential.
+// Check the length with:
+//
+//	len(mockedOrganizationRepository.ByCredentialCalls())
+func (mock *OrganizationRepositoryMock) ByCredentialCalls() []struct {
+	Ctx    context.Context
+	CredID int
+} {
+	var calls []struct {
+		Ctx    context.Context
+		CredID int
+	}
+	mock.lockByCredential.RLock()
+	calls = mock.calls.ByCredential
+	mock.lockByCredential.RUnlock()
+	return calls
+}
+
+// Create calls CreateFunc.
+func (mock *OrganizationRepositoryMock) Create(ctx context.Context, o *auth.Organization, ownerID int) error {
+	if mock.CreateFunc == nil {
+		panic("OrganizationRepositoryMock.CreateFunc: method is nil but OrganizationRepository.Create was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		O       *auth.Organization
+		OwnerID int
+	}{
+		Ctx:     ctx,
+		O:       o,
+		OwnerID: ownerID,
+	}
+	mock.lockCreate.Lock()
+	mock.calls.Create = append(mock.calls.Create, callInfo)
+	mock.lockCreate.Unlock()
+	return mock.CreateFunc(ctx, o, ownerID)
+}
+
+// CreateCalls gets all the calls that were made to Create.
+// Check the length with:
+//
+//	len(mockedOrganizationRepository.CreateCalls())
+func (mock *OrganizationRepositoryMock) CreateCalls() []struct {
+	Ctx     context.Context
+	O       *auth.Organization
+	OwnerID int
+} {
+	var calls []struct {
+		Ctx     context.Context
+		O       *auth.Organization
+		OwnerID int
+	}
+	mock.lockCreate.RLock()
+	calls = mock.calls.Create
+	mock.lockCreate.RUnlock()
+	return calls
+}
+
+// CreateInvitation calls CreateInvitationFunc.
+func (mock *OrganizationRepositoryMock) CreateInvitation(ctx context.Context, i *auth.Invitation) error {
+	if mock.CreateInvitationFunc == nil {
+		panic("OrganizationRepositoryMock.CreateInvitationFunc: method is nil but OrganizationRepository.CreateInvitation was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		I   *auth.Invitation
+	}{
+		Ctx: ctx,
+		I:   i,
+	}
+	mock.lockCreateInvitation.Lock()
+	mock.calls.CreateInvitation = append(mock.calls.CreateInvitation, callInfo)
+	mock.lockCreateInvitation.Unlock()
+	return mock.CreateInvitationFunc(ctx, i)
+}
+
+// CreateInvitationCalls gets all the calls that were made to CreateInvitation.
+// Check the length with:
+//
+//	len(mockedOrganizationRepository.CreateInvitationCalls())
+func (mock *OrganizationRepositoryMock) CreateInvitationCalls() []struct {
+	Ctx context.Context
+	I   *auth.Invitation
+} {
+	var calls []struct {
+		Ctx context.Context
+		I   *auth.Invitation
+	}
+	mock.lockCreateInvitation.RLock()
+	calls = mock.calls.CreateInvitation
+	mock.lockCreateInvitation.RUnlock()
+	return calls
+}
+
+// DeleteInvitations calls DeleteInvitationsFunc.
+func (mock *OrganizationRepositoryMock) DeleteInvitations(ctx context.Context, email string) error {
+	if mock.DeleteInvitationsFunc == nil {
+		panic("OrganizationRepositoryMock.DeleteInvitationsFunc: method is nil but OrganizationRepository.DeleteInvitations was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Email string
+	}{
+		Ctx:   ctx,
+		Email: email,
+	}
+	mock.lockDeleteInvitations.Lock()
+	mock.calls.DeleteInvitations = append(mock.calls.DeleteInvitations, callInfo)
+	mock.lockDeleteInvitations.Unlock()
+	return mock.DeleteInvitationsFunc(ctx, email)
+}
+
+// DeleteInvitationsCalls gets all the calls that were made to DeleteInvitations.
+// Check the length with:
+//
+//	len(mockedOrganizationRepository.DeleteInvitationsCalls())
+func (mock *OrganizationRepositoryMock) DeleteInvitationsCalls() []struct {
+	Ctx   context.Context
+	Email string
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Email string
+	}
+	mock.lockDeleteInvitations.RLock()
+	calls = mock.calls.DeleteInvitations
+	mock.lockDeleteInvitations.RUnlock()
+	return calls
+}
+
+// Invitation calls InvitationFunc.
+func (mock *OrganizationRepositoryMock) Invitation(ctx context.Context, id int) (auth.Invitation, error) {
+	if mock.InvitationFunc == nil {
+		panic("OrganizationRepositoryMock.InvitationFunc: method is nil but OrganizationRepository.Invitation was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		ID  int
+	}{
+		Ctx: ctx,
+		ID:  id,
+	}
+	mock.lockInvitation.Lock()
+	mock.calls.Invitation = append(mock.calls.Invitation, callInfo)
+	mock.lockInvitation.Unlock()
+	return mock.InvitationFunc(ctx, id)
+}
+
+// InvitationCalls gets all the calls that were made to Invitation.
+// Check the length with:
+//
+//	len(mockedOrganizationRepository.InvitationCalls())
+func (mock *OrganizationRepositoryMock) InvitationCalls() []struct {
+	Ctx context.Context
+	ID  int
+} {
+	var calls []struct {
+		Ctx context.Context
+		ID  int
+	}
+	mock.lockInvitation.RLock()
+	calls = mock.calls.Invitation
+	mock.lockInvitation.RUnlock()
+	return calls
+}
+
+// Member calls MemberFunc.
+func (mock *OrganizationRepositoryMock) Member(ctx context.Context, orgID int, credID int) (auth.Membership, error) {
+	if mock.MemberFunc == nil {
+		panic("OrganizationRepositoryMock.MemberFunc: method is nil but OrganizationRepository.Member was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		OrgID  int
+		CredID int
+	}{
+		Ctx:    ctx,
+		OrgID:  orgID,
+		CredID: credID,
+	}
+	mock.lockMember.Lock()
+	mock.calls.Member = append(mock.calls.Member, callInfo)
+	mock.lockMember.Unlock()
+	return mock.MemberFunc(ctx, orgID, credID)
+}
+
+// MemberCalls gets all the calls that were made to Member.
+// Check the length with:
+//
+//	len(mockedOrganizationRepository.MemberCalls())
+func (mock *OrganizationRepositoryMock) MemberCalls() []struct {
+	Ctx    context.Context
+	OrgID  int
+	CredID int
+} {
+	var calls []struct {
+		Ctx    context.Context
+		OrgID  int
+		CredID int
+	}
+	mock.lockMember.RLock()
+	calls = mock.calls.Member
+	mock.lockMember.RUnlock()
+	return calls
+}
@@ -0,0 +1,132 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mock
+
+import (
+	"context"
+	"github.com/kl09/auth-go"
+	"sync"
+)
+
+// Ensure, that ProfileRepositoryMock does implement auth.ProfileRepository.
+// If this is not the case, regenerate this file with moq.
+var _ auth.ProfileRepository = &ProfileRepositoryMock{}
+
+// ProfileRepositoryMock is a mock implementation of auth.ProfileRepository.
+//
+//	func TestSomethingThatUsesProfileRepository(t *testing.T) {
+//
+//		// make and configure a mocked auth.ProfileRepository
+//		mockedProfileRepository := &ProfileRepositoryMock{
+//			ByCredentialFunc: func(ctx context.Context, credID int) (auth.Profile, error) {
+//				panic("mock out the ByCredential method")
+//			},
+//			SaveFunc: func(ctx context.Context, p *auth.Profile) error {
+//				panic("mock out the Save method")
+//			},
+//		}
+//
+//		// use mockedProfileRepository in code that requires auth.ProfileRepository
+//		// and then make assertions.
+//
+//	}
+type ProfileRepositoryMock struct {
+	// ByCredentialFunc mocks the ByCredential method.
+	ByCredentialFunc func(ctx context.Context, credID int) (auth.Profile, error)
+
+	// SaveFunc mocks the Save method.
+	SaveFunc func(ctx context.Context, p *auth.Profile) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// ByCredential holds details about calls to the ByCredential method.
+		ByCredential []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// CredID is the credID argument value.
+			CredID int
+		}
+		// Save holds details about calls to the Save method.
+		Save []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// P is the p argument value.
+			P *auth.Profile
+		}
+	}
+	lockByCredential sync.RWMutex
+	lockSave         sync.RWMutex
+}
+
+// ByCredential calls ByCredentialFunc.
+func (mock *ProfileRepositoryMock) ByCredential(ctx context.Context, credID int) (auth.Profile, error) {
+	if mock.ByCredentialFunc == nil {
+		panic("ProfileRepositoryMock.ByCredentialFunc: method is nil but ProfileRepository.ByCredential was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		CredID int
+	}{
+		Ctx:    ctx,
+		CredID: credID,
+	}
+	mock.lockByCredential.Lock()
+	mock.calls.ByCredential = append(mock.calls.ByCredential, callInfo)
+	mock.lockByCredential.Unlock()
+	return mock.ByCredentialFunc(ctx, credID)
+}
+
+// ByCredentialCalls gets all the calls that were made to ByCredential.
+// Check the length with:
+//
+//	len(mockedProfileRepository.ByCredentialCalls())
+func (mock *ProfileRepositoryMock) ByCredentialCalls() []struct {
+	Ctx    context.Context
+	CredID int
+} {
+	var calls []struct {
+		Ctx    context.Context
+		CredID int
+	}
+	mock.lockByCredential.RLock()
+	calls = mock.calls.ByCredential
+	mock.lockByCredential.RUnlock()
+	return calls
+}
+
+// Save calls SaveFunc.
+func (mock *ProfileRepositoryMock) Save(ctx context.Context, p *auth.Profile) error {
+	if mock.SaveFunc == nil {
+		panic("ProfileRepositoryMock.SaveFunc: method is nil but ProfileRepository.Save was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		P   *auth.Profile
+	}{
+		Ctx: ctx,
+		P:   p,
+	}
+	mock.lockSave.Lock()
+	mock.calls.Save = append(mock.calls.Save, callInfo)
+	mock.lockSave.Unlock()
+	return mock.SaveFunc(ctx, p)
+}
+
+// SaveCalls gets all the calls that were made to Save.
+// Check the length with:
+//
+//	len(mockedProfileRepository.SaveCalls())
+func (mock *ProfileRepositoryMock) SaveCalls() []struct {
+	Ctx context.Context
+	P   *auth.Profile
+} {
+	var calls []struct {
+		Ctx context.Context
+		P   *auth.Profile
+	}
+	mock.lockSave.RLock()
+	calls = mock.calls.Save
+	mock.lockSave.RUnlock()
+	return calls
+}
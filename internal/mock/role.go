@@ -0,0 +1,338 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mock
+
+import (
+	"context"
+	"github.com/kl09/auth-go"
+	"sync"
+)
+
+// Ensure, that RoleRepositoryMock does implement auth.RoleRepository.
+// If this is not the case, regenerate this file with moq.
+var _ auth.RoleRepository = &RoleRepositoryMock{}
+
+// RoleRepositoryMock is a mock implementation of auth.RoleRepository.
+//
+//	func TestSomethingThatUsesRoleRepository(t *testing.T) {
+//
+//		// make and configure a mocked auth.RoleRepository
+//		mockedRoleRepository := &RoleRepositoryMock{
+//			AssignFunc: func(ctx context.Context, credID int, name string) error {
+//				panic("mock out the Assign method")
+//			},
+//			ByCredentialFunc: func(ctx context.Context, credID int) ([]auth.Role, error) {
+//				panic("mock out the ByCredential method")
+//			},
+//			CreateFunc: func(ctx context.Context, r *auth.Role) error {
+//				panic("mock out the Create method")
+//			},
+//			DeleteFunc: func(ctx context.Context, name string) error {
+//				panic("mock out the Delete method")
+//			},
+//			RolesFunc: func(ctx context.Context) ([]auth.Role, error) {
+//				panic("mock out the Roles method")
+//			},
+//			UnassignFunc: func(ctx context.Context, credID int, name string) error {
+//				panic("mock out the Unassign method")
+//			},
+//		}
+//
+//		// use mockedRoleRepository in code that requires auth.RoleRepository
+//		// and then make assertions.
+//
+//	}
+type RoleRepositoryMock struct {
+	// AssignFunc mocks the Assign method.
+	AssignFunc func(ctx context.Context, credID int, name string) error
+
+	// ByCredentialFunc mocks the ByCredential method.
+	ByCredentialFunc func(ctx context.Context, credID int) ([]auth.Role, error)
+
+	// CreateFunc mocks the Create method.
+	CreateFunc func(ctx context.Context, r *auth.Role) error
+
+	// DeleteFunc mocks the Delete method.
+	DeleteFunc func(ctx context.Context, name string) error
+
+	// RolesFunc mocks the Roles method.
+	RolesFunc func(ctx context.Context) ([]auth.Role, error)
+
+	// UnassignFunc mocks the Unassign method.
+	UnassignFunc func(ctx context.Context, credID int, name string) error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Assign holds details about calls to the Assign method.
+		Assign []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// CredID is the credID argument value.
+			CredID int
+			// Name is the name argument value.
+			Name string
+		}
+		// ByCredential holds details about calls to the ByCredential method.
+		ByCredential []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// CredID is the credID argument value.
+			CredID int
+		}
+		// Create holds details about calls to the Create method.
+		Create []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// R is the r argument value.
+			R *auth.Role
+		}
+		// Delete holds details about calls to the Delete method.
+		Delete []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Name is the name argument value.
+			Name string
+		}
+		// Roles holds details about calls to the Roles method.
+		Roles []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// Unassign holds details about calls to the Unassign method.
+		Unassign []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// CredID is the credID argument value.
+			CredID int
+			// Name is the name argument value.
+			Name string
+		}
+	}
+	lockAssign       sync.RWMutex
+	lockByCredential sync.RWMutex
+	lockCreate       sync.RWMutex
+	lockDelete       sync.RWMutex
+	lockRoles        sync.RWMutex
+	lockUnassign     sync.RWMutex
+}
+
+// Assign calls AssignFunc.
+func (mock *RoleRepositoryMock) Assign(ctx context.Context, credID int, name string) error {
+	if mock.AssignFunc == nil {
+		panic("RoleRepositoryMock.AssignFunc: method is nil but RoleRepository.Assign was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		CredID int
+		Name   string
+	}{
+		Ctx:    ctx,
+		CredID: credID,
+		Name:   name,
+	}
+	mock.lockAssign.Lock()
+	mock.calls.Assign = append(mock.calls.Assign, callInfo)
+	mock.lockAssign.Unlock()
+	return mock.AssignFunc(ctx, credID, name)
+}
+
+// AssignCalls gets all the calls that were made to Assign.
+// Check the length with:
+//
+//	len(mockedRoleRepository.AssignCalls())
+func (mock *RoleRepositoryMock) AssignCalls() []struct {
+	Ctx    context.Context
+	CredID int
+	Name   string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		CredID int
+		Name   string
+	}
+	mock.lockAssign.RLock()
+	calls = mock.calls.Assign
+	mock.lockAssign.RUnlock()
+	return calls
+}
+
+// ByCredential calls ByCredentialFunc.
+func (mock *RoleRepositoryMock) ByCredential(ctx context.Context, credID int) ([]auth.Role, error) {
+	if mock.ByCredentialFunc == nil {
+		panic("RoleRepositoryMock.ByCredentialFunc: method is nil but RoleRepository.ByCredential was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		CredID int
+	}{
+		Ctx:    ctx,
+		CredID: credID,
+	}
+	mock.lockByCredential.Lock()
+	mock.calls.ByCredential = append(mock.calls.ByCredential, callInfo)
+	mock.lockByCredential.Unlock()
+	return mock.ByCredentialFunc(ctx, credID)
+}
+
+// ByCredentialCalls gets all the calls that were made to ByCredential.
+// Check the length with:
+//
+//	len(mockedRoleRepository.ByCredentialCalls())
+func (mock *RoleRepositoryMock) ByCredentialCalls() []struct {
+	Ctx    context.Context
+	CredID int
+} {
+	var calls []struct {
+		Ctx    context.Context
+		CredID int
+	}
+	mock.lockByCredential.RLock()
+	calls = mock.calls.ByCredential
+	mock.lockByCredential.RUnlock()
+	return calls
+}
+
+// Create calls CreateFunc.
+func (mock *RoleRepositoryMock) Create(ctx context.Context, r *auth.Role) error {
+	if mock.CreateFunc == nil {
+		panic("RoleRepositoryMock.CreateFunc: method is nil but RoleRepository.Create was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		R   *auth.Role
+	}{
+		Ctx: ctx,
+		R:   r,
+	}
+	mock.lockCreate.Lock()
+	mock.calls.Create = append(mock.calls.Create, callInfo)
+	mock.lockCreate.Unlock()
+	return mock.CreateFunc(ctx, r)
+}
+
+// CreateCalls gets all the calls that were made to Create.
+// Check the length with:
+//
+//	len(mockedRoleRepository.CreateCalls())
+func (mock *RoleRepositoryMock) CreateCalls() []struct {
+	Ctx context.Context
+	R   *auth.Role
+} {
+	var calls []struct {
+		Ctx context.Context
+		R   *auth.Role
+	}
+	mock.lockCreate.RLock()
+	calls = mock.calls.Create
+	mock.lockCreate.RUnlock()
+	return calls
+}
+
+// Delete calls DeleteFunc.
+func (mock *RoleRepositoryMock) Delete(ctx context.Context, name string) error {
+	if mock.DeleteFunc == nil {
+		panic("RoleRepositoryMock.DeleteFunc: method is nil but RoleRepository.Delete was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		Name string
+	}{
+		Ctx:  ctx,
+		Name: name,
+	}
+	mock.lockDelete.Lock()
+	mock.calls.Delete = append(mock.calls.Delete, callInfo)
+	mock.lockDelete.Unlock()
+	return mock.DeleteFunc(ctx, name)
+}
+
+// DeleteCalls gets all the calls that were made to Delete.
+// Check the length with:
+//
+//	len(mockedRoleRepository.DeleteCalls())
+func (mock *RoleRepositoryMock) DeleteCalls() []struct {
+	Ctx  context.Context
+	Name string
+} {
+	var calls []struct {
+		Ctx  context.Context
+		Name string
+	}
+	mock.lockDelete.RLock()
+	calls = mock.calls.Delete
+	mock.lockDelete.RUnlock()
+	return calls
+}
+
+// Roles calls RolesFunc.
+func (mock *RoleRepositoryMock) Roles(ctx context.Context) ([]auth.Role, error) {
+	if mock.RolesFunc == nil {
+		panic("RoleRepositoryMock.RolesFunc: method is nil but RoleRepository.Roles was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockRoles.Lock()
+	mock.calls.Roles = append(mock.calls.Roles, callInfo)
+	mock.lockRoles.Unlock()
+	return mock.RolesFunc(ctx)
+}
+
+// RolesCalls gets all the calls that were made to Roles.
+// Check the length with:
+//
+//	len(mockedRoleRepository.RolesCalls())
+func (mock *RoleRepositoryMock) RolesCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockRoles.RLock()
+	calls = mock.calls.Roles
+	mock.lockRoles.RUnlock()
+	return calls
+}
+
+// Unassign calls UnassignFunc.
+func (mock *RoleRepositoryMock) Unassign(ctx context.Context, credID int, name string) error {
+	if mock.UnassignFunc == nil {
+		panic("RoleRepositoryMock.UnassignFunc: method is nil but RoleRepository.Unassign was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		CredID int
+		Name   string
+	}{
+		Ctx:    ctx,
+		CredID: credID,
+		Name:   name,
+	}
+	mock.lockUnassign.Lock()
+	mock.calls.Unassign = append(mock.calls.Unassign, callInfo)
+	mock.lockUnassign.Unlock()
+	return mock.UnassignFunc(ctx, credID, name)
+}
+
+// UnassignCalls gets all the calls that were made to Unassign.
+// Check the length with:
+//
+//	len(mockedRoleRepository.UnassignCalls())
+func (mock *RoleRepositoryMock) UnassignCalls() []struct {
+	Ctx    context.Context
+	CredID int
+	Name   string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		CredID int
+		Name   string
+	}
+	mock.lockUnassign.RLock()
+	calls = mock.calls.Unassign
+	mock.lockUnassign.RUnlock()
+	return calls
+}
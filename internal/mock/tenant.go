@@ -0,0 +1,226 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mock
+
+import (
+	"context"
+	"github.com/kl09/auth-go"
+	"sync"
+)
+
+// Ensure, that TenantRepositoryMock does implement auth.TenantRepository.
+// If this is not the case, regenerate this file with moq.
+var _ auth.TenantRepository = &TenantRepositoryMock{}
+
+// TenantRepositoryMock is a mock implementation of auth.TenantRepository.
+//
+//	func TestSomethingThatUsesTenantRepository(t *testing.T) {
+//
+//		// make and configure a mocked auth.TenantRepository
+//		mockedTenantRepository := &TenantRepositoryMock{
+//			ByHostFunc: func(ctx context.Context, host string) (auth.Tenant, error) {
+//				panic("mock out the ByHost method")
+//			},
+//			ByIDFunc: func(ctx context.Context, id string) (auth.Tenant, error) {
+//				panic("mock out the ByID method")
+//			},
+//			SaveFunc: func(ctx context.Context, t *auth.Tenant) error {
+//				panic("mock out the Save method")
+//			},
+//			TenantsFunc: func(ctx context.Context) ([]auth.Tenant, error) {
+//				panic("mock out the Tenants method")
+//			},
+//		}
+//
+//		// use mockedTenantRepository in code that requires auth.TenantRepository
+//		// and then make assertions.
+//
+//	}
+type TenantRepositoryMock struct {
+	// ByHostFunc mocks the ByHost method.
+	ByHostFunc func(ctx context.Context, host string) (auth.Tenant, error)
+
+	// ByIDFunc mocks the ByID method.
+	ByIDFunc func(ctx context.Context, id string) (auth.Tenant, error)
+
+	// SaveFunc mocks the Save method.
+	SaveFunc func(ctx context.Context, t *auth.Tenant) error
+
+	// TenantsFunc mocks the Tenants method.
+	TenantsFunc func(ctx context.Context) ([]auth.Tenant, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// ByHost holds details about calls to the ByHost method.
+		ByHost []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Host is the host argument value.
+			Host string
+		}
+		// ByID holds details about calls to the ByID method.
+		ByID []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ID is the id argument value.
+			ID string
+		}
+		// Save holds details about calls to the Save method.
+		Save []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// T is the t argument value.
+			T *auth.Tenant
+		}
+		// Tenants holds details about calls to the Tenants method.
+		Tenants []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+	}
+	lockByHost  sync.RWMutex
+	lockByID    sync.RWMutex
+	lockSave    sync.RWMutex
+	lockTenants sync.RWMutex
+}
+
+// ByHost calls ByHostFunc.
+func (mock *TenantRepositoryMock) ByHost(ctx context.Context, host string) (auth.Tenant, error) {
+	if mock.ByHostFunc == nil {
+		panic("TenantRepositoryMock.ByHostFunc: method is nil but TenantRepository.ByHost was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		Host string
+	}{
+		Ctx:  ctx,
+		Host: host,
+	}
+	mock.lockByHost.Lock()
+	mock.calls.ByHost = append(mock.calls.ByHost, callInfo)
+	mock.lockByHost.Unlock()
+	return mock.ByHostFunc(ctx, host)
+}
+
+// ByHostCalls gets all the calls that were made to ByHost.
+// Check the length with:
+//
+//	len(mockedTenantRepository.ByHostCalls())
+func (mock *TenantRepositoryMock) ByHostCalls() []struct {
+	Ctx  context.Context
+	Host string
+} {
+	var calls []struct {
+		Ctx  context.Context
+		Host string
+	}
+	mock.lockByHost.RLock()
+	calls = mock.calls.ByHost
+	mock.lockByHost.RUnlock()
+	return calls
+}
+
+// ByID calls ByIDFunc.
+func (mock *TenantRepositoryMock) ByID(ctx context.Context, id string) (auth.Tenant, error) {
+	if mock.ByIDFunc == nil {
+		panic("TenantRepositoryMock.ByIDFunc: method is nil but TenantRepository.ByID was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		ID  string
+	}{
+		Ctx: ctx,
+		ID:  id,
+	}
+	mock.lockByID.Lock()
+	mock.calls.ByID = append(mock.calls.ByID, callInfo)
+	mock.lockByID.Unlock()
+	return mock.ByIDFunc(ctx, id)
+}
+
+// ByIDCalls gets all the calls that were made to ByID.
+// Check the length with:
+//
+//	len(mockedTenantRepository.ByIDCalls())
+func (mock *TenantRepositoryMock) ByIDCalls() []struct {
+	Ctx context.Context
+	ID  string
+} {
+	var calls []struct {
+		Ctx context.Context
+		ID  string
+	}
+	mock.lockByID.RLock()
+	calls = mock.calls.ByID
+	mock.lockByID.RUnlock()
+	return calls
+}
+
+// Save calls SaveFunc.
+func (mock *TenantRepositoryMock) Save(ctx context.Context, t *auth.Tenant) error {
+	if mock.SaveFunc == nil {
+		panic("TenantRepositoryMock.SaveFunc: method is nil but TenantRepository.Save was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		T   *auth.Tenant
+	}{
+		Ctx: ctx,
+		T:   t,
+	}
+	mock.lockSave.Lock()
+	mock.calls.Save = append(mock.calls.Save, callInfo)
+	mock.lockSave.Unlock()
+	return mock.SaveFunc(ctx, t)
+}
+
+// SaveCalls gets all the calls that were made to Save.
+// Check the length with:
+//
+//	len(mockedTenantRepository.SaveCalls())
+func (mock *TenantRepositoryMock) SaveCalls() []struct {
+	Ctx context.Context
+	T   *auth.Tenant
+} {
+	var calls []struct {
+		Ctx context.Context
+		T   *auth.Tenant
+	}
+	mock.lockSave.RLock()
+	calls = mock.calls.Save
+	mock.lockSave.RUnlock()
+	return calls
+}
+
+// Tenants calls TenantsFunc.
+func (mock *TenantRepositoryMock) Tenants(ctx context.Context) ([]auth.Tenant, error) {
+	if mock.TenantsFunc == nil {
+		panic("TenantRepositoryMock.TenantsFunc: method is nil but TenantRepository.Tenants was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockTenants.Lock()
+	mock.calls.Tenants = append(mock.calls.Tenants, callInfo)
+	mock.lockTenants.Unlock()
+	return mock.TenantsFunc(ctx)
+}
+
+// TenantsCalls gets all the calls that were made to Tenants.
+// Check the length with:
+//
+//	len(mockedTenantRepository.TenantsCalls())
+func (mock *TenantRepositoryMock) TenantsCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockTenants.RLock()
+	calls = mock.calls.Tenants
+	mock.lockTenants.RUnlock()
+	return calls
+}
@@ -0,0 +1,192 @@
+package password
+
+import (
+	"crypto/md5" //nolint:gosec // imported legacy hashes are only verified
+	"crypto/pbkdf2"
+	"crypto/sha1" //nolint:gosec // imported legacy hashes are only verified
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Legacy algorithms of hashes imported from other systems,
+// they are only verified and always rehashed with the configured algorithm.
+const (
+	PBKDF2SHA256 = "pbkdf2-sha256"
+	SaltedMD5    = "md5"
+	SaltedSHA1   = "sha1"
+)
+
+// Positions of the salt in salted MD5 and SHA1 hashes.
+const (
+	// SaltPrefix hashes the salt followed by the password.
+	SaltPrefix = "prefix"
+	// SaltSuffix hashes the password followed by the salt.
+	SaltSuffix = "suffix"
+)
+
+// maxPBKDF2Iterations limits the iterations of imported PBKDF2 hashes, so a bad import can't stall logins.
+const maxPBKDF2Iterations = 10_000_000
+
+// Foreign is a hash made by another system.
+type Foreign struct {
+	// Algorithm is one of the legacy algorithms, Bcrypt for hashes in the modular crypt format, e.g. $2a$10$...,
+	// or Argon2id and Scrypt for hashes in the PHC string format, e.g. $scrypt$ln=15,r=8,p=1$salt$key.
+	Algorithm string
+	// Hash is the digest or the derived key, the whole hash for Bcrypt, Argon2id and Scrypt.
+	Hash []byte
+	Salt []byte
+	// Iterations is the number of iterations of PBKDF2.
+	Iterations int
+	// SaltPosition is the position of the salt in salted MD5 and SHA1, SaltPrefix by default.
+	SaltPosition string
+}
+
+// Encode converts the foreign hash to the format verified by Hasher, e.g. $pbkdf2-sha256$i=600000$salt$key.
+func Encode(f Foreign) (string, error) {
+	switch f.Algorithm {
+	case Bcrypt:
+		if !strings.HasPrefix(string(f.Hash), "$2") {
+			return "", fmt.Errorf("bad bcrypt hash, the modular crypt format expected")
+		}
+
+		cost, err := bcrypt.Cost(f.Hash)
+		if err != nil {
+			return "", fmt.Errorf("bad bcrypt hash: %w", err)
+		}
+
+		if cost > maxBcryptCost {
+			return "", fmt.Errorf("bad bcrypt cost %d", cost)
+		}
+
+		return string(f.Hash), nil
+	case Argon2id, Scrypt:
+		return encodePHC(f.Algorithm, string(f.Hash))
+	case PBKDF2SHA256:
+		if f.Iterations < 1 || f.Iterations > maxPBKDF2Iterations {
+			return "", fmt.Errorf("bad pbkdf2 iterations %d", f.Iterations)
+		}
+
+		if len(f.Hash) < 16 {
+			return "", fmt.Errorf("bad pbkdf2 key length %d", len(f.Hash))
+		}
+
+		return fmt.Sprintf("$%s$i=%d$%s$%s",
+			PBKDF2SHA256, f.Iterations, b64.EncodeToString(f.Salt), b64.EncodeToString(f.Hash)), nil
+	case SaltedMD5, SaltedSHA1:
+		if f.SaltPosition == "" {
+			f.SaltPosition = SaltPrefix
+		}
+
+		if f.SaltPosition != SaltPrefix && f.SaltPosition != SaltSuffix {
+			return "", fmt.Errorf("bad salt position %q", f.SaltPosition)
+		}
+
+		if len(f.Hash) != saltedHash(f.Algorithm)().Size() {
+			return "", fmt.Errorf("bad %s digest length %d", f.Algorithm, len(f.Hash))
+		}
+
+		return fmt.Sprintf("$%s$s=%s$%s$%s",
+			f.Algorithm, f.SaltPosition, b64.EncodeToString(f.Salt), b64.EncodeToString(f.Hash)), nil
+	default:
+		return "", fmt.Errorf("unknown algorithm %q", f.Algorithm)
+	}
+}
+
+// encodePHC checks an argon2id or scrypt hash in the PHC string format, its parameters must be within the limits
+// of verified hashes.
+func encodePHC(algorithm, hash string) (string, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) < 3 || parts[0] != "" || parts[1] != algorithm {
+		return "", fmt.Errorf("bad %s hash, the PHC string format expected", algorithm)
+	}
+
+	if algorithm == Argon2id {
+		if parts[2] != "v="+strconv.Itoa(argon2.Version) {
+			return "", fmt.Errorf("bad argon2id version %q", parts[2])
+		}
+
+		parts = append(parts[:2], parts[3:]...)
+	}
+
+	params, ok := parseParams(parts[2])
+	if !ok || len(parts) != 5 {
+		return "", fmt.Errorf("bad %s hash, the PHC string format expected", algorithm)
+	}
+
+	if _, ok = params["keyid"]; ok {
+		return "", fmt.Errorf("bad %s hash, peppered hashes can't be imported", algorithm)
+	}
+
+	if algorithm == Argon2id {
+		_, _, _, ok = argon2idParams(params)
+	} else {
+		_, _, _, ok = scryptParams(params)
+	}
+
+	if !ok {
+		return "", fmt.Errorf("bad %s parameters %q", algorithm, parts[2])
+	}
+
+	if _, _, ok = saltAndKey(parts); !ok {
+		return "", fmt.Errorf("bad %s salt or key", algorithm)
+	}
+
+	return hash, nil
+}
+
+func verifyPBKDF2(parts []string, params map[string]string, pwd string) bool {
+	salt, key, ok := saltAndKey(parts)
+	if !ok {
+		return false
+	}
+
+	iter, err := strconv.Atoi(params["i"])
+	if err != nil || iter < 1 || iter > maxPBKDF2Iterations {
+		return false
+	}
+
+	got, err := pbkdf2.Key(sha256.New, pwd, salt, iter, len(key))
+	if err != nil {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(got, key) == 1
+}
+
+func verifySalted(algorithm string, parts []string, params map[string]string, pwd string) bool {
+	salt, digest, ok := saltAndKey(parts)
+	if !ok {
+		return false
+	}
+
+	h := saltedHash(algorithm)()
+
+	switch params["s"] {
+	case SaltPrefix:
+		h.Write(salt)
+		h.Write([]byte(pwd))
+	case SaltSuffix:
+		h.Write([]byte(pwd))
+		h.Write(salt)
+	default:
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(h.Sum(nil), digest) == 1
+}
+
+// saltedHash returns the hash function of salted MD5 or SHA1.
+func saltedHash(algorithm string) func() hash.Hash {
+	if algorithm == SaltedMD5 {
+		return md5.New
+	}
+
+	return sha1.New
+}
@@ -0,0 +1,188 @@
+package password_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/kl09/auth-go/internal/password"
+)
+
+func TestEncode_Verify(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("12345"), bcrypt.MinCost)
+	require.Nil(t, err)
+
+	argon2id := hashWith(t, password.WithCost(2), password.WithMemory(1024))
+	scrypt := hashWith(t, password.WithAlgorithm(password.Scrypt), password.WithCost(10))
+
+	cases := []struct {
+		name     string
+		foreign  password.Foreign
+		wantHash string
+	}{
+		{
+			name: "pbkdf2-sha256",
+			foreign: password.Foreign{
+				Algorithm:  password.PBKDF2SHA256,
+				Hash:       mustHex(t, "ca7afb8c4555ea1faa0e152e411b522549d84dc7d59036ab3a9b72e5d88658e4"),
+				Salt:       []byte("salt"),
+				Iterations: 1000,
+			},
+			wantHash: "$pbkdf2-sha256$i=1000$c2FsdA$ynr7jEVV6h+qDhUuQRtSJUnYTcfVkDarOpty5diGWOQ",
+		},
+		{
+			name: "salted md5",
+			foreign: password.Foreign{
+				Algorithm: password.SaltedMD5,
+				Hash:      mustHex(t, "527ca2d6f2e1fc83ec00a43e088d29ce"),
+				Salt:      []byte("pepper"),
+			},
+			wantHash: "$md5$s=prefix$cGVwcGVy$Unyi1vLh/IPsAKQ+CI0pzg",
+		},
+		{
+			name: "salted sha1 with the salt suffix",
+			foreign: password.Foreign{
+				Algorithm:    password.SaltedSHA1,
+				Hash:         mustHex(t, "22025a9023c70d3763a94ec865beb222ca241974"),
+				Salt:         []byte("pepper"),
+				SaltPosition: password.SaltSuffix,
+			},
+			wantHash: "$sha1$s=suffix$cGVwcGVy$IgJakCPHDTdjqU7IZb6yIsokGXQ",
+		},
+		{
+			name:     "bcrypt",
+			foreign:  password.Foreign{Algorithm: password.Bcrypt, Hash: legacy},
+			wantHash: string(legacy),
+		},
+		{
+			name:     "argon2id",
+			foreign:  password.Foreign{Algorithm: password.Argon2id, Hash: []byte(argon2id)},
+			wantHash: argon2id,
+		},
+		{
+			name:     "scrypt",
+			foreign:  password.Foreign{Algorithm: password.Scrypt, Hash: []byte(scrypt)},
+			wantHash: scrypt,
+		},
+	}
+
+	h, err := password.New(password.WithCost(1), password.WithMemory(1024))
+	require.Nil(t, err)
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			hash, err := password.Encode(tc.foreign)
+			require.Nil(t, err)
+			assert.Equal(t, tc.wantHash, hash)
+
+			ok, rehash := h.Verify(hash, "12345")
+			assert.True(t, ok)
+			assert.True(t, rehash)
+
+			ok, rehash = h.Verify(hash, "54321")
+			assert.False(t, ok)
+			assert.False(t, rehash)
+		})
+	}
+}
+
+func TestEncode_Errors(t *testing.T) {
+	cases := []struct {
+		name    string
+		foreign password.Foreign
+		wantErr string
+	}{
+		{
+			name:    "unknown algorithm",
+			foreign: password.Foreign{Algorithm: "crypt"},
+			wantErr: `unknown algorithm "crypt"`,
+		},
+		{
+			name:    "no pbkdf2 iterations",
+			foreign: password.Foreign{Algorithm: password.PBKDF2SHA256, Hash: make([]byte, 32)},
+			wantErr: "bad pbkdf2 iterations 0",
+		},
+		{
+			name:    "bad md5 digest",
+			foreign: password.Foreign{Algorithm: password.SaltedMD5, Hash: make([]byte, 20)},
+			wantErr: "bad md5 digest length 20",
+		},
+		{
+			name:    "bad salt position",
+			foreign: password.Foreign{Algorithm: password.SaltedSHA1, Hash: make([]byte, 20), SaltPosition: "middle"},
+			wantErr: `bad salt position "middle"`,
+		},
+		{
+			name:    "bcrypt not in the modular crypt format",
+			foreign: password.Foreign{Algorithm: password.Bcrypt, Hash: []byte("12345")},
+			wantErr: "bad bcrypt hash, the modular crypt format expected",
+		},
+		{
+			name:    "bcrypt cost over the limit",
+			foreign: password.Foreign{Algorithm: password.Bcrypt, Hash: []byte("$2a$31$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy")},
+			wantErr: "bad bcrypt cost 31",
+		},
+		{
+			name:    "argon2id not in the PHC string format",
+			foreign: password.Foreign{Algorithm: password.Argon2id, Hash: []byte("$scrypt$ln=10,r=8,p=1$c2FsdA$a2V5")},
+			wantErr: "bad argon2id hash, the PHC string format expected",
+		},
+		{
+			name:    "argon2id of an unknown version",
+			foreign: password.Foreign{Algorithm: password.Argon2id, Hash: []byte("$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$a2V5")},
+			wantErr: `bad argon2id version "v=16"`,
+		},
+		{
+			name:    "argon2id memory over the limit",
+			foreign: password.Foreign{Algorithm: password.Argon2id, Hash: []byte("$argon2id$v=19$m=4194304,t=1,p=1$c2FsdA$a2V5")},
+			wantErr: `bad argon2id parameters "m=4194304,t=1,p=1"`,
+		},
+		{
+			name:    "scrypt N over the limit",
+			foreign: password.Foreign{Algorithm: password.Scrypt, Hash: []byte("$scrypt$ln=30,r=8,p=1$c2FsdA$a2V5")},
+			wantErr: `bad scrypt parameters "ln=30,r=8,p=1"`,
+		},
+		{
+			name:    "peppered scrypt",
+			foreign: password.Foreign{Algorithm: password.Scrypt, Hash: []byte("$scrypt$ln=10,r=8,p=1,keyid=pepper$c2FsdA$a2V5")},
+			wantErr: "bad scrypt hash, peppered hashes can't be imported",
+		},
+		{
+			name:    "scrypt without a key",
+			foreign: password.Foreign{Algorithm: password.Scrypt, Hash: []byte("$scrypt$ln=10,r=8,p=1$c2FsdA$")},
+			wantErr: "bad scrypt salt or key",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := password.Encode(tc.foreign)
+			assert.EqualError(t, err, tc.wantErr)
+		})
+	}
+}
+
+// hashWith hashes 12345 with a Hasher of the options.
+func hashWith(t *testing.T, options ...password.Option) string {
+	t.Helper()
+
+	h, err := password.New(options...)
+	require.Nil(t, err)
+
+	hash, err := h.Hash("12345")
+	require.Nil(t, err)
+
+	return hash
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+
+	b, err := hex.DecodeString(s)
+	require.Nil(t, err)
+
+	return b
+}
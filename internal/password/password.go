@@ -0,0 +1,409 @@
+// Package password hashes passwords with argon2id, bcrypt or scrypt into the PHC string format
+// and verifies them, including legacy bcrypt hashes and hashes imported from other systems.
+package password
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Supported algorithms.
+const (
+	Argon2id = "argon2id"
+	Bcrypt   = "bcrypt"
+	Scrypt   = "scrypt"
+)
+
+// Default costs of the algorithms.
+const (
+	// DefaultArgon2idTime is the number of iterations of argon2id.
+	DefaultArgon2idTime = 3
+	// DefaultArgon2idMemory is the memory of argon2id in KiB.
+	DefaultArgon2idMemory = 64 * 1024
+	// DefaultArgon2idThreads is the parallelism of argon2id.
+	DefaultArgon2idThreads = 2
+	// DefaultBcryptCost is the log2 of the number of rounds of bcrypt.
+	DefaultBcryptCost = 12
+	// DefaultScryptCost is the log2 of the N parameter of scrypt.
+	DefaultScryptCost = 15
+)
+
+// Limits of parameters of new and verified hashes, so a bad import can't exhaust memory or stall logins.
+const (
+	maxBcryptCost      = 16
+	maxArgon2idTime    = 32
+	maxArgon2idMemory  = 1024 * 1024 // KiB
+	maxArgon2idThreads = 16
+	maxScryptCost      = 20
+	maxScryptR         = 32
+	maxScryptP         = 16
+	// maxScryptMemory limits the 128 * r * N bytes used by scrypt.
+	maxScryptMemory = 1 << 30
+)
+
+const (
+	saltLength = 16
+	keyLength  = 32
+	scryptR    = 8
+	scryptP    = 1
+	// pepperKeyID is the keyid parameter of hashes of peppered passwords.
+	pepperKeyID = "pepper"
+)
+
+var b64 = base64.RawStdEncoding
+
+// Hasher hashes passwords with the configured algorithm and cost and verifies hashes of all supported algorithms.
+type Hasher struct {
+	algorithm string
+	// cost is the time of argon2id, the log2 of rounds of bcrypt or the log2 of N of scrypt.
+	cost    int
+	memory  uint32
+	threads uint8
+	// pepper is a server-side secret mixed into every password, it must never change.
+	pepper []byte
+}
+
+// Option configures the Hasher.
+type Option func(*Hasher)
+
+// WithAlgorithm configures the algorithm of new hashes, argon2id is used by default.
+func WithAlgorithm(algorithm string) Option {
+	return func(h *Hasher) {
+		h.algorithm = algorithm
+	}
+}
+
+// WithCost configures the cost of new hashes, zero keeps the default cost of the algorithm.
+func WithCost(cost int) Option {
+	return func(h *Hasher) {
+		h.cost = cost
+	}
+}
+
+// WithMemory configures the memory of argon2id in KiB, zero keeps the default memory.
+func WithMemory(kib uint32) Option {
+	return func(h *Hasher) {
+		if kib != 0 {
+			h.memory = kib
+		}
+	}
+}
+
+// WithPepper configures a server-side secret mixed into passwords with HMAC-SHA256,
+// hashes made without the pepper are still verified and rehashed.
+func WithPepper(pepper []byte) Option {
+	return func(h *Hasher) {
+		h.pepper = pepper
+	}
+}
+
+// New creates a Hasher.
+func New(options ...Option) (*Hasher, error) {
+	h := &Hasher{
+		algorithm: Argon2id,
+		memory:    DefaultArgon2idMemory,
+		threads:   DefaultArgon2idThreads,
+	}
+
+	for _, opt := range options {
+		opt(h)
+	}
+
+	if h.cost == 0 {
+		switch h.algorithm {
+		case Argon2id:
+			h.cost = DefaultArgon2idTime
+		case Bcrypt:
+			h.cost = DefaultBcryptCost
+		case Scrypt:
+			h.cost = DefaultScryptCost
+		}
+	}
+
+	switch h.algorithm {
+	case Argon2id:
+		if h.cost < 1 || h.cost > maxArgon2idTime || h.memory < 8*uint32(h.threads) || h.memory > maxArgon2idMemory {
+			return nil, fmt.Errorf("bad argon2id cost %d or memory %d", h.cost, h.memory)
+		}
+	case Bcrypt:
+		if h.cost < bcrypt.MinCost || h.cost > maxBcryptCost {
+			return nil, fmt.Errorf("bad bcrypt cost %d", h.cost)
+		}
+	case Scrypt:
+		if h.cost < 1 || h.cost > maxScryptCost {
+			return nil, fmt.Errorf("bad scrypt cost %d", h.cost)
+		}
+	default:
+		return nil, fmt.Errorf("unknown algorithm %q", h.algorithm)
+	}
+
+	return h, nil
+}
+
+// Hash hashes the password with a random salt.
+func (h *Hasher) Hash(pwd string) (string, error) {
+	salt := make([]byte, saltLength)
+
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	peppered := len(h.pepper) > 0
+
+	params := h.params()
+	if peppered {
+		params += ",keyid=" + pepperKeyID
+	}
+
+	switch h.algorithm {
+	case Bcrypt:
+		// bcrypt generates its own salt.
+		b, err := bcrypt.GenerateFromPassword(h.bcryptInput(pwd, peppered), h.cost)
+		if err != nil {
+			return "", err
+		}
+
+		return "$bcrypt$" + params + "$" + bcryptTail(b), nil
+	case Scrypt:
+		key, err := scrypt.Key(h.input(pwd, peppered), salt, 1<<h.cost, scryptR, scryptP, keyLength)
+		if err != nil {
+			return "", err
+		}
+
+		return "$scrypt$" + params + "$" + b64.EncodeToString(salt) + "$" + b64.EncodeToString(key), nil
+	default:
+		key := argon2.IDKey(h.input(pwd, peppered), salt, uint32(h.cost), h.memory, h.threads, keyLength)
+
+		return fmt.Sprintf("$argon2id$v=%d$%s$%s$%s",
+			argon2.Version, params, b64.EncodeToString(salt), b64.EncodeToString(key)), nil
+	}
+}
+
+// Verify compares the password with the hash,
+// rehash reports that the hash uses an outdated algorithm, cost or pepper and should be replaced.
+func (h *Hasher) Verify(hash, pwd string) (ok, rehash bool) {
+	// Legacy bcrypt hashes in the modular crypt format, e.g. $2a$10$...
+	if strings.HasPrefix(hash, "$2") {
+		if cost, err := bcrypt.Cost([]byte(hash)); err != nil || cost > maxBcryptCost {
+			return false, false
+		}
+
+		ok = bcrypt.CompareHashAndPassword([]byte(hash), []byte(pwd)) == nil
+		return ok, ok
+	}
+
+	parts := strings.Split(hash, "$")
+	if len(parts) < 4 || parts[0] != "" {
+		return false, false
+	}
+
+	algorithm := parts[1]
+
+	if algorithm == Argon2id {
+		if parts[2] != "v="+strconv.Itoa(argon2.Version) {
+			return false, false
+		}
+
+		// The version is the only argon2id parameter before the costs.
+		parts = append(parts[:2], parts[3:]...)
+	}
+
+	costs, peppered := strings.CutSuffix(parts[2], ",keyid="+pepperKeyID)
+	if peppered && len(h.pepper) == 0 {
+		return false, false
+	}
+
+	params, ok := parseParams(costs)
+	if !ok {
+		return false, false
+	}
+
+	switch algorithm {
+	case Argon2id:
+		ok = h.verifyArgon2id(parts, params, pwd, peppered)
+	case Scrypt:
+		ok = h.verifyScrypt(parts, params, pwd, peppered)
+	case Bcrypt:
+		ok = h.verifyBcrypt(parts, params, pwd, peppered)
+	case PBKDF2SHA256:
+		ok = !peppered && verifyPBKDF2(parts, params, pwd)
+	case SaltedMD5, SaltedSHA1:
+		ok = !peppered && verifySalted(algorithm, parts, params, pwd)
+	default:
+		return false, false
+	}
+
+	if !ok {
+		return false, false
+	}
+
+	return true, algorithm != h.algorithm || costs != h.params() || peppered != (len(h.pepper) > 0)
+}
+
+// params returns the costs of new hashes in the PHC format.
+func (h *Hasher) params() string {
+	switch h.algorithm {
+	case Bcrypt:
+		return fmt.Sprintf("r=%d", h.cost)
+	case Scrypt:
+		return fmt.Sprintf("ln=%d,r=%d,p=%d", h.cost, scryptR, scryptP)
+	default:
+		return fmt.Sprintf("m=%d,t=%d,p=%d", h.memory, h.cost, h.threads)
+	}
+}
+
+func (h *Hasher) verifyArgon2id(parts []string, params map[string]string, pwd string, peppered bool) bool {
+	salt, key, ok := saltAndKey(parts)
+	if !ok {
+		return false
+	}
+
+	t, m, p, ok := argon2idParams(params)
+	if !ok {
+		return false
+	}
+
+	got := argon2.IDKey(h.input(pwd, peppered), salt, t, m, p, uint32(len(key)))
+
+	return subtle.ConstantTimeCompare(got, key) == 1
+}
+
+func (h *Hasher) verifyScrypt(parts []string, params map[string]string, pwd string, peppered bool) bool {
+	salt, key, ok := saltAndKey(parts)
+	if !ok {
+		return false
+	}
+
+	n, r, p, ok := scryptParams(params)
+	if !ok {
+		return false
+	}
+
+	got, err := scrypt.Key(h.input(pwd, peppered), salt, n, r, p, len(key))
+	if err != nil {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(got, key) == 1
+}
+
+// argon2idParams parses the time, memory and threads of an argon2id hash, ok is false if they are over the limits.
+func argon2idParams(params map[string]string) (t, m uint32, p uint8, ok bool) {
+	t64, errT := strconv.ParseUint(params["t"], 10, 32)
+	m64, errM := strconv.ParseUint(params["m"], 10, 32)
+	p64, errP := strconv.ParseUint(params["p"], 10, 8)
+
+	if errT != nil || errM != nil || errP != nil ||
+		t64 < 1 || t64 > maxArgon2idTime ||
+		p64 < 1 || p64 > maxArgon2idThreads ||
+		m64 < 8*p64 || m64 > maxArgon2idMemory {
+		return 0, 0, 0, false
+	}
+
+	return uint32(t64), uint32(m64), uint8(p64), true
+}
+
+// scryptParams parses N, r and p of a scrypt hash, ok is false if they are over the limits.
+func scryptParams(params map[string]string) (n, r, p int, ok bool) {
+	ln, errN := strconv.Atoi(params["ln"])
+	r, errR := strconv.Atoi(params["r"])
+	p, errP := strconv.Atoi(params["p"])
+
+	if errN != nil || errR != nil || errP != nil ||
+		ln < 1 || ln > maxScryptCost ||
+		r < 1 || r > maxScryptR ||
+		p < 1 || p > maxScryptP ||
+		128*r<<ln > maxScryptMemory {
+		return 0, 0, 0, false
+	}
+
+	return 1 << ln, r, p, true
+}
+
+func (h *Hasher) verifyBcrypt(parts []string, params map[string]string, pwd string, peppered bool) bool {
+	cost, err := strconv.Atoi(params["r"])
+	if err != nil || cost < bcrypt.MinCost || cost > maxBcryptCost || len(parts) != 4 {
+		return false
+	}
+
+	mcf := fmt.Sprintf("$2a$%02d$%s", cost, parts[3])
+
+	return bcrypt.CompareHashAndPassword([]byte(mcf), h.bcryptInput(pwd, peppered)) == nil
+}
+
+// input returns the password mixed with the pepper if the hash is peppered.
+func (h *Hasher) input(pwd string, peppered bool) []byte {
+	if !peppered {
+		return []byte(pwd)
+	}
+
+	mac := hmac.New(sha256.New, h.pepper)
+	mac.Write([]byte(pwd))
+
+	return mac.Sum(nil)
+}
+
+// bcryptInput pre-hashes the password, so bcrypt doesn't truncate passwords longer than 72 bytes,
+// the digest is encoded as bcrypt stops at a zero byte.
+func (h *Hasher) bcryptInput(pwd string, peppered bool) []byte {
+	digest := h.input(pwd, peppered)
+	if !peppered {
+		sum := sha256.Sum256(digest)
+		digest = sum[:]
+	}
+
+	return []byte(base64.StdEncoding.EncodeToString(digest))
+}
+
+// bcryptTail returns the salt and the hash of a bcrypt hash in the modular crypt format.
+func bcryptTail(b []byte) string {
+	s := string(b)
+
+	return s[strings.LastIndex(s, "$")+1:]
+}
+
+// parseParams parses comma separated name=value parameters.
+func parseParams(s string) (map[string]string, bool) {
+	params := make(map[string]string)
+
+	for _, p := range strings.Split(s, ",") {
+		name, value, ok := strings.Cut(p, "=")
+		if !ok {
+			return nil, false
+		}
+
+		params[name] = value
+	}
+
+	return params, true
+}
+
+// saltAndKey decodes the salt and the key of parts of a hash without the version.
+func saltAndKey(parts []string) (salt, key []byte, ok bool) {
+	if len(parts) != 5 {
+		return nil, nil, false
+	}
+
+	salt, err := b64.DecodeString(parts[3])
+	if err != nil {
+		return nil, nil, false
+	}
+
+	key, err = b64.DecodeString(parts[4])
+	if err != nil || len(key) == 0 {
+		return nil, nil, false
+	}
+
+	return salt, key, true
+}
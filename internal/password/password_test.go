@@ -0,0 +1,212 @@
+package password_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/kl09/auth-go/internal/password"
+)
+
+func TestHasher_HashVerify(t *testing.T) {
+	cases := []struct {
+		name    string
+		options []password.Option
+		prefix  string
+	}{
+		{
+			name:    "argon2id",
+			options: []password.Option{password.WithCost(1), password.WithMemory(1024)},
+			prefix:  "$argon2id$v=19$m=1024,t=1,p=2$",
+		},
+		{
+			name:    "argon2id with pepper",
+			options: []password.Option{password.WithCost(1), password.WithMemory(1024), password.WithPepper([]byte("pepper"))},
+			prefix:  "$argon2id$v=19$m=1024,t=1,p=2,keyid=pepper$",
+		},
+		{
+			name:    "bcrypt",
+			options: []password.Option{password.WithAlgorithm(password.Bcrypt), password.WithCost(bcrypt.MinCost)},
+			prefix:  "$bcrypt$r=4$",
+		},
+		{
+			name: "bcrypt with pepper",
+			options: []password.Option{
+				password.WithAlgorithm(password.Bcrypt), password.WithCost(bcrypt.MinCost), password.WithPepper([]byte("pepper")),
+			},
+			prefix: "$bcrypt$r=4,keyid=pepper$",
+		},
+		{
+			name:    "scrypt",
+			options: []password.Option{password.WithAlgorithm(password.Scrypt), password.WithCost(10)},
+			prefix:  "$scrypt$ln=10,r=8,p=1$",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h, err := password.New(tc.options...)
+			require.Nil(t, err)
+
+			// Longer than 72 bytes which bcrypt truncates.
+			pwd := strings.Repeat("p", 80)
+
+			hash, err := h.Hash(pwd)
+			require.Nil(t, err)
+			assert.True(t, strings.HasPrefix(hash, tc.prefix), hash)
+
+			ok, rehash := h.Verify(hash, pwd)
+			assert.True(t, ok)
+			assert.False(t, rehash)
+
+			ok, _ = h.Verify(hash, strings.Repeat("p", 79)+"q")
+			assert.False(t, ok)
+
+			other, err := h.Hash(pwd)
+			require.Nil(t, err)
+			assert.NotEqual(t, hash, other)
+		})
+	}
+}
+
+func TestHasher_Rehash(t *testing.T) {
+	old, err := password.New(password.WithAlgorithm(password.Bcrypt), password.WithCost(bcrypt.MinCost))
+	require.Nil(t, err)
+
+	legacy, err := bcrypt.GenerateFromPassword([]byte("12345"), bcrypt.MinCost)
+	require.Nil(t, err)
+
+	oldHash, err := old.Hash("12345")
+	require.Nil(t, err)
+
+	cases := []struct {
+		name       string
+		options    []password.Option
+		hash       string
+		wantOK     bool
+		wantRehash bool
+	}{
+		{
+			name:       "same",
+			options:    []password.Option{password.WithAlgorithm(password.Bcrypt), password.WithCost(bcrypt.MinCost)},
+			hash:       oldHash,
+			wantOK:     true,
+			wantRehash: false,
+		},
+		{
+			name:       "legacy bcrypt",
+			options:    []password.Option{password.WithAlgorithm(password.Bcrypt), password.WithCost(bcrypt.MinCost)},
+			hash:       string(legacy),
+			wantOK:     true,
+			wantRehash: true,
+		},
+		{
+			name:       "outdated cost",
+			options:    []password.Option{password.WithAlgorithm(password.Bcrypt), password.WithCost(bcrypt.MinCost + 1)},
+			hash:       oldHash,
+			wantOK:     true,
+			wantRehash: true,
+		},
+		{
+			name:       "outdated algorithm",
+			options:    []password.Option{password.WithCost(1), password.WithMemory(1024)},
+			hash:       oldHash,
+			wantOK:     true,
+			wantRehash: true,
+		},
+		{
+			name: "pepper added",
+			options: []password.Option{
+				password.WithAlgorithm(password.Bcrypt), password.WithCost(bcrypt.MinCost), password.WithPepper([]byte("pepper")),
+			},
+			hash:       oldHash,
+			wantOK:     true,
+			wantRehash: true,
+		},
+		{
+			name:    "malformed",
+			options: []password.Option{password.WithCost(1), password.WithMemory(1024)},
+			hash:    "$argon2id$v=19$m=1024$salt",
+			wantOK:  false,
+		},
+		{
+			name:    "unknown algorithm",
+			options: []password.Option{password.WithCost(1), password.WithMemory(1024)},
+			hash:    "$md5$r=1$salt$hash",
+			wantOK:  false,
+		},
+		{
+			name:    "legacy bcrypt cost over the limit",
+			options: []password.Option{password.WithCost(1), password.WithMemory(1024)},
+			hash:    "$2a$31$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy",
+			wantOK:  false,
+		},
+		{
+			name:    "bcrypt cost over the limit",
+			options: []password.Option{password.WithCost(1), password.WithMemory(1024)},
+			hash:    "$bcrypt$r=31$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy",
+			wantOK:  false,
+		},
+		{
+			name:    "argon2id memory over the limit",
+			options: []password.Option{password.WithCost(1), password.WithMemory(1024)},
+			hash:    "$argon2id$v=19$m=4194304,t=1,p=1$c2FsdA$a2V5",
+			wantOK:  false,
+		},
+		{
+			name:    "argon2id time over the limit",
+			options: []password.Option{password.WithCost(1), password.WithMemory(1024)},
+			hash:    "$argon2id$v=19$m=1024,t=1000000,p=1$c2FsdA$a2V5",
+			wantOK:  false,
+		},
+		{
+			name:    "scrypt N over the limit",
+			options: []password.Option{password.WithCost(1), password.WithMemory(1024)},
+			hash:    "$scrypt$ln=30,r=8,p=1$c2FsdA$a2V5",
+			wantOK:  false,
+		},
+		{
+			name:    "scrypt memory over the limit",
+			options: []password.Option{password.WithCost(1), password.WithMemory(1024)},
+			hash:    "$scrypt$ln=20,r=32,p=1$c2FsdA$a2V5",
+			wantOK:  false,
+		},
+		{
+			name:    "scrypt p over the limit",
+			options: []password.Option{password.WithCost(1), password.WithMemory(1024)},
+			hash:    "$scrypt$ln=10,r=8,p=1000000$c2FsdA$a2V5",
+			wantOK:  false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h, err := password.New(tc.options...)
+			require.Nil(t, err)
+
+			ok, rehash := h.Verify(tc.hash, "12345")
+			assert.Equal(t, tc.wantOK, ok)
+			assert.Equal(t, tc.wantRehash, rehash)
+		})
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	_, err := password.New(password.WithAlgorithm("md5"))
+	assert.EqualError(t, err, `unknown algorithm "md5"`)
+
+	_, err = password.New(password.WithAlgorithm(password.Bcrypt), password.WithCost(40))
+	assert.EqualError(t, err, "bad bcrypt cost 40")
+
+	_, err = password.New(password.WithAlgorithm(password.Bcrypt), password.WithCost(17))
+	assert.EqualError(t, err, "bad bcrypt cost 17")
+
+	_, err = password.New(password.WithAlgorithm(password.Scrypt), password.WithCost(21))
+	assert.EqualError(t, err, "bad scrypt cost 21")
+
+	_, err = password.New(password.WithMemory(4 * 1024 * 1024))
+	assert.EqualError(t, err, "bad argon2id cost 3 or memory 4194304")
+}
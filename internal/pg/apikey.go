@@ -0,0 +1,199 @@
+package pg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	auth "github.com/kl09/auth-go"
+)
+
+// APIKeyRepository is a repository for API keys of credentials.
+type APIKeyRepository struct {
+	*Client
+}
+
+// NewAPIKeyRepository creates a new APIKeyRepository.
+func NewAPIKeyRepository(c *Client) *APIKeyRepository {
+	return &APIKeyRepository{
+		c,
+	}
+}
+
+// scope is the JSON representation of a scope of an API key.
+type scope struct {
+	Action   string `json:"action"`
+	Resource string `json:"resource"`
+}
+
+// Create creates a new APIKey of a Credential of the tenant of the context.
+func (r *APIKeyRepository) Create(ctx context.Context, k *auth.APIKey) error {
+	scopes := make([]scope, 0, len(k.Scopes))
+	for _, s := range k.Scopes {
+		scopes = append(scopes, scope(s))
+	}
+
+	b, err := json.Marshal(scopes)
+	if err != nil {
+		return err
+	}
+
+	ctx, done := r.startQuery(ctx, stmtAPIKeyCreate)
+
+	err = r.pool.QueryRow(ctx, stmtAPIKeyCreate,
+		tenantID(ctx),
+		k.CredentialID,
+		k.Name,
+		k.Prefix,
+		k.Hash,
+		b,
+		nullTime(k.ExpiresAt),
+		k.CreatedAt,
+	).Scan(&k.ID)
+	done(err)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return auth.NewError(auth.ErrCredNotFound, "Credential not found")
+	}
+
+	return apiKeyError(err)
+}
+
+// ByPrefix returns an APIKey by prefix.
+func (r *APIKeyRepository) ByPrefix(ctx context.Context, prefix string) (auth.APIKey, error) {
+	ctx, done := r.startQuery(ctx, stmtAPIKeyByPrefix)
+
+	k, err := scanAPIKey(r.pool.QueryRow(ctx, stmtAPIKeyByPrefix, tenantID(ctx), prefix))
+	done(err)
+
+	if err != nil {
+		return auth.APIKey{}, apiKeyError(err)
+	}
+
+	return k, nil
+}
+
+// ByCredential returns APIKeys of a Credential ordered by id.
+func (r *APIKeyRepository) ByCredential(ctx context.Context, credID int) ([]auth.APIKey, error) {
+	ctx, done := r.startQuery(ctx, stmtAPIKeyByCredential)
+
+	keys, err := r.byCredential(ctx, credID)
+	done(err)
+
+	return keys, apiKeyError(err)
+}
+
+func (r *APIKeyRepository) byCredential(ctx context.Context, credID int) ([]auth.APIKey, error) {
+	rows, err := r.pool.Query(ctx, stmtAPIKeyByCredential, tenantID(ctx), credID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]auth.APIKey, 0)
+
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, k)
+	}
+
+	return keys, rows.Err()
+}
+
+// Touch sets the last usage time of an APIKey.
+func (r *APIKeyRepository) Touch(ctx context.Context, id int, at time.Time) error {
+	ctx, done := r.startQuery(ctx, stmtAPIKeyTouch)
+
+	_, err := r.pool.Exec(ctx, stmtAPIKeyTouch, id, at)
+	done(err)
+
+	return apiKeyError(err)
+}
+
+// Delete deletes an APIKey of a Credential by id.
+func (r *APIKeyRepository) Delete(ctx context.Context, credID, id int) error {
+	ctx, done := r.startQuery(ctx, stmtAPIKeyDelete)
+
+	tag, err := r.pool.Exec(ctx, stmtAPIKeyDelete, tenantID(ctx), credID, id)
+	done(err)
+
+	if err == nil && tag.RowsAffected() == 0 {
+		err = pgx.ErrNoRows
+	}
+
+	return apiKeyError(err)
+}
+
+// scanAPIKey scans apiKeyColumns of a row.
+func scanAPIKey(row pgx.Row) (auth.APIKey, error) {
+	var (
+		k          auth.APIKey
+		scopes     []byte
+		expiresAt  *time.Time
+		lastUsedAt *time.Time
+	)
+
+	err := row.Scan(
+		&k.ID,
+		&k.CredentialID,
+		&k.Name,
+		&k.Prefix,
+		&k.Hash,
+		&scopes,
+		&expiresAt,
+		&lastUsedAt,
+		&k.CreatedAt,
+	)
+	if err != nil {
+		return auth.APIKey{}, err
+	}
+
+	var s []scope
+
+	err = json.Unmarshal(scopes, &s)
+	if err != nil {
+		return auth.APIKey{}, err
+	}
+
+	k.Scopes = make([]auth.Permission, 0, len(s))
+	for _, p := range s {
+		k.Scopes = append(k.Scopes, auth.Permission(p))
+	}
+
+	if expiresAt != nil {
+		k.ExpiresAt = *expiresAt
+	}
+
+	if lastUsedAt != nil {
+		k.LastUsedAt = *lastUsedAt
+	}
+
+	return k, nil
+}
+
+// apiKeyError converts Postgres errors into auth errors.
+func apiKeyError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return auth.NewError(auth.ErrAPIKeyNotFound, "API key not found")
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation && pgErr.ConstraintName == "api_key_prefix_key" {
+		return auth.WrapError(err, auth.ErrValidation, "API key prefix collision, try again.")
+	}
+
+	return queryError(err)
+}
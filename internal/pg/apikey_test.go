@@ -0,0 +1,71 @@
+package pg_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/pg"
+)
+
+func TestAPIKeyRepository(t *testing.T) {
+	c := setUp(t)
+	defer c.Close()
+
+	creds := pg.NewCredentialRepository(c)
+	r := pg.NewAPIKeyRepository(c)
+	ctx := context.Background()
+
+	now := time.Date(2020, time.April, 15, 0, 0, 0, 0, time.UTC)
+	cred := auth.Credential{Password: "1", Token: "1", Email: "owner@example.org", CreatedAt: now, UpdatedAt: now}
+	require.Nil(t, creds.Create(ctx, &cred))
+
+	k := auth.APIKey{
+		CredentialID: cred.ID,
+		Name:         "ci",
+		Prefix:       "prefix",
+		Hash:         "hash",
+		Scopes:       []auth.Permission{{Action: "read", Resource: "orgs"}},
+		ExpiresAt:    now.Add(time.Hour),
+		CreatedAt:    now,
+	}
+	require.Nil(t, r.Create(ctx, &k))
+
+	found, err := r.ByPrefix(ctx, k.Prefix)
+	require.Nil(t, err)
+
+	if diff := cmp.Diff(k, found); diff != "" {
+		t.Fatal(diff)
+	}
+
+	dup := k
+	assert.Equal(t, auth.ErrValidation, auth.ErrorCode(r.Create(ctx, &dup)))
+
+	err = r.Create(ctx, &auth.APIKey{CredentialID: cred.ID + 1, Prefix: "other", CreatedAt: now})
+	assert.Equal(t, auth.NewError(auth.ErrCredNotFound, "Credential not found"), err)
+
+	require.Nil(t, r.Touch(ctx, k.ID, now.Add(time.Minute)))
+	k.LastUsedAt = now.Add(time.Minute)
+
+	keys, err := r.ByCredential(ctx, cred.ID)
+	require.Nil(t, err)
+
+	if diff := cmp.Diff([]auth.APIKey{k}, keys); diff != "" {
+		t.Fatal(diff)
+	}
+
+	// Keys are scoped by the tenant of the context.
+	_, err = r.ByPrefix(auth.ContextWithTenant(ctx, auth.Tenant{ID: "other"}), k.Prefix)
+	assert.Equal(t, auth.NewError(auth.ErrAPIKeyNotFound, "API key not found"), err)
+
+	assert.Equal(t, auth.NewError(auth.ErrAPIKeyNotFound, "API key not found"), r.Delete(ctx, cred.ID+1, k.ID))
+	require.Nil(t, r.Delete(ctx, cred.ID, k.ID))
+
+	_, err = r.ByPrefix(ctx, k.Prefix)
+	assert.Equal(t, auth.NewError(auth.ErrAPIKeyNotFound, "API key not found"), err)
+}
@@ -0,0 +1,127 @@
+package pg
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	auth "github.com/kl09/auth-go"
+)
+
+const defaultAuditLimit = 100
+
+// AuditLog is an append-only storage of security events.
+type AuditLog struct {
+	*Client
+}
+
+// NewAuditLog creates a new AuditLog.
+func NewAuditLog(c *Client) *AuditLog {
+	return &AuditLog{
+		c,
+	}
+}
+
+// Append records a new entry in the tenant of the context.
+func (a *AuditLog) Append(ctx context.Context, e *auth.AuditEntry) error {
+	ctx, done := a.startQuery(ctx, stmtAuditAppend)
+
+	err := a.pool.QueryRow(ctx, stmtAuditAppend,
+		tenantID(ctx),
+		e.CredentialID,
+		e.Event,
+		e.IP,
+		e.UserAgent,
+		e.Reason,
+		e.Actor,
+		e.CreatedAt,
+	).Scan(&e.ID)
+	done(err)
+
+	return queryError(err)
+}
+
+// AppendBatch records new entries in the tenant of the context in a single round trip.
+func (a *AuditLog) AppendBatch(ctx context.Context, entries []*auth.AuditEntry) error {
+	ctx, done := a.startQuery(ctx, stmtAuditAppend)
+
+	b := &pgx.Batch{}
+	tenant := tenantID(ctx)
+
+	for _, e := range entries {
+		b.Queue(stmtAuditAppend,
+			tenant,
+			e.CredentialID,
+			e.Event,
+			e.IP,
+			e.UserAgent,
+			e.Reason,
+			e.Actor,
+			e.CreatedAt,
+		).QueryRow(func(row pgx.Row) error {
+			return row.Scan(&e.ID)
+		})
+	}
+
+	err := a.pool.SendBatch(ctx, b).Close()
+	done(err)
+
+	return queryError(err)
+}
+
+// Find returns entries of the tenant of the context matching the filter, newest first.
+func (a *AuditLog) Find(ctx context.Context, f auth.AuditFilter) ([]auth.AuditEntry, error) {
+	if f.Limit <= 0 {
+		f.Limit = defaultAuditLimit
+	}
+
+	ctx, done := a.startQuery(ctx, stmtAuditFind)
+
+	entries, err := a.find(ctx, f)
+	done(err)
+
+	return entries, queryError(err)
+}
+
+func (a *AuditLog) find(ctx context.Context, f auth.AuditFilter) ([]auth.AuditEntry, error) {
+	rows, err := a.pool.Query(ctx, stmtAuditFind, tenantID(ctx), f.CredentialID, nullTime(f.From), nullTime(f.To), f.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]auth.AuditEntry, 0)
+
+	for rows.Next() {
+		var e auth.AuditEntry
+
+		err = rows.Scan(&e.ID, &e.CredentialID, &e.Event, &e.IP, &e.UserAgent, &e.Reason, &e.Actor, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// Anonymize removes the credential from its entries keeping the entries.
+func (a *AuditLog) Anonymize(ctx context.Context, credID int) error {
+	ctx, done := a.startQuery(ctx, stmtAuditAnonymize)
+
+	_, err := a.pool.Exec(ctx, stmtAuditAnonymize, credID, auth.ActorCredential(credID), auth.ActorDeleted)
+	done(err)
+
+	return queryError(err)
+}
+
+// nullTime converts a zero time to NULL.
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
@@ -0,0 +1,165 @@
+package pg_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+
+	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/pg"
+)
+
+func TestAuditLog_AppendFind(t *testing.T) {
+	c := setUp(t)
+	defer c.Close()
+
+	r := pg.NewCredentialRepository(c)
+	a := pg.NewAuditLog(c)
+
+	now := time.Date(2020, time.April, 15, 0, 0, 0, 0, time.UTC)
+	cred := auth.Credential{
+		Password:  "12345",
+		Email:     "example@example.org",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	require.Nil(t, r.Create(context.Background(), &cred))
+
+	entries := []auth.AuditEntry{
+		{CredentialID: cred.ID, Event: auth.AuditRegister, IP: "127.0.0.1", UserAgent: "curl", CreatedAt: now},
+		{Event: auth.AuditLoginFailure, Reason: auth.ErrCredNotFound, IP: "127.0.0.1", CreatedAt: now.Add(time.Hour)},
+		{CredentialID: cred.ID, Event: auth.AuditLoginSuccess, IP: "127.0.0.1", CreatedAt: now.Add(2 * time.Hour)},
+	}
+	for i := range entries {
+		require.Nil(t, a.Append(context.Background(), &entries[i]))
+		require.NotZero(t, entries[i].ID)
+	}
+
+	cases := []struct {
+		name   string
+		filter auth.AuditFilter
+		want   []auth.AuditEntry
+	}{
+		{
+			name:   "all",
+			filter: auth.AuditFilter{},
+			want:   []auth.AuditEntry{entries[2], entries[1], entries[0]},
+		},
+		{
+			name:   "by credential",
+			filter: auth.AuditFilter{CredentialID: cred.ID},
+			want:   []auth.AuditEntry{entries[2], entries[0]},
+		},
+		{
+			name:   "by time range",
+			filter: auth.AuditFilter{From: now.Add(time.Hour), To: now.Add(2 * time.Hour)},
+			want:   []auth.AuditEntry{entries[1]},
+		},
+		{
+			name:   "limit",
+			filter: auth.AuditFilter{Limit: 1},
+			want:   []auth.AuditEntry{entries[2]},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := a.Find(context.Background(), tc.filter)
+			require.Nil(t, err)
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestAuditLog_AppendBatch(t *testing.T) {
+	c := setUp(t)
+	defer c.Close()
+
+	a := pg.NewAuditLog(c)
+
+	now := time.Date(2020, time.April, 15, 0, 0, 0, 0, time.UTC)
+	entries := []*auth.AuditEntry{
+		{Event: auth.AuditTokenUse, Reason: auth.ErrTokenExpired, IP: "127.0.0.1", CreatedAt: now},
+		{Event: auth.AuditAPIKeyUse, IP: "127.0.0.1", UserAgent: "curl", CreatedAt: now.Add(time.Hour)},
+	}
+	require.Nil(t, a.AppendBatch(context.Background(), entries))
+
+	for _, e := range entries {
+		require.NotZero(t, e.ID)
+	}
+
+	got, err := a.Find(context.Background(), auth.AuditFilter{})
+	require.Nil(t, err)
+
+	if diff := cmp.Diff([]auth.AuditEntry{*entries[1], *entries[0]}, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestAuditLog_Tenant(t *testing.T) {
+	c := setUp(t)
+	defer c.Close()
+
+	now := time.Date(2020, time.April, 15, 0, 0, 0, 0, time.UTC)
+	require.Nil(t, pg.NewTenantRepository(c).Save(context.Background(), &auth.Tenant{
+		ID:        "other",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}))
+
+	a := pg.NewAuditLog(c)
+	other := auth.ContextWithTenant(context.Background(), auth.Tenant{ID: "other"})
+
+	entry := auth.AuditEntry{Event: auth.AuditLoginFailure, IP: "127.0.0.1", CreatedAt: now}
+	require.Nil(t, a.Append(other, &entry))
+
+	got, err := a.Find(context.Background(), auth.AuditFilter{})
+	require.Nil(t, err)
+	require.Empty(t, got)
+
+	got, err = a.Find(other, auth.AuditFilter{})
+	require.Nil(t, err)
+
+	if diff := cmp.Diff([]auth.AuditEntry{entry}, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestAuditLog_Anonymize(t *testing.T) {
+	c := setUp(t)
+	defer c.Close()
+
+	a := pg.NewAuditLog(c)
+	ctx := context.Background()
+
+	now := time.Date(2020, time.April, 15, 0, 0, 0, 0, time.UTC)
+	entries := []auth.AuditEntry{
+		{CredentialID: 1, Event: auth.AuditLoginSuccess, IP: "127.0.0.1", UserAgent: "curl", CreatedAt: now},
+		{CredentialID: 2, Event: auth.AuditView, IP: "127.0.0.2", Actor: auth.ActorCredential(1), CreatedAt: now.Add(time.Hour)},
+		{CredentialID: 2, Event: auth.AuditLoginSuccess, IP: "127.0.0.2", CreatedAt: now.Add(2 * time.Hour)},
+	}
+	for i := range entries {
+		require.Nil(t, a.Append(ctx, &entries[i]))
+	}
+
+	require.Nil(t, a.Anonymize(ctx, 1))
+
+	got, err := a.Find(ctx, auth.AuditFilter{})
+	require.Nil(t, err)
+
+	want := []auth.AuditEntry{
+		entries[2],
+		{ID: entries[1].ID, CredentialID: 2, Event: auth.AuditView, IP: "127.0.0.2", Actor: auth.ActorDeleted, CreatedAt: now.Add(time.Hour)},
+		{ID: entries[0].ID, Event: auth.AuditLoginSuccess, CreatedAt: now},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
@@ -1,28 +1,42 @@
 package pg
 
 import (
-	"database/sql"
+	"context"
+	"errors"
 	"io/ioutil"
 	"time"
 
-	"github.com/jinzhu/gorm"
-	// postgres dialect
-	_ "github.com/jinzhu/gorm/dialects/postgres"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/logging"
 )
 
+const instrumentationName = "github.com/kl09/auth-go/internal/pg"
+
 type Client struct {
-	db                *gorm.DB
+	pool              *pgxpool.Pool
 	logger            zerolog.Logger
 	maxOpenCons       int
-	maxIdleCons       int
+	minIdleCons       int
 	connectionTimeout time.Duration
+	statementTimeout  time.Duration
+	tracer            trace.Tracer
 }
 
 // NewClient returns a new Client for DB connection.
 func NewClient(options ...ConfigOption) *Client {
 	c := Client{
 		logger: zerolog.New(ioutil.Discard),
+		tracer: noop.NewTracerProvider().Tracer(instrumentationName),
 	}
 
 	for _, opt := range options {
@@ -49,10 +63,10 @@ func WithMaxConnections(n int) ConfigOption {
 	}
 }
 
-// WithMaxIdleConnections configures a max idle connections to Postgres.
-func WithMaxIdleConnections(n int) ConfigOption {
+// WithMinIdleConnections configures a min idle connections to Postgres kept open by the pool.
+func WithMinIdleConnections(n int) ConfigOption {
 	return func(c *Client) {
-		c.maxIdleCons = n
+		c.minIdleCons = n
 	}
 }
 
@@ -63,47 +77,145 @@ func WithConnectionTimeout(t time.Duration) ConfigOption {
 	}
 }
 
+// WithStatementTimeout configures a default timeout of a single query.
+// A deadline of the query context takes precedence if it is earlier.
+func WithStatementTimeout(t time.Duration) ConfigOption {
+	return func(c *Client) {
+		c.statementTimeout = t
+	}
+}
+
+// WithTracerProvider configures tracing of queries.
+func WithTracerProvider(tp trace.TracerProvider) ConfigOption {
+	return func(c *Client) {
+		c.tracer = tp.Tracer(instrumentationName)
+	}
+}
+
 // Open opens PostgreSQL connection.
 func (c *Client) Open(source string) error {
-	var err error
-
 	c.logger.Debug().Msg("connecting to db")
 
-	c.db, err = gorm.Open("postgres", source)
+	cfg, err := pgxpool.ParseConfig(source)
+	if err != nil {
+		c.logger.Err(err).Msg("sql config parse failed")
+		return err
+	}
+
+	if c.maxOpenCons > 0 {
+		cfg.MaxConns = int32(c.maxOpenCons)
+	}
+
+	if c.minIdleCons > 0 {
+		cfg.MinIdleConns = int32(c.minIdleCons)
+	}
+
+	if c.connectionTimeout > 0 {
+		cfg.MaxConnLifetime = c.connectionTimeout
+	}
+
+	cfg.AfterConnect = prepareStatements
+
+	c.pool, err = pgxpool.NewWithConfig(context.Background(), cfg)
 	if err != nil {
 		c.logger.Err(err).Msg("sql open failed")
 		return err
 	}
 
-	err = c.db.DB().Ping()
+	// Pooled connections prepare statements against the migrated schema,
+	// so the connectivity is checked on a plain connection.
+	conn, err := c.connect(context.Background())
 	if err != nil {
 		c.logger.Err(err).Msg("sql ping failed")
+		c.pool.Close()
+
 		return err
 	}
+	defer conn.Close(context.Background())
 
 	c.logger.Debug().Msg("connected to db")
 
-	c.db.SingularTable(true)
-	c.db.DB().SetMaxOpenConns(c.maxOpenCons)
-	c.db.DB().SetMaxIdleConns(c.maxIdleCons)
-	c.db.DB().SetConnMaxLifetime(c.connectionTimeout)
-
 	return nil
 }
 
 // Close closes PostgreSQL connection.
 func (c *Client) Close() error {
 	c.logger.Debug().Msg("connection to db closed")
-	return c.db.Close()
+	c.pool.Close()
+
+	return nil
 }
 
-// Schema sets up the initial schema.
-func (c *Client) Schema() error {
-	_, err := c.db.DB().Exec(Schema)
-	return err
+// Ping checks that a pooled connection to PostgreSQL can be used.
+func (c *Client) Ping(ctx context.Context) error {
+	return c.pool.Ping(ctx)
+}
+
+// Stats returns connection pool statistics.
+func (c *Client) Stats() *pgxpool.Stat {
+	return c.pool.Stat()
+}
+
+// startQuery starts a span of the named statement and limits ctx by the default statement timeout.
+// The returned function must be called with the query error once the query is done.
+func (c *Client) startQuery(ctx context.Context, stmt string) (context.Context, func(err error)) {
+	ctx, span := c.tracer.Start(ctx, stmt,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBQuerySummary(stmt),
+			semconv.DBQueryText(statements[stmt]),
+		),
+	)
+
+	cancel := func() {}
+	if c.statementTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.statementTimeout)
+	}
+
+	return ctx, func(err error) {
+		cancel()
+
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			logging.FromContext(ctx, &c.logger).Error().Err(err).Str("stmt", stmt).Msg("query failed")
+		}
+
+		span.End()
+	}
+}
+
+// connect opens a single connection outside of the pool.
+func (c *Client) connect(ctx context.Context) (*pgx.Conn, error) {
+	return pgx.ConnectConfig(ctx, c.pool.Config().ConnConfig)
 }
 
-// Stats returns database statistics.
-func (c *Client) Stats() sql.DBStats {
-	return c.db.DB().Stats()
+// prepareStatements prepares all named statements on a new connection.
+func prepareStatements(ctx context.Context, conn *pgx.Conn) error {
+	for name, sql := range statements {
+		if _, err := conn.Prepare(ctx, name, sql); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// queryError converts errors common for all queries into auth errors.
+func queryError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || pgconn.Timeout(err) {
+		return auth.WrapError(err, auth.ErrTimeout, "Query timed out")
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.QueryCanceled {
+		return auth.WrapError(err, auth.ErrTimeout, "Query timed out")
+	}
+
+	return err
 }
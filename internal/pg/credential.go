@@ -2,12 +2,22 @@ package pg
 
 import (
 	"context"
+	"errors"
+	"strings"
+	"time"
 
-	"github.com/jinzhu/gorm"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 
 	auth "github.com/kl09/auth-go"
 )
 
+const defaultSearchLimit = 100
+
+// likeEscaper escapes wildcards of a LIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
 // CredentialRepository is a repository for credentials.
 type CredentialRepository struct {
 	*Client
@@ -22,53 +32,228 @@ func NewCredentialRepository(c *Client) *CredentialRepository {
 
 // ByToken returns a Credential by token.
 func (c *CredentialRepository) ByToken(ctx context.Context, token string) (auth.Credential, error) {
-	cred := auth.Credential{}
+	return c.credential(ctx, stmtCredentialByToken, tenantID(ctx), token)
+}
 
-	db := c.db.Where("token = ?", token).Take(&cred)
-	if db.Error != nil {
-		if db.Error == gorm.ErrRecordNotFound {
-			return cred, auth.NewError(auth.ErrCredNotFound, "Credential not found")
-		}
+// ByID returns a Credential by id.
+func (c *CredentialRepository) ByID(ctx context.Context, id int) (auth.Credential, error) {
+	return c.credential(ctx, stmtCredentialByID, tenantID(ctx), id)
+}
+
+// ByEmail returns a Credential by email.
+func (c *CredentialRepository) ByEmail(ctx context.Context, email string) (auth.Credential, error) {
+	return c.credential(ctx, stmtCredentialByEmail, tenantID(ctx), email)
+}
+
+// Create creates a new Credential in the tenant of the context.
+func (c *CredentialRepository) Create(ctx context.Context, cred *auth.Credential) error {
+	ctx, done := c.startQuery(ctx, stmtCredentialCreate)
+
+	cred.TenantID = tenantID(ctx)
+
+	err := c.pool.QueryRow(ctx, stmtCredentialCreate,
+		cred.TenantID,
+		cred.Password,
+		cred.Token,
+		nullTime(cred.TokenExpiresAt),
+		cred.Email,
+		cred.EmailTmp,
+		cred.EmailVerified,
+		cred.VerificationCode,
+		cred.VerificationCodeAttempts,
+		status(cred.Status),
+		cred.StatusReason,
+		nullTime(cred.StatusUntil),
+		cred.ActiveOrganizationID,
+		cred.CreatedAt,
+		cred.UpdatedAt,
+	).Scan(&cred.ID)
+	done(err)
+
+	return credentialError(err)
+}
 
-		return cred, db.Error
+// Search returns Credentials matching the filter ordered by id.
+func (c *CredentialRepository) Search(ctx context.Context, f auth.CredentialFilter) ([]auth.Credential, error) {
+	if f.Limit <= 0 {
+		f.Limit = defaultSearchLimit
 	}
 
-	return cred, nil
+	ctx, done := c.startQuery(ctx, stmtCredentialSearch)
+
+	creds, err := c.credentials(ctx, stmtCredentialSearch, tenantID(ctx), likeEscaper.Replace(f.Email), f.Limit, f.Offset)
+	done(err)
+
+	return creds, credentialError(err)
 }
 
-// ByID returns a Credential by id.
-func (c *CredentialRepository) ByID(ctx context.Context, id int) (auth.Credential, error) {
-	cred := auth.Credential{}
+// PendingDeletion returns Credentials of all tenants pending deletion with StatusUntil before the time
+// ordered by StatusUntil.
+func (c *CredentialRepository) PendingDeletion(ctx context.Context, before time.Time, limit int) ([]auth.Credential, error) {
+	ctx, done := c.startQuery(ctx, stmtCredentialPendingDeletion)
 
-	db := c.db.Where("id = ?", id).Take(&cred)
-	if db.Error != nil {
-		if db.Error == gorm.ErrRecordNotFound {
-			return cred, auth.NewError(auth.ErrCredNotFound, "Credential not found")
+	creds, err := c.credentials(ctx, stmtCredentialPendingDeletion, before, limit)
+	done(err)
+
+	return creds, credentialError(err)
+}
+
+// credentials queries credentialColumns of rows.
+func (c *CredentialRepository) credentials(ctx context.Context, stmt string, args ...any) ([]auth.Credential, error) {
+	rows, err := c.pool.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	creds := make([]auth.Credential, 0)
+
+	for rows.Next() {
+		cred, err := scanCredential(rows)
+		if err != nil {
+			return nil, err
 		}
 
-		return cred, db.Error
+		creds = append(creds, cred)
 	}
 
-	return cred, nil
+	return creds, rows.Err()
 }
 
-// ByEmail returns a Credential by email.
-func (c *CredentialRepository) ByEmail(ctx context.Context, email string) (auth.Credential, error) {
-	cred := auth.Credential{}
+// Update updates a Credential by id.
+func (c *CredentialRepository) Update(ctx context.Context, cred *auth.Credential) error {
+	ctx, done := c.startQuery(ctx, stmtCredentialUpdate)
 
-	db := c.db.Where("email = ?", email).Take(&cred)
-	if db.Error != nil {
-		if db.Error == gorm.ErrRecordNotFound {
-			return cred, auth.NewError(auth.ErrCredNotFound, "Credential not found")
-		}
+	tag, err := c.pool.Exec(ctx, stmtCredentialUpdate,
+		tenantID(ctx),
+		cred.ID,
+		cred.Password,
+		cred.Token,
+		nullTime(cred.TokenExpiresAt),
+		cred.Email,
+		cred.EmailTmp,
+		cred.EmailVerified,
+		cred.VerificationCode,
+		cred.VerificationCodeAttempts,
+		status(cred.Status),
+		cred.StatusReason,
+		nullTime(cred.StatusUntil),
+		cred.ActiveOrganizationID,
+		cred.UpdatedAt,
+	)
+	done(err)
+
+	if err == nil && tag.RowsAffected() == 0 {
+		err = pgx.ErrNoRows
+	}
 
-		return cred, db.Error
+	return credentialError(err)
+}
+
+// Delete deletes a Credential by id.
+func (c *CredentialRepository) Delete(ctx context.Context, id int) error {
+	ctx, done := c.startQuery(ctx, stmtCredentialDelete)
+
+	tag, err := c.pool.Exec(ctx, stmtCredentialDelete, tenantID(ctx), id)
+	done(err)
+
+	if err == nil && tag.RowsAffected() == 0 {
+		err = pgx.ErrNoRows
+	}
+
+	return credentialError(err)
+}
+
+func (c *CredentialRepository) credential(ctx context.Context, stmt string, args ...interface{}) (auth.Credential, error) {
+	ctx, done := c.startQuery(ctx, stmt)
+
+	cred, err := scanCredential(c.pool.QueryRow(ctx, stmt, args...))
+	done(err)
+
+	if err != nil {
+		return auth.Credential{}, credentialError(err)
 	}
 
 	return cred, nil
 }
 
-// Create creates a new Credential.
-func (c *CredentialRepository) Create(ctx context.Context, cred *auth.Credential) error {
-	return c.db.Create(cred).Error
+// scanCredential scans credentialColumns of a row.
+func scanCredential(row pgx.Row) (auth.Credential, error) {
+	var (
+		cred           auth.Credential
+		tokenExpiresAt *time.Time
+		until          *time.Time
+		activeOrgID    *int
+	)
+
+	err := row.Scan(
+		&cred.ID,
+		&cred.TenantID,
+		&cred.Password,
+		&cred.Token,
+		&tokenExpiresAt,
+		&cred.Email,
+		&cred.EmailTmp,
+		&cred.EmailVerified,
+		&cred.VerificationCode,
+		&cred.VerificationCodeAttempts,
+		&cred.Status,
+		&cred.StatusReason,
+		&until,
+		&activeOrgID,
+		&cred.CreatedAt,
+		&cred.UpdatedAt,
+	)
+
+	if tokenExpiresAt != nil {
+		cred.TokenExpiresAt = *tokenExpiresAt
+	}
+
+	if until != nil {
+		cred.StatusUntil = *until
+	}
+
+	if activeOrgID != nil {
+		cred.ActiveOrganizationID = *activeOrgID
+	}
+
+	return cred, err
+}
+
+// tenantID returns the tenant of the context all queries are scoped by.
+func tenantID(ctx context.Context) string {
+	return auth.TenantFromContext(ctx).ID
+}
+
+// status defaults an empty status to active.
+func status(s string) string {
+	if s == "" {
+		return auth.StatusActive
+	}
+
+	return s
+}
+
+// credentialError converts Postgres errors into auth errors.
+func credentialError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return auth.NewError(auth.ErrCredNotFound, "Credential not found")
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == pgerrcode.UniqueViolation && (pgErr.ConstraintName == "credential_tenant_id_email_key" ||
+			pgErr.ConstraintName == "credential_tenant_id_email_tmp_key"):
+			return auth.WrapError(err, auth.ErrEmailExists, "User with this email already exists.")
+		case pgErr.Code == pgerrcode.ForeignKeyViolation && pgErr.ConstraintName == "credential_active_organization_id_fkey":
+			return auth.WrapError(err, auth.ErrOrgNotFound, "Organization not found")
+		}
+	}
+
+	return queryError(err)
 }
@@ -2,199 +2,94 @@ package pg_test
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
-	"github.com/google/go-cmp/cmp"
-	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	auth "github.com/kl09/auth-go"
 	"github.com/kl09/auth-go/internal/pg"
+	"github.com/kl09/auth-go/internal/repotest"
 )
 
-func TestCredentialRepository_Create(t *testing.T) {
-	c := setUp(t)
+func BenchmarkCredentialRepository_ByToken(b *testing.B) {
+	c := setUp(b)
 	defer c.Close()
 
 	r := pg.NewCredentialRepository(c)
 
-	now := time.Now()
 	cred := auth.Credential{
-		Password:  "12345",
-		Email:     "example@example.org",
-		CreatedAt: now,
-		UpdatedAt: now,
+		Password: "12345",
+		Email:    "example@example.org",
+		Token:    "token",
 	}
-	require.Nil(t, r.Create(context.Background(), &cred))
-
-	assert.Equal(t,
-		auth.Credential{
-			ID:                       1,
-			Password:                 "12345",
-			Email:                    "example@example.org",
-			EmailTmp:                 "",
-			EmailVerified:            false,
-			VerificationCode:         "",
-			VerificationCodeAttempts: 0,
-			CreatedAt:                now,
-			UpdatedAt:                now,
-		},
-		cred,
-	)
-}
+	require.Nil(b, r.Create(context.Background(), &cred))
 
-func TestCredentialRepository_ByToken(t *testing.T) {
-	c := setUp(t)
-	defer c.Close()
+	b.ResetTimer()
 
-	r := pg.NewCredentialRepository(c)
-
-	now := time.Date(2020, time.April, 15, 0, 0, 0, 0, time.UTC)
-	cred := auth.Credential{
-		Password:  "12345",
-		Email:     "example@example.org",
-		Token:     "token",
-		CreatedAt: now,
-		UpdatedAt: now,
-	}
-	require.Nil(t, r.Create(context.Background(), &cred))
-
-	testCases := []struct {
-		name          string
-		token         string
-		expectedCred  auth.Credential
-		expectedError error
-	}{
-		{
-			name:  "success get",
-			token: "token",
-			expectedCred: auth.Credential{
-				ID:        1,
-				Password:  "12345",
-				Email:     "example@example.org",
-				Token:     "token",
-				CreatedAt: now,
-				UpdatedAt: now,
-			},
-		},
-		{
-			name:          "error - not found",
-			token:         "bad_token",
-			expectedError: auth.NewError(auth.ErrCredNotFound, "Credential not found"),
-		},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			cred, err := r.ByToken(context.Background(), tc.token)
-
-			if diff := cmp.Diff(tc.expectedCred, cred); diff != "" {
-				t.Fatal(diff)
-			}
-			assert.Equal(t, tc.expectedError, err)
-		})
+	for i := 0; i < b.N; i++ {
+		if _, err := r.ByToken(context.Background(), "token"); err != nil {
+			b.Fatal(err)
+		}
 	}
 }
 
-func TestCredentialRepository_ByID(t *testing.T) {
-	c := setUp(t)
+func BenchmarkCredentialRepository_ByEmail(b *testing.B) {
+	c := setUp(b)
 	defer c.Close()
 
 	r := pg.NewCredentialRepository(c)
 
-	now := time.Date(2020, time.April, 15, 0, 0, 0, 0, time.UTC)
 	cred := auth.Credential{
-		Password:  "12345",
-		Email:     "example@example.org",
-		CreatedAt: now,
-		UpdatedAt: now,
-	}
-	require.Nil(t, r.Create(context.Background(), &cred))
-
-	testCases := []struct {
-		name          string
-		id            int
-		expectedCred  auth.Credential
-		expectedError error
-	}{
-		{
-			name: "success get",
-			id:   1,
-			expectedCred: auth.Credential{
-				ID:        1,
-				Password:  "12345",
-				Email:     "example@example.org",
-				CreatedAt: now,
-				UpdatedAt: now,
-			},
-		},
-		{
-			name:          "error - not found",
-			id:            2,
-			expectedError: auth.NewError(auth.ErrCredNotFound, "Credential not found"),
-		},
+		Password: "12345",
+		Email:    "example@example.org",
+		Token:    "token",
 	}
+	require.Nil(b, r.Create(context.Background(), &cred))
 
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			cred, err := r.ByID(context.Background(), tc.id)
+	b.ResetTimer()
 
-			if diff := cmp.Diff(tc.expectedCred, cred); diff != "" {
-				t.Fatal(diff)
-			}
-			assert.Equal(t, tc.expectedError, err)
-		})
+	for i := 0; i < b.N; i++ {
+		if _, err := r.ByEmail(context.Background(), "example@example.org"); err != nil {
+			b.Fatal(err)
+		}
 	}
 }
 
-func TestCredentialRepository_ByEmail(t *testing.T) {
-	c := setUp(t)
+func BenchmarkCredentialRepository_Create(b *testing.B) {
+	c := setUp(b)
 	defer c.Close()
 
 	r := pg.NewCredentialRepository(c)
 
-	now := time.Date(2020, time.April, 15, 0, 0, 0, 0, time.UTC)
-	cred := auth.Credential{
-		Password:  "12345",
-		Email:     "example@example.org",
-		CreatedAt: now,
-		UpdatedAt: now,
-	}
-	require.Nil(t, r.Create(context.Background(), &cred))
-
-	testCases := []struct {
-		name          string
-		email         string
-		expectedCred  auth.Credential
-		expectedError error
-	}{
-		{
-			name:  "success get",
-			email: "example@example.org",
-			expectedCred: auth.Credential{
-				ID:        1,
-				Password:  "12345",
-				Email:     "example@example.org",
-				CreatedAt: now,
-				UpdatedAt: now,
-			},
-		},
-		{
-			name:          "error - not found",
-			email:         "example2@example.org",
-			expectedError: auth.NewError(auth.ErrCredNotFound, "Credential not found"),
-		},
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		cred := auth.Credential{
+			Password: "12345",
+			Email:    fmt.Sprintf("example%d@example.org", i),
+			Token:    fmt.Sprintf("token%d", i),
+		}
+		if err := r.Create(context.Background(), &cred); err != nil {
+			b.Fatal(err)
+		}
 	}
+}
 
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			cred, err := r.ByEmail(context.Background(), tc.email)
+func TestCredentialRepository_Conformance(t *testing.T) {
+	repotest.TestCredentialRepository(t, func(t *testing.T) auth.CredentialRepository {
+		c := setUp(t)
+		t.Cleanup(func() { _ = c.Close() })
 
-			if diff := cmp.Diff(tc.expectedCred, cred); diff != "" {
-				t.Fatal(diff)
-			}
-			assert.Equal(t, tc.expectedError, err)
+		now := time.Now()
+		err := pg.NewTenantRepository(c).Save(context.Background(), &auth.Tenant{
+			ID:        repotest.OtherTenantID,
+			CreatedAt: now,
+			UpdatedAt: now,
 		})
-	}
+		require.Nil(t, err)
+
+		return pg.NewCredentialRepository(c)
+	})
 }
@@ -0,0 +1,107 @@
+package pg
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/kl09/auth-go/internal/logging"
+)
+
+// channelCredentialChanged is a channel the credential_notify_change trigger notifies about updated
+// and deleted credentials.
+const channelCredentialChanged = "credential_changed"
+
+const (
+	minListenRetryInterval = 100 * time.Millisecond
+	maxListenRetryInterval = 30 * time.Second
+)
+
+// CredentialChange is a notification about an updated or deleted credential.
+type CredentialChange struct {
+	TenantID string `json:"tenant_id"`
+	ID       int    `json:"id"`
+}
+
+// CredentialListener listens to changes of credentials made by any process using the database.
+type CredentialListener struct {
+	client   *Client
+	onChange func(c CredentialChange)
+	onReset  func()
+}
+
+// NewCredentialListener creates a new CredentialListener calling onChange on every change.
+// Changes are missed while there is no connection, onReset is called when the connection is lost
+// and once it is listening again, so everything learned from the database before can be forgotten.
+func NewCredentialListener(c *Client, onChange func(c CredentialChange), onReset func()) *CredentialListener {
+	return &CredentialListener{
+		client:   c,
+		onChange: onChange,
+		onReset:  onReset,
+	}
+}
+
+// Run listens until ctx is done reconnecting with a backoff after failures.
+func (l *CredentialListener) Run(ctx context.Context) error {
+	logger := logging.FromContext(ctx, &l.client.logger)
+	retryInterval := minListenRetryInterval
+
+	for {
+		listening, err := l.listen(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		l.onReset()
+
+		if listening {
+			retryInterval = minListenRetryInterval
+		}
+
+		logger.Error().Err(err).Dur("retry_interval", retryInterval).Msg("credential listener failed")
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(retryInterval):
+		}
+
+		retryInterval *= 2
+		if retryInterval > maxListenRetryInterval {
+			retryInterval = maxListenRetryInterval
+		}
+	}
+}
+
+// listen delivers notifications of a single connection until it fails, listening reports that it was established.
+func (l *CredentialListener) listen(ctx context.Context) (listening bool, err error) {
+	conn, err := l.client.connect(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err = conn.Exec(ctx, "LISTEN "+channelCredentialChanged); err != nil {
+		return false, err
+	}
+
+	l.onReset()
+
+	logger := logging.FromContext(ctx, &l.client.logger)
+	logger.Info().Msg("listening to credential changes")
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return true, err
+		}
+
+		var change CredentialChange
+		if err = json.Unmarshal([]byte(n.Payload), &change); err != nil {
+			logger.Error().Err(err).Str("payload", n.Payload).Msg("bad credential change")
+			continue
+		}
+
+		l.onChange(change)
+	}
+}
@@ -0,0 +1,64 @@
+package pg_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/pg"
+)
+
+func TestCredentialListener(t *testing.T) {
+	c := setUp(t)
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	changes := make(chan pg.CredentialChange, 10)
+	resets := make(chan struct{}, 10)
+
+	l := pg.NewCredentialListener(c, func(change pg.CredentialChange) {
+		changes <- change
+	}, func() {
+		resets <- struct{}{}
+	})
+
+	done := make(chan error)
+
+	go func() {
+		done <- l.Run(ctx)
+	}()
+
+	select {
+	case <-resets:
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener didn't start")
+	}
+
+	r := pg.NewCredentialRepository(c)
+	cred := auth.Credential{Password: "12345", Email: "example@example.org", Token: "token"}
+
+	require.Nil(t, r.Create(context.Background(), &cred))
+
+	cred.Token = "new_token"
+	require.Nil(t, r.Update(context.Background(), &cred))
+	require.Nil(t, r.Delete(context.Background(), cred.ID))
+
+	// Creates aren't notified, nothing can be cached by the id of a new credential.
+	for i := 0; i < 2; i++ {
+		select {
+		case change := <-changes:
+			assert.Equal(t, pg.CredentialChange{TenantID: auth.DefaultTenantID, ID: cred.ID}, change)
+		case <-time.After(5 * time.Second):
+			t.Fatal("change wasn't notified")
+		}
+	}
+
+	cancel()
+	require.Nil(t, <-done)
+	assert.Empty(t, changes)
+}
@@ -0,0 +1,304 @@
+package pg
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockKey is a key of the advisory lock held while migrations run,
+// so concurrent migrators never apply the same migration twice.
+const migrationLockKey int64 = 0x617574685f6d6967
+
+const createMigrationsTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations
+(
+	version integer PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	applied_at timestamp with time zone DEFAULT now() NOT NULL
+);
+`
+
+// Migration is a numbered schema change with its rollback.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is a state of a Migration in the database.
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrations returns all migrations embedded into the binary ordered by version.
+func Migrations() ([]Migration, error) {
+	return parseMigrations(migrationFiles, "migrations")
+}
+
+// parseMigrations reads migrations named as NNNN_name.up.sql and NNNN_name.down.sql from dir.
+func parseMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+
+	for _, e := range entries {
+		var direction string
+
+		name := e.Name()
+
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migration %s: unknown direction", name)
+		}
+
+		parts := strings.SplitN(strings.TrimSuffix(name, "."+direction+".sql"), "_", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("migration %s: name must be NNNN_name", name)
+		}
+
+		version, err := strconv.Atoi(parts[0])
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: bad version", name)
+		}
+
+		b, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: parts[1]}
+			byVersion[version] = m
+		}
+
+		if m.Name != parts[1] {
+			return nil, fmt.Errorf("migration %d: names %s and %s differ", version, m.Name, parts[1])
+		}
+
+		if direction == "up" {
+			m.Up = string(b)
+		} else {
+			m.Down = string(b)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d: both up and down are required", m.Version)
+		}
+
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Migrator applies and rolls back schema migrations.
+type Migrator struct {
+	client *Client
+}
+
+// NewMigrator creates a new Migrator.
+func NewMigrator(c *Client) *Migrator {
+	return &Migrator{
+		client: c,
+	}
+}
+
+// Up applies all pending migrations and returns the applied ones.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+
+	err := m.withLock(ctx, func(conn *pgx.Conn, statuses []MigrationStatus) error {
+		for _, s := range statuses {
+			if s.Applied {
+				continue
+			}
+
+			m.client.logger.Info().Int("version", s.Version).Str("name", s.Name).Msg("applying migration")
+
+			err := inTx(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, s.Up); err != nil {
+					return err
+				}
+
+				_, err := tx.Exec(ctx,
+					"INSERT INTO schema_migrations (version, name) VALUES ($1, $2)",
+					s.Version, s.Name,
+				)
+
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d up: %w", s.Version, err)
+			}
+
+			applied = append(applied, s.Migration)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down rolls back the last n applied migrations and returns the rolled back ones.
+func (m *Migrator) Down(ctx context.Context, n int) ([]Migration, error) {
+	var rolledBack []Migration
+
+	err := m.withLock(ctx, func(conn *pgx.Conn, statuses []MigrationStatus) error {
+		for i := len(statuses) - 1; i >= 0 && len(rolledBack) < n; i-- {
+			s := statuses[i]
+			if !s.Applied {
+				continue
+			}
+
+			m.client.logger.Info().Int("version", s.Version).Str("name", s.Name).Msg("rolling back migration")
+
+			err := inTx(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, s.Down); err != nil {
+					return err
+				}
+
+				_, err := tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", s.Version)
+
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d down: %w", s.Version, err)
+			}
+
+			rolledBack = append(rolledBack, s.Migration)
+		}
+
+		return nil
+	})
+
+	return rolledBack, err
+}
+
+// Status returns the state of every known migration.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var result []MigrationStatus
+
+	err := m.withLock(ctx, func(conn *pgx.Conn, statuses []MigrationStatus) error {
+		result = statuses
+		return nil
+	})
+
+	return result, err
+}
+
+// withLock runs fn on a single connection holding the migration advisory lock.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgx.Conn, statuses []MigrationStatus) error) error {
+	migrations, err := Migrations()
+	if err != nil {
+		return err
+	}
+
+	conn, err := m.client.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		return err
+	}
+
+	defer func() {
+		if _, err := conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey); err != nil {
+			m.client.logger.Err(err).Msg("migration unlock failed")
+		}
+	}()
+
+	if _, err = conn.Exec(ctx, createMigrationsTable); err != nil {
+		return err
+	}
+
+	applied, err := appliedMigrations(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+
+	for _, mig := range migrations {
+		appliedAt, ok := applied[mig.Version]
+		statuses = append(statuses, MigrationStatus{
+			Migration: mig,
+			Applied:   ok,
+			AppliedAt: appliedAt,
+		})
+	}
+
+	return fn(conn, statuses)
+}
+
+func appliedMigrations(ctx context.Context, conn *pgx.Conn) (map[int]time.Time, error) {
+	rows, err := conn.Query(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+
+	for rows.Next() {
+		var (
+			version   int
+			appliedAt time.Time
+		)
+
+		if err = rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+func inTx(ctx context.Context, conn *pgx.Conn, fn func(tx pgx.Tx) error) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = fn(tx); err != nil {
+		_ = tx.Rollback(context.Background())
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,65 @@
+package pg_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kl09/auth-go/internal/pg"
+)
+
+func TestMigrations(t *testing.T) {
+	migrations, err := pg.Migrations()
+	require.Nil(t, err)
+	require.NotEmpty(t, migrations)
+
+	for i, m := range migrations {
+		assert.Equal(t, i+1, m.Version)
+		assert.NotEmpty(t, m.Name)
+		assert.NotEmpty(t, m.Up)
+		assert.NotEmpty(t, m.Down)
+	}
+}
+
+func TestMigrator_UpDownStatus(t *testing.T) {
+	c := setUp(t)
+	defer c.Close()
+
+	ctx := context.Background()
+	m := pg.NewMigrator(c)
+
+	migrations, err := pg.Migrations()
+	require.Nil(t, err)
+
+	// setUp has already applied everything.
+	applied, err := m.Up(ctx)
+	require.Nil(t, err)
+	assert.Empty(t, applied)
+
+	statuses, err := m.Status(ctx)
+	require.Nil(t, err)
+	require.Len(t, statuses, len(migrations))
+
+	for _, s := range statuses {
+		assert.True(t, s.Applied)
+		assert.False(t, s.AppliedAt.IsZero())
+	}
+
+	rolledBack, err := m.Down(ctx, len(migrations))
+	require.Nil(t, err)
+	require.Len(t, rolledBack, len(migrations))
+	assert.Equal(t, migrations[len(migrations)-1].Version, rolledBack[0].Version)
+
+	statuses, err = m.Status(ctx)
+	require.Nil(t, err)
+
+	for _, s := range statuses {
+		assert.False(t, s.Applied)
+	}
+
+	applied, err = m.Up(ctx)
+	require.Nil(t, err)
+	assert.Len(t, applied, len(migrations))
+}
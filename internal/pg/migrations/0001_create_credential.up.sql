@@ -1,7 +1,4 @@
-package pg
-
-const Schema = `
-CREATE TABLE credential
+CREATE TABLE IF NOT EXISTS credential
 (
 	id integer PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
 	password VARCHAR(128) NOT NULL,
@@ -17,4 +14,3 @@ CREATE TABLE credential
 	UNIQUE (email_tmp),
 	UNIQUE (token)
 );
-`
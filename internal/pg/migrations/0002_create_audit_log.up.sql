@@ -0,0 +1,23 @@
+CREATE TABLE audit_log
+(
+	id bigint PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+	credential_id integer,
+	event VARCHAR(32) NOT NULL,
+	ip VARCHAR(45) NOT NULL DEFAULT '',
+	user_agent VARCHAR(512) NOT NULL DEFAULT '',
+	reason VARCHAR(64) NOT NULL DEFAULT '',
+	created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+CREATE INDEX audit_log_credential_id_created_at_idx ON audit_log (credential_id, created_at);
+CREATE INDEX audit_log_created_at_idx ON audit_log (created_at);
+
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only
+	BEFORE UPDATE OR DELETE ON audit_log
+	FOR EACH ROW EXECUTE PROCEDURE audit_log_append_only();
@@ -0,0 +1,3 @@
+ALTER TABLE audit_log DROP COLUMN actor;
+
+ALTER TABLE credential DROP COLUMN disabled;
@@ -0,0 +1,3 @@
+ALTER TABLE credential ADD COLUMN disabled BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE audit_log ADD COLUMN actor VARCHAR(64) NOT NULL DEFAULT '';
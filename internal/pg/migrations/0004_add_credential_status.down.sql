@@ -0,0 +1,8 @@
+ALTER TABLE credential ADD COLUMN disabled BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE credential SET disabled = true WHERE status <> 'active';
+
+ALTER TABLE credential
+	DROP COLUMN status,
+	DROP COLUMN status_reason,
+	DROP COLUMN status_until;
@@ -0,0 +1,8 @@
+ALTER TABLE credential
+	ADD COLUMN status VARCHAR(32) NOT NULL DEFAULT 'active',
+	ADD COLUMN status_reason VARCHAR(255) NOT NULL DEFAULT '',
+	ADD COLUMN status_until timestamp with time zone;
+
+UPDATE credential SET status = 'disabled' WHERE disabled;
+
+ALTER TABLE credential DROP COLUMN disabled;
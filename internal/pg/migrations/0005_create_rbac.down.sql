@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS credential_role;
+DROP TABLE IF EXISTS permission;
+DROP TABLE IF EXISTS role;
@@ -0,0 +1,26 @@
+CREATE TABLE role
+(
+	id integer PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+	name VARCHAR(64) NOT NULL,
+	description VARCHAR(255) NOT NULL DEFAULT '',
+	created_at timestamp with time zone DEFAULT now() NOT NULL,
+	UNIQUE (name)
+);
+
+CREATE TABLE permission
+(
+	role_id integer NOT NULL REFERENCES role (id) ON DELETE CASCADE,
+	action VARCHAR(64) NOT NULL,
+	resource VARCHAR(128) NOT NULL,
+	PRIMARY KEY (role_id, action, resource)
+);
+
+CREATE TABLE credential_role
+(
+	credential_id integer NOT NULL REFERENCES credential (id) ON DELETE CASCADE,
+	role_id integer NOT NULL REFERENCES role (id) ON DELETE CASCADE,
+	created_at timestamp with time zone DEFAULT now() NOT NULL,
+	PRIMARY KEY (credential_id, role_id)
+);
+
+CREATE INDEX credential_role_role_id_idx ON credential_role (role_id);
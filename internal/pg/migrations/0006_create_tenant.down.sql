@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS credential_tenant_id_email_tmp_key;
+
+ALTER TABLE credential
+	DROP CONSTRAINT credential_tenant_id_email_key,
+	DROP COLUMN token_expires_at,
+	DROP COLUMN tenant_id,
+	ADD CONSTRAINT credential_email_key UNIQUE (email),
+	ADD CONSTRAINT credential_email_tmp_key UNIQUE (email_tmp);
+
+DROP TABLE IF EXISTS tenant;
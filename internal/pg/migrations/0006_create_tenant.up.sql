@@ -0,0 +1,23 @@
+CREATE TABLE tenant
+(
+	id VARCHAR(64) PRIMARY KEY,
+	name VARCHAR(255) NOT NULL DEFAULT '',
+	hosts VARCHAR(255)[] NOT NULL DEFAULT '{}',
+	settings JSONB NOT NULL DEFAULT '{}',
+	created_at timestamp with time zone DEFAULT now() NOT NULL,
+	updated_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+CREATE INDEX tenant_hosts_idx ON tenant USING GIN (hosts);
+
+INSERT INTO tenant (id, name) VALUES ('default', 'Default');
+
+ALTER TABLE credential
+	ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES tenant (id),
+	ADD COLUMN token_expires_at timestamp with time zone,
+	DROP CONSTRAINT credential_email_key,
+	DROP CONSTRAINT credential_email_tmp_key,
+	ADD CONSTRAINT credential_tenant_id_email_key UNIQUE (tenant_id, email);
+
+-- Empty email_tmp means there is no pending email change, so it must not be unique.
+CREATE UNIQUE INDEX credential_tenant_id_email_tmp_key ON credential (tenant_id, email_tmp) WHERE email_tmp <> '';
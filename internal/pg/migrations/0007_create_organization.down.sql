@@ -0,0 +1,6 @@
+ALTER TABLE credential
+	DROP COLUMN active_organization_id;
+
+DROP TABLE IF EXISTS invitation;
+DROP TABLE IF EXISTS membership;
+DROP TABLE IF EXISTS organization;
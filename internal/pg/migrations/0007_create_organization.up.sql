@@ -0,0 +1,38 @@
+CREATE TABLE organization
+(
+	id integer PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+	tenant_id VARCHAR(64) NOT NULL REFERENCES tenant (id),
+	name VARCHAR(255) NOT NULL,
+	created_at timestamp with time zone DEFAULT now() NOT NULL,
+	updated_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+CREATE INDEX organization_tenant_id_idx ON organization (tenant_id);
+
+CREATE TABLE membership
+(
+	organization_id integer NOT NULL REFERENCES organization (id) ON DELETE CASCADE,
+	credential_id integer NOT NULL REFERENCES credential (id) ON DELETE CASCADE,
+	role VARCHAR(32) NOT NULL,
+	created_at timestamp with time zone DEFAULT now() NOT NULL,
+	PRIMARY KEY (organization_id, credential_id)
+);
+
+CREATE INDEX membership_credential_id_idx ON membership (credential_id);
+
+CREATE TABLE invitation
+(
+	id integer PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+	organization_id integer NOT NULL REFERENCES organization (id) ON DELETE CASCADE,
+	email VARCHAR(255) NOT NULL,
+	role VARCHAR(32) NOT NULL,
+	invited_by integer NOT NULL,
+	expires_at timestamp with time zone NOT NULL,
+	accepted_at timestamp with time zone,
+	created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+CREATE INDEX invitation_organization_id_idx ON invitation (organization_id);
+
+ALTER TABLE credential
+	ADD COLUMN active_organization_id integer REFERENCES organization (id) ON DELETE SET NULL;
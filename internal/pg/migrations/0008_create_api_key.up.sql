@@ -0,0 +1,15 @@
+CREATE TABLE api_key
+(
+	id integer PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+	credential_id integer NOT NULL REFERENCES credential (id) ON DELETE CASCADE,
+	name VARCHAR(64) NOT NULL,
+	prefix VARCHAR(32) NOT NULL,
+	hash VARCHAR(128) NOT NULL,
+	scopes JSONB NOT NULL DEFAULT '[]',
+	expires_at timestamp with time zone,
+	last_used_at timestamp with time zone,
+	created_at timestamp with time zone DEFAULT now() NOT NULL,
+	UNIQUE (prefix)
+);
+
+CREATE INDEX api_key_credential_id_idx ON api_key (credential_id);
@@ -0,0 +1,10 @@
+CREATE TABLE profile
+(
+	credential_id integer PRIMARY KEY REFERENCES credential (id) ON DELETE CASCADE,
+	display_name VARCHAR(128) NOT NULL DEFAULT '',
+	locale VARCHAR(35) NOT NULL DEFAULT '',
+	timezone VARCHAR(64) NOT NULL DEFAULT '',
+	public_metadata JSONB NOT NULL DEFAULT '{}',
+	private_metadata JSONB NOT NULL DEFAULT '{}',
+	updated_at timestamp with time zone DEFAULT now() NOT NULL
+);
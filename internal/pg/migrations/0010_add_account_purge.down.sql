@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS credential_pending_deletion_idx;
+
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1 @@
+ALTER TABLE credential ALTER COLUMN password TYPE VARCHAR(128);
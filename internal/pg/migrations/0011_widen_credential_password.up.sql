@@ -0,0 +1,2 @@
+-- Imported legacy hashes carry their salts and parameters, so they are longer than hashes made here.
+ALTER TABLE credential ALTER COLUMN password TYPE VARCHAR(255);
@@ -0,0 +1,3 @@
+DROP TRIGGER credential_notify_change ON credential;
+
+DROP FUNCTION credential_notify_change();
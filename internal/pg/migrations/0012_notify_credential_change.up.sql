@@ -0,0 +1,12 @@
+-- Instances caching credentials evict the changed ones. Tokens are secrets, so only the tenant and the id
+-- are sent, they are enough to find a cached credential. Notifications are delivered on commit.
+CREATE FUNCTION credential_notify_change() RETURNS trigger AS $$
+BEGIN
+	PERFORM pg_notify('credential_changed', json_build_object('tenant_id', OLD.tenant_id, 'id', OLD.id)::text);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER credential_notify_change
+	AFTER UPDATE OR DELETE ON credential
+	FOR EACH ROW EXECUTE PROCEDURE credential_notify_change();
@@ -0,0 +1,28 @@
+ALTER TABLE credential_role
+	DROP CONSTRAINT credential_role_credential_id_tenant_id_fkey,
+	DROP COLUMN tenant_id;
+
+ALTER TABLE credential DROP CONSTRAINT credential_id_tenant_id_key;
+
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'UPDATE'
+		AND NEW.id = OLD.id
+		AND NEW.event = OLD.event
+		AND NEW.reason = OLD.reason
+		AND NEW.created_at = OLD.created_at
+		AND (NEW.credential_id IS NOT DISTINCT FROM OLD.credential_id OR NEW.credential_id IS NULL)
+		AND (NEW.ip = OLD.ip OR NEW.ip = '')
+		AND (NEW.user_agent = OLD.user_agent OR NEW.user_agent = '')
+		AND (NEW.actor = OLD.actor OR NEW.actor = 'deleted')
+	THEN
+		RETURN NEW;
+	END IF;
+
+	RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP INDEX audit_log_tenant_id_created_at_idx;
+
+ALTER TABLE audit_log DROP COLUMN tenant_id;
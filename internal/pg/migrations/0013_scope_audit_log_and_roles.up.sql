@@ -0,0 +1,46 @@
+-- Audit entries and role assignments belong to the tenant of their credential like the credential does.
+-- Entries without a credential can't be attributed, they stay in the default tenant.
+ALTER TABLE audit_log ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES tenant (id);
+
+ALTER TABLE audit_log DISABLE TRIGGER audit_log_append_only;
+
+UPDATE audit_log a SET tenant_id = c.tenant_id FROM credential c WHERE c.id = a.credential_id;
+
+ALTER TABLE audit_log ENABLE TRIGGER audit_log_append_only;
+
+ALTER TABLE audit_log ALTER COLUMN tenant_id DROP DEFAULT;
+
+CREATE INDEX audit_log_tenant_id_created_at_idx ON audit_log (tenant_id, created_at);
+
+-- Anonymization must not move entries between tenants.
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'UPDATE'
+		AND NEW.id = OLD.id
+		AND NEW.tenant_id = OLD.tenant_id
+		AND NEW.event = OLD.event
+		AND NEW.reason = OLD.reason
+		AND NEW.created_at = OLD.created_at
+		AND (NEW.credential_id IS NOT DISTINCT FROM OLD.credential_id OR NEW.credential_id IS NULL)
+		AND (NEW.ip = OLD.ip OR NEW.ip = '')
+		AND (NEW.user_agent = OLD.user_agent OR NEW.user_agent = '')
+		AND (NEW.actor = OLD.actor OR NEW.actor = 'deleted')
+	THEN
+		RETURN NEW;
+	END IF;
+
+	RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+-- The assignment references the credential with its tenant, so a role can't be assigned across tenants.
+ALTER TABLE credential ADD CONSTRAINT credential_id_tenant_id_key UNIQUE (id, tenant_id);
+
+ALTER TABLE credential_role ADD COLUMN tenant_id VARCHAR(64);
+
+UPDATE credential_role cr SET tenant_id = c.tenant_id FROM credential c WHERE c.id = cr.credential_id;
+
+ALTER TABLE credential_role
+	ALTER COLUMN tenant_id SET NOT NULL,
+	ADD CONSTRAINT credential_role_credential_id_tenant_id_fkey FOREIGN KEY (credential_id, tenant_id)
+		REFERENCES credential (id, tenant_id) ON DELETE CASCADE;
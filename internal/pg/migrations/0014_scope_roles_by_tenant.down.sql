@@ -0,0 +1,12 @@
+-- Roles of other tenants are deleted with their assignments, their names may clash with roles of the default one.
+DELETE FROM role WHERE tenant_id <> 'default';
+
+ALTER TABLE credential_role
+	DROP CONSTRAINT credential_role_role_id_tenant_id_fkey,
+	ADD CONSTRAINT credential_role_role_id_fkey FOREIGN KEY (role_id) REFERENCES role (id) ON DELETE CASCADE;
+
+ALTER TABLE role
+	DROP CONSTRAINT role_id_tenant_id_key,
+	DROP CONSTRAINT role_tenant_id_name_key,
+	ADD CONSTRAINT role_name_key UNIQUE (name),
+	DROP COLUMN tenant_id;
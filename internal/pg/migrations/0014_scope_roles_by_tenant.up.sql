@@ -0,0 +1,32 @@
+-- Roles belong to a tenant, so an admin of one tenant can't change permissions of another one.
+ALTER TABLE role
+	ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES tenant (id),
+	DROP CONSTRAINT role_name_key,
+	ADD CONSTRAINT role_tenant_id_name_key UNIQUE (tenant_id, name),
+	ADD CONSTRAINT role_id_tenant_id_key UNIQUE (id, tenant_id);
+
+-- Roles assigned in other tenants are copied into them with their permissions.
+INSERT INTO role (tenant_id, name, description, created_at)
+SELECT DISTINCT cr.tenant_id, r.name, r.description, r.created_at
+FROM credential_role cr
+JOIN role r ON r.id = cr.role_id
+WHERE cr.tenant_id <> r.tenant_id;
+
+INSERT INTO permission (role_id, action, resource)
+SELECT c.id, p.action, p.resource
+FROM role c
+JOIN role r ON r.name = c.name AND r.tenant_id = 'default'
+JOIN permission p ON p.role_id = r.id
+WHERE c.tenant_id <> 'default';
+
+UPDATE credential_role cr SET role_id = c.id
+FROM role r, role c
+WHERE r.id = cr.role_id AND c.tenant_id = cr.tenant_id AND c.name = r.name AND cr.tenant_id <> r.tenant_id;
+
+ALTER TABLE role ALTER COLUMN tenant_id DROP DEFAULT;
+
+-- The assignment references the role with its tenant, so a role can't be assigned across tenants.
+ALTER TABLE credential_role
+	DROP CONSTRAINT credential_role_role_id_fkey,
+	ADD CONSTRAINT credential_role_role_id_tenant_id_fkey FOREIGN KEY (role_id, tenant_id)
+		REFERENCES role (id, tenant_id) ON DELETE CASCADE;
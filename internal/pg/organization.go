@@ -0,0 +1,238 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	auth "github.com/kl09/auth-go"
+)
+
+// OrganizationRepository is a repository for organizations, their members and invitations.
+type OrganizationRepository struct {
+	*Client
+}
+
+// NewOrganizationRepository creates a new OrganizationRepository.
+func NewOrganizationRepository(c *Client) *OrganizationRepository {
+	return &OrganizationRepository{
+		c,
+	}
+}
+
+// Create creates a new Organization in the tenant of the context with the Credential as the owner.
+func (r *OrganizationRepository) Create(ctx context.Context, o *auth.Organization, ownerID int) error {
+	ctx, done := r.startQuery(ctx, stmtOrgCreate)
+
+	o.TenantID = tenantID(ctx)
+
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, stmtOrgCreate, o.TenantID, o.Name, o.CreatedAt, o.UpdatedAt).Scan(&o.ID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, stmtMembershipCreate, o.ID, ownerID, auth.OrgRoleOwner, o.CreatedAt)
+
+		return err
+	})
+	done(err)
+
+	return orgError(err)
+}
+
+// ByCredential returns memberships of a Credential ordered by organization id.
+func (r *OrganizationRepository) ByCredential(ctx context.Context, credID int) ([]auth.Membership, error) {
+	ctx, done := r.startQuery(ctx, stmtOrgByCredential)
+
+	memberships, err := r.memberships(ctx, credID)
+	done(err)
+
+	return memberships, orgError(err)
+}
+
+func (r *OrganizationRepository) memberships(ctx context.Context, credID int) ([]auth.Membership, error) {
+	rows, err := r.pool.Query(ctx, stmtOrgByCredential, tenantID(ctx), credID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	memberships := make([]auth.Membership, 0)
+
+	for rows.Next() {
+		m, err := scanMembership(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		memberships = append(memberships, m)
+	}
+
+	return memberships, rows.Err()
+}
+
+// Member returns a membership of a Credential in an Organization.
+func (r *OrganizationRepository) Member(ctx context.Context, orgID, credID int) (auth.Membership, error) {
+	ctx, done := r.startQuery(ctx, stmtOrgMember)
+
+	m, err := scanMembership(r.pool.QueryRow(ctx, stmtOrgMember, tenantID(ctx), orgID, credID))
+	done(err)
+
+	if err != nil {
+		return auth.Membership{}, orgError(err)
+	}
+
+	return m, nil
+}
+
+// CreateInvitation creates a new Invitation to an Organization of the tenant of the context.
+func (r *OrganizationRepository) CreateInvitation(ctx context.Context, i *auth.Invitation) error {
+	ctx, done := r.startQuery(ctx, stmtInvitationCreate)
+
+	err := r.pool.QueryRow(ctx, stmtInvitationCreate,
+		tenantID(ctx),
+		i.OrganizationID,
+		i.Email,
+		i.Role,
+		i.InvitedBy,
+		i.ExpiresAt,
+		i.CreatedAt,
+	).Scan(&i.ID)
+	done(err)
+
+	return orgError(err)
+}
+
+// Invitation returns an Invitation by id.
+func (r *OrganizationRepository) Invitation(ctx context.Context, id int) (auth.Invitation, error) {
+	ctx, done := r.startQuery(ctx, stmtInvitationByID)
+
+	var (
+		i          auth.Invitation
+		acceptedAt *time.Time
+	)
+
+	err := r.pool.QueryRow(ctx, stmtInvitationByID, tenantID(ctx), id).Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.Email,
+		&i.Role,
+		&i.InvitedBy,
+		&i.ExpiresAt,
+		&acceptedAt,
+		&i.CreatedAt,
+	)
+	done(err)
+
+	if err != nil {
+		return auth.Invitation{}, invitationError(err)
+	}
+
+	if acceptedAt != nil {
+		i.AcceptedAt = *acceptedAt
+	}
+
+	return i, nil
+}
+
+// AcceptInvitation marks a pending Invitation as accepted and makes the Credential a member
+// with the invited role, the role of an existing member isn't changed.
+func (r *OrganizationRepository) AcceptInvitation(
+	ctx context.Context,
+	id, credID int,
+	at time.Time,
+) (auth.Membership, error) {
+	ctx, done := r.startQuery(ctx, stmtInvitationAccept)
+
+	var m auth.Membership
+
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var (
+			orgID int
+			role  string
+		)
+
+		err := tx.QueryRow(ctx, stmtInvitationAccept, tenantID(ctx), id, at).Scan(&orgID, &role)
+		if err != nil {
+			return invitationError(err)
+		}
+
+		_, err = tx.Exec(ctx, stmtMembershipSave, orgID, credID, role, at)
+		if err != nil {
+			return err
+		}
+
+		m, err = scanMembership(tx.QueryRow(ctx, stmtOrgMember, tenantID(ctx), orgID, credID))
+
+		return err
+	})
+	done(err)
+
+	return m, orgError(err)
+}
+
+// DeleteInvitations deletes invitations to organizations of the tenant of the context addressed to the email.
+func (r *OrganizationRepository) DeleteInvitations(ctx context.Context, email string) error {
+	ctx, done := r.startQuery(ctx, stmtInvitationDelete)
+
+	_, err := r.pool.Exec(ctx, stmtInvitationDelete, tenantID(ctx), email)
+	done(err)
+
+	return queryError(err)
+}
+
+// scanMembership scans columns of membershipSelect.
+func scanMembership(row pgx.Row) (auth.Membership, error) {
+	var m auth.Membership
+
+	err := row.Scan(
+		&m.Organization.ID,
+		&m.Organization.TenantID,
+		&m.Organization.Name,
+		&m.Organization.CreatedAt,
+		&m.Organization.UpdatedAt,
+		&m.CredentialID,
+		&m.Role,
+		&m.CreatedAt,
+	)
+
+	return m, err
+}
+
+// orgError converts Postgres errors into auth errors.
+func orgError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var authErr auth.Error
+	if errors.As(err, &authErr) {
+		return err
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return auth.NewError(auth.ErrOrgNotFound, "Organization not found")
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation &&
+		pgErr.ConstraintName == "membership_credential_id_fkey" {
+		return auth.WrapError(err, auth.ErrCredNotFound, "Credential not found")
+	}
+
+	return queryError(err)
+}
+
+// invitationError converts Postgres errors of invitations into auth errors.
+func invitationError(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return auth.NewError(auth.ErrInvitationInvalid, "Invitation not found or already accepted.")
+	}
+
+	return queryError(err)
+}
@@ -0,0 +1,157 @@
+package pg_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/pg"
+)
+
+func TestOrganizationRepository(t *testing.T) {
+	c := setUp(t)
+	defer c.Close()
+
+	creds := pg.NewCredentialRepository(c)
+	r := pg.NewOrganizationRepository(c)
+	ctx := context.Background()
+
+	now := time.Date(2020, time.April, 15, 0, 0, 0, 0, time.UTC)
+	owner := auth.Credential{Password: "1", Token: "1", Email: "owner@example.org", CreatedAt: now, UpdatedAt: now}
+	require.Nil(t, creds.Create(ctx, &owner))
+
+	member := auth.Credential{Password: "2", Token: "2", Email: "member@example.org", CreatedAt: now, UpdatedAt: now}
+	require.Nil(t, creds.Create(ctx, &member))
+
+	acme := auth.Organization{Name: "Acme", CreatedAt: now, UpdatedAt: now}
+	require.Nil(t, r.Create(ctx, &acme, owner.ID))
+	assert.Equal(t, auth.DefaultTenantID, acme.TenantID)
+
+	ownership := auth.Membership{Organization: acme, CredentialID: owner.ID, Role: auth.OrgRoleOwner, CreatedAt: now}
+
+	m, err := r.Member(ctx, acme.ID, owner.ID)
+	require.Nil(t, err)
+
+	if diff := cmp.Diff(ownership, m); diff != "" {
+		t.Fatal(diff)
+	}
+
+	_, err = r.Member(ctx, acme.ID, member.ID)
+	assert.Equal(t, auth.NewError(auth.ErrOrgNotFound, "Organization not found"), err)
+
+	i := auth.Invitation{
+		OrganizationID: acme.ID,
+		Email:          member.Email,
+		Role:           auth.OrgRoleAdmin,
+		InvitedBy:      owner.ID,
+		ExpiresAt:      now.Add(time.Hour),
+		CreatedAt:      now,
+	}
+	require.Nil(t, r.CreateInvitation(ctx, &i))
+
+	found, err := r.Invitation(ctx, i.ID)
+	require.Nil(t, err)
+
+	if diff := cmp.Diff(i, found); diff != "" {
+		t.Fatal(diff)
+	}
+
+	err = r.CreateInvitation(ctx, &auth.Invitation{OrganizationID: acme.ID + 1, ExpiresAt: now, CreatedAt: now})
+	assert.Equal(t, auth.NewError(auth.ErrOrgNotFound, "Organization not found"), err)
+
+	m, err = r.AcceptInvitation(ctx, i.ID, member.ID, now.Add(time.Minute))
+	require.Nil(t, err)
+
+	membership := auth.Membership{Organization: acme, CredentialID: member.ID, Role: auth.OrgRoleAdmin, CreatedAt: now.Add(time.Minute)}
+	if diff := cmp.Diff(membership, m); diff != "" {
+		t.Fatal(diff)
+	}
+
+	_, err = r.AcceptInvitation(ctx, i.ID, member.ID, now.Add(time.Minute))
+	assert.Equal(t, auth.NewError(auth.ErrInvitationInvalid, "Invitation not found or already accepted."), err)
+
+	found, err = r.Invitation(ctx, i.ID)
+	require.Nil(t, err)
+	assert.Equal(t, now.Add(time.Minute), found.AcceptedAt.UTC())
+
+	memberships, err := r.ByCredential(ctx, member.ID)
+	require.Nil(t, err)
+
+	if diff := cmp.Diff([]auth.Membership{membership}, memberships); diff != "" {
+		t.Fatal(diff)
+	}
+
+	// Organizations of other tenants aren't visible.
+	shop := auth.Tenant{ID: "shop", CreatedAt: now, UpdatedAt: now}
+	require.Nil(t, pg.NewTenantRepository(c).Save(ctx, &shop))
+
+	shopCtx := auth.ContextWithTenant(ctx, shop)
+
+	_, err = r.Member(shopCtx, acme.ID, owner.ID)
+	assert.Equal(t, auth.NewError(auth.ErrOrgNotFound, "Organization not found"), err)
+
+	_, err = r.Invitation(shopCtx, i.ID)
+	assert.Equal(t, auth.NewError(auth.ErrInvitationInvalid, "Invitation not found or already accepted."), err)
+
+	// The active organization is stored with the credential.
+	member.ActiveOrganizationID = acme.ID
+	require.Nil(t, creds.Update(ctx, &member))
+
+	updated, err := creds.ByID(ctx, member.ID)
+	require.Nil(t, err)
+	assert.Equal(t, acme.ID, updated.ActiveOrganizationID)
+
+	member.ActiveOrganizationID = acme.ID + 1
+	err = creds.Update(ctx, &member)
+	assert.Equal(t, auth.ErrOrgNotFound, auth.ErrorCode(err))
+}
+
+func TestOrganizationRepository_DeleteInvitations(t *testing.T) {
+	c := setUp(t)
+	defer c.Close()
+
+	creds := pg.NewCredentialRepository(c)
+	r := pg.NewOrganizationRepository(c)
+	ctx := context.Background()
+
+	now := time.Date(2020, time.April, 15, 0, 0, 0, 0, time.UTC)
+	owner := auth.Credential{Password: "1", Token: "1", Email: "owner@example.org", CreatedAt: now, UpdatedAt: now}
+	require.Nil(t, creds.Create(ctx, &owner))
+
+	acme := auth.Organization{Name: "Acme", CreatedAt: now, UpdatedAt: now}
+	require.Nil(t, r.Create(ctx, &acme, owner.ID))
+
+	invitations := make([]auth.Invitation, 2)
+	for n, email := range []string{"member@example.org", "other@example.org"} {
+		invitations[n] = auth.Invitation{
+			OrganizationID: acme.ID,
+			Email:          email,
+			Role:           auth.OrgRoleMember,
+			InvitedBy:      owner.ID,
+			ExpiresAt:      now.Add(time.Hour),
+			CreatedAt:      now,
+		}
+		require.Nil(t, r.CreateInvitation(ctx, &invitations[n]))
+	}
+
+	// Invitations of other tenants aren't deleted.
+	shop := auth.Tenant{ID: "shop", CreatedAt: now, UpdatedAt: now}
+	require.Nil(t, pg.NewTenantRepository(c).Save(ctx, &shop))
+	require.Nil(t, r.DeleteInvitations(auth.ContextWithTenant(ctx, shop), "member@example.org"))
+
+	_, err := r.Invitation(ctx, invitations[0].ID)
+	require.Nil(t, err)
+
+	require.Nil(t, r.DeleteInvitations(ctx, "member@example.org"))
+
+	_, err = r.Invitation(ctx, invitations[0].ID)
+	assert.Equal(t, auth.ErrInvitationInvalid, auth.ErrorCode(err))
+
+	_, err = r.Invitation(ctx, invitations[1].ID)
+	require.Nil(t, err)
+}
@@ -0,0 +1,122 @@
+package pg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	auth "github.com/kl09/auth-go"
+)
+
+// ProfileRepository is a repository for profiles of credentials.
+type ProfileRepository struct {
+	*Client
+}
+
+// NewProfileRepository creates a new ProfileRepository.
+func NewProfileRepository(c *Client) *ProfileRepository {
+	return &ProfileRepository{
+		c,
+	}
+}
+
+// ByCredential returns the Profile of a Credential of the tenant of the context.
+func (r *ProfileRepository) ByCredential(ctx context.Context, credID int) (auth.Profile, error) {
+	ctx, done := r.startQuery(ctx, stmtProfileByCredential)
+
+	var (
+		p               auth.Profile
+		publicMetadata  []byte
+		privateMetadata []byte
+		updatedAt       *time.Time
+	)
+
+	err := r.pool.QueryRow(ctx, stmtProfileByCredential, tenantID(ctx), credID).Scan(
+		&p.CredentialID,
+		&p.DisplayName,
+		&p.Locale,
+		&p.Timezone,
+		&publicMetadata,
+		&privateMetadata,
+		&updatedAt,
+	)
+	done(err)
+
+	if err != nil {
+		return auth.Profile{}, profileError(err)
+	}
+
+	err = json.Unmarshal(publicMetadata, &p.PublicMetadata)
+	if err != nil {
+		return auth.Profile{}, err
+	}
+
+	err = json.Unmarshal(privateMetadata, &p.PrivateMetadata)
+	if err != nil {
+		return auth.Profile{}, err
+	}
+
+	if updatedAt != nil {
+		p.UpdatedAt = *updatedAt
+	}
+
+	return p, nil
+}
+
+// Save creates or updates the Profile of a Credential of the tenant of the context.
+func (r *ProfileRepository) Save(ctx context.Context, p *auth.Profile) error {
+	publicMetadata, err := marshalMetadata(p.PublicMetadata)
+	if err != nil {
+		return err
+	}
+
+	privateMetadata, err := marshalMetadata(p.PrivateMetadata)
+	if err != nil {
+		return err
+	}
+
+	ctx, done := r.startQuery(ctx, stmtProfileSave)
+
+	tag, err := r.pool.Exec(ctx, stmtProfileSave,
+		tenantID(ctx),
+		p.CredentialID,
+		p.DisplayName,
+		p.Locale,
+		p.Timezone,
+		publicMetadata,
+		privateMetadata,
+		p.UpdatedAt,
+	)
+	done(err)
+
+	if err == nil && tag.RowsAffected() == 0 {
+		err = pgx.ErrNoRows
+	}
+
+	return profileError(err)
+}
+
+// marshalMetadata encodes metadata as a JSON object, nil is encoded as an empty object.
+func marshalMetadata(m map[string]any) ([]byte, error) {
+	if m == nil {
+		return []byte("{}"), nil
+	}
+
+	return json.Marshal(m)
+}
+
+// profileError converts Postgres errors into auth errors.
+func profileError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return auth.NewError(auth.ErrCredNotFound, "Credential not found")
+	}
+
+	return queryError(err)
+}
@@ -0,0 +1,66 @@
+package pg_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/pg"
+)
+
+func TestProfileRepository(t *testing.T) {
+	c := setUp(t)
+	defer c.Close()
+
+	creds := pg.NewCredentialRepository(c)
+	r := pg.NewProfileRepository(c)
+	ctx := context.Background()
+
+	now := time.Date(2020, time.April, 15, 0, 0, 0, 0, time.UTC)
+	cred := auth.Credential{Password: "1", Token: "1", Email: "owner@example.org", CreatedAt: now, UpdatedAt: now}
+	require.Nil(t, creds.Create(ctx, &cred))
+
+	// A profile which was never saved is empty.
+	p, err := r.ByCredential(ctx, cred.ID)
+	require.Nil(t, err)
+
+	empty := auth.Profile{CredentialID: cred.ID, PublicMetadata: map[string]any{}, PrivateMetadata: map[string]any{}}
+	if diff := cmp.Diff(empty, p); diff != "" {
+		t.Fatal(diff)
+	}
+
+	p = auth.Profile{
+		CredentialID:    cred.ID,
+		DisplayName:     "Jane",
+		Locale:          "en-US",
+		Timezone:        "Europe/Berlin",
+		PublicMetadata:  map[string]any{"plan": "pro", "seats": float64(5)},
+		PrivateMetadata: map[string]any{"tags": []any{"vip"}},
+		UpdatedAt:       now,
+	}
+	require.Nil(t, r.Save(ctx, &p))
+
+	p.DisplayName = "Jane Doe"
+	p.UpdatedAt = now.Add(time.Minute)
+	require.Nil(t, r.Save(ctx, &p))
+
+	found, err := r.ByCredential(ctx, cred.ID)
+	require.Nil(t, err)
+
+	found.UpdatedAt = found.UpdatedAt.UTC()
+	if diff := cmp.Diff(p, found); diff != "" {
+		t.Fatal(diff)
+	}
+
+	// Profiles are scoped by the tenant of the context.
+	other := auth.ContextWithTenant(ctx, auth.Tenant{ID: "other"})
+
+	_, err = r.ByCredential(other, cred.ID)
+	assert.Equal(t, auth.NewError(auth.ErrCredNotFound, "Credential not found"), err)
+	assert.Equal(t, auth.NewError(auth.ErrCredNotFound, "Credential not found"), r.Save(other, &p))
+}
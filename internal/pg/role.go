@@ -0,0 +1,170 @@
+package pg
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	auth "github.com/kl09/auth-go"
+)
+
+// RoleRepository is a repository for roles of tenants and their assignments to credentials.
+type RoleRepository struct {
+	*Client
+}
+
+// NewRoleRepository creates a new RoleRepository.
+func NewRoleRepository(c *Client) *RoleRepository {
+	return &RoleRepository{
+		c,
+	}
+}
+
+// Roles returns all Roles of the tenant of the context ordered by name.
+func (r *RoleRepository) Roles(ctx context.Context) ([]auth.Role, error) {
+	return r.roles(ctx, stmtRoleAll, tenantID(ctx))
+}
+
+// ByCredential returns Roles assigned to a Credential of the tenant of the context ordered by name.
+func (r *RoleRepository) ByCredential(ctx context.Context, credID int) ([]auth.Role, error) {
+	return r.roles(ctx, stmtRoleByCredential, tenantID(ctx), credID)
+}
+
+// Create creates a new Role of the tenant of the context with its permissions.
+func (r *RoleRepository) Create(ctx context.Context, role *auth.Role) error {
+	ctx, done := r.startQuery(ctx, stmtRoleCreate)
+
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, stmtRoleCreate, tenantID(ctx), role.Name, role.Description, role.CreatedAt).Scan(&role.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range role.Permissions {
+			_, err = tx.Exec(ctx, stmtPermissionCreate, role.ID, p.Action, p.Resource)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	done(err)
+
+	return roleError(err)
+}
+
+// Delete deletes a Role of the tenant of the context by name with all its assignments.
+func (r *RoleRepository) Delete(ctx context.Context, name string) error {
+	ctx, done := r.startQuery(ctx, stmtRoleDelete)
+
+	tag, err := r.pool.Exec(ctx, stmtRoleDelete, tenantID(ctx), name)
+	done(err)
+
+	if err == nil && tag.RowsAffected() == 0 {
+		err = pgx.ErrNoRows
+	}
+
+	return roleError(err)
+}
+
+// Assign assigns a Role to a Credential, both of the tenant of the context.
+func (r *RoleRepository) Assign(ctx context.Context, credID int, name string) error {
+	ctx, done := r.startQuery(ctx, stmtRoleAssign)
+
+	err := r.assign(ctx, credID, name)
+	done(err)
+
+	return roleError(err)
+}
+
+func (r *RoleRepository) assign(ctx context.Context, credID int, name string) error {
+	var roleID int
+
+	err := r.pool.QueryRow(ctx, stmtRoleIDByName, tenantID(ctx), name).Scan(&roleID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.pool.Exec(ctx, stmtRoleAssign, tenantID(ctx), credID, roleID)
+
+	return err
+}
+
+// Unassign removes a Role from a Credential of the tenant of the context.
+func (r *RoleRepository) Unassign(ctx context.Context, credID int, name string) error {
+	ctx, done := r.startQuery(ctx, stmtRoleUnassign)
+
+	_, err := r.pool.Exec(ctx, stmtRoleUnassign, tenantID(ctx), credID, name)
+	done(err)
+
+	return roleError(err)
+}
+
+func (r *RoleRepository) roles(ctx context.Context, stmt string, args ...interface{}) ([]auth.Role, error) {
+	ctx, done := r.startQuery(ctx, stmt)
+
+	roles, err := r.scanRoles(ctx, stmt, args...)
+	done(err)
+
+	return roles, roleError(err)
+}
+
+func (r *RoleRepository) scanRoles(ctx context.Context, stmt string, args ...interface{}) ([]auth.Role, error) {
+	rows, err := r.pool.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := make([]auth.Role, 0)
+
+	for rows.Next() {
+		var (
+			role      auth.Role
+			actions   []string
+			resources []string
+		)
+
+		err = rows.Scan(&role.ID, &role.Name, &role.Description, &role.CreatedAt, &actions, &resources)
+		if err != nil {
+			return nil, err
+		}
+
+		role.Permissions = make([]auth.Permission, 0, len(actions))
+		for i := range actions {
+			role.Permissions = append(role.Permissions, auth.Permission{Action: actions[i], Resource: resources[i]})
+		}
+
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+// roleError converts Postgres errors into auth errors.
+func roleError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return auth.NewError(auth.ErrRoleNotFound, "Role not found")
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == pgerrcode.UniqueViolation && pgErr.ConstraintName == "role_tenant_id_name_key":
+			return auth.WrapError(err, auth.ErrRoleExists, "Role with this name already exists.")
+		case pgErr.Code == pgerrcode.ForeignKeyViolation && (pgErr.ConstraintName == "credential_role_credential_id_fkey" ||
+			pgErr.ConstraintName == "credential_role_credential_id_tenant_id_fkey"):
+			return auth.WrapError(err, auth.ErrCredNotFound, "Credential not found")
+		}
+	}
+
+	return queryError(err)
+}
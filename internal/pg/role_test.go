@@ -0,0 +1,130 @@
+package pg_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/pg"
+)
+
+func TestRoleRepository(t *testing.T) {
+	c := setUp(t)
+	defer c.Close()
+
+	creds := pg.NewCredentialRepository(c)
+	r := pg.NewRoleRepository(c)
+	ctx := context.Background()
+
+	now := time.Date(2020, time.April, 15, 0, 0, 0, 0, time.UTC)
+	cred := auth.Credential{
+		Password:  "12345",
+		Email:     "example@example.org",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	require.Nil(t, creds.Create(ctx, &cred))
+
+	editor := auth.Role{
+		Name:        "editor",
+		Description: "Edits orders",
+		Permissions: []auth.Permission{
+			{Action: "write", Resource: "orders"},
+			{Action: "read", Resource: "orders"},
+		},
+		CreatedAt: now,
+	}
+	require.Nil(t, r.Create(ctx, &editor))
+
+	empty := auth.Role{
+		Name:        "empty",
+		Permissions: []auth.Permission{},
+		CreatedAt:   now,
+	}
+	require.Nil(t, r.Create(ctx, &empty))
+
+	err := r.Create(ctx, &auth.Role{Name: "editor", CreatedAt: now})
+	assert.Equal(t, auth.ErrRoleExists, auth.ErrorCode(err))
+
+	// Permissions are returned ordered by resource and action.
+	editor.Permissions = []auth.Permission{
+		{Action: "read", Resource: "orders"},
+		{Action: "write", Resource: "orders"},
+	}
+
+	roles, err := r.Roles(ctx)
+	require.Nil(t, err)
+
+	if diff := cmp.Diff([]auth.Role{editor, empty}, roles); diff != "" {
+		t.Fatal(diff)
+	}
+
+	require.Nil(t, r.Assign(ctx, cred.ID, "editor"))
+	require.Nil(t, r.Assign(ctx, cred.ID, "editor"))
+
+	err = r.Assign(ctx, cred.ID, "unknown")
+	assert.Equal(t, auth.NewError(auth.ErrRoleNotFound, "Role not found"), err)
+
+	err = r.Assign(ctx, cred.ID+1, "editor")
+	assert.Equal(t, auth.ErrCredNotFound, auth.ErrorCode(err))
+
+	roles, err = r.ByCredential(ctx, cred.ID)
+	require.Nil(t, err)
+
+	if diff := cmp.Diff([]auth.Role{editor}, roles); diff != "" {
+		t.Fatal(diff)
+	}
+
+	// Roles and assignments are scoped by tenants, names are unique per tenant.
+	require.Nil(t, pg.NewTenantRepository(c).Save(ctx, &auth.Tenant{ID: "other", CreatedAt: now, UpdatedAt: now}))
+	other := auth.ContextWithTenant(ctx, auth.Tenant{ID: "other"})
+
+	err = r.Assign(other, cred.ID, "editor")
+	assert.Equal(t, auth.NewError(auth.ErrRoleNotFound, "Role not found"), err)
+
+	otherEditor := auth.Role{Name: "editor", Permissions: []auth.Permission{}, CreatedAt: now}
+	require.Nil(t, r.Create(other, &otherEditor))
+
+	roles, err = r.Roles(other)
+	require.Nil(t, err)
+
+	if diff := cmp.Diff([]auth.Role{otherEditor}, roles); diff != "" {
+		t.Fatal(diff)
+	}
+
+	err = r.Assign(other, cred.ID, "editor")
+	assert.Equal(t, auth.ErrCredNotFound, auth.ErrorCode(err))
+
+	roles, err = r.ByCredential(other, cred.ID)
+	require.Nil(t, err)
+	assert.Empty(t, roles)
+
+	require.Nil(t, r.Unassign(other, cred.ID, "editor"))
+	require.Nil(t, r.Delete(other, "editor"))
+
+	roles, err = r.ByCredential(ctx, cred.ID)
+	require.Nil(t, err)
+	assert.Len(t, roles, 1)
+
+	require.Nil(t, r.Unassign(ctx, cred.ID, "editor"))
+	require.Nil(t, r.Unassign(ctx, cred.ID, "editor"))
+
+	roles, err = r.ByCredential(ctx, cred.ID)
+	require.Nil(t, err)
+	assert.Empty(t, roles)
+
+	require.Nil(t, r.Assign(ctx, cred.ID, "editor"))
+	require.Nil(t, r.Delete(ctx, "editor"))
+
+	err = r.Delete(ctx, "editor")
+	assert.Equal(t, auth.NewError(auth.ErrRoleNotFound, "Role not found"), err)
+
+	roles, err = r.ByCredential(ctx, cred.ID)
+	require.Nil(t, err)
+	assert.Empty(t, roles)
+}
@@ -0,0 +1,204 @@
+package pg
+
+// Names of prepared statements.
+const (
+	stmtCredentialByToken = "credential_by_token"
+	stmtCredentialByID    = "credential_by_id"
+	stmtCredentialByEmail = "credential_by_email"
+	stmtCredentialCreate  = "credential_create"
+	stmtCredentialSearch  = "credential_search"
+	stmtCredentialUpdate  = "credential_update"
+	stmtCredentialDelete  = "credential_delete"
+
+	stmtCredentialPendingDeletion = "credential_pending_deletion"
+
+	stmtAuditAppend    = "audit_append"
+	stmtAuditFind      = "audit_find"
+	stmtAuditAnonymize = "audit_anonymize"
+
+	stmtRoleAll          = "role_all"
+	stmtRoleByCredential = "role_by_credential"
+	stmtRoleIDByName     = "role_id_by_name"
+	stmtRoleCreate       = "role_create"
+	stmtRoleDelete       = "role_delete"
+	stmtPermissionCreate = "permission_create"
+	stmtRoleAssign       = "role_assign"
+	stmtRoleUnassign     = "role_unassign"
+
+	stmtTenantByID   = "tenant_by_id"
+	stmtTenantByHost = "tenant_by_host"
+	stmtTenantAll    = "tenant_all"
+	stmtTenantSave   = "tenant_save"
+
+	stmtOrgCreate        = "org_create"
+	stmtMembershipCreate = "membership_create"
+	stmtOrgByCredential  = "org_by_credential"
+	stmtOrgMember        = "org_member"
+	stmtInvitationCreate = "invitation_create"
+	stmtInvitationByID   = "invitation_by_id"
+	stmtInvitationAccept = "invitation_accept"
+	stmtInvitationDelete = "invitation_delete"
+	stmtMembershipSave   = "membership_save"
+
+	stmtAPIKeyCreate       = "api_key_create"
+	stmtAPIKeyByPrefix     = "api_key_by_prefix"
+	stmtAPIKeyByCredential = "api_key_by_credential"
+	stmtAPIKeyTouch        = "api_key_touch"
+	stmtAPIKeyDelete       = "api_key_delete"
+
+	stmtProfileByCredential = "profile_by_credential"
+	stmtProfileSave         = "profile_save"
+)
+
+const credentialColumns = `id, tenant_id, password, token, token_expires_at, email, email_tmp, email_verified,
+	verification_code, verification_code_attempts, status, status_reason, status_until, active_organization_id,
+	created_at, updated_at`
+
+const tenantColumns = `id, name, hosts, settings, created_at, updated_at`
+
+const invitationColumns = `i.id, i.organization_id, i.email, i.role, i.invited_by, i.expires_at, i.accepted_at,
+	i.created_at`
+
+// membershipSelect selects memberships with their organizations.
+const membershipSelect = `SELECT o.id, o.tenant_id, o.name, o.created_at, o.updated_at, m.credential_id, m.role, m.created_at
+	FROM membership m
+	JOIN organization o ON o.id = m.organization_id`
+
+const apiKeyColumns = `k.id, k.credential_id, k.name, k.prefix, k.hash, k.scopes, k.expires_at, k.last_used_at,
+	k.created_at`
+
+const auditColumns = `id, COALESCE(credential_id, 0), event, ip, user_agent, reason, actor, created_at`
+
+// roleSelect selects roles with their permissions aggregated into arrays of actions and resources.
+const roleSelect = `SELECT r.id, r.name, r.description, r.created_at,
+	COALESCE(array_agg(p.action ORDER BY p.resource, p.action) FILTER (WHERE p.role_id IS NOT NULL), '{}'),
+	COALESCE(array_agg(p.resource ORDER BY p.resource, p.action) FILTER (WHERE p.role_id IS NOT NULL), '{}')
+	FROM role r
+	LEFT JOIN permission p ON p.role_id = r.id`
+
+// statements are prepared on every connection of the pool.
+var statements = map[string]string{
+	stmtCredentialByToken: `SELECT ` + credentialColumns + ` FROM credential WHERE tenant_id = $1 AND token = $2`,
+	stmtCredentialByID:    `SELECT ` + credentialColumns + ` FROM credential WHERE tenant_id = $1 AND id = $2`,
+	stmtCredentialByEmail: `SELECT ` + credentialColumns + ` FROM credential WHERE tenant_id = $1 AND email = $2`,
+	stmtCredentialCreate: `INSERT INTO credential (tenant_id, password, token, token_expires_at, email, email_tmp,
+	email_verified, verification_code, verification_code_attempts, status, status_reason, status_until,
+	active_organization_id, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NULLIF($13::integer, 0), $14, $15)
+	RETURNING id`,
+	stmtCredentialSearch: `SELECT ` + credentialColumns + ` FROM credential
+	WHERE tenant_id = $1
+	AND ($2::text = '' OR email ILIKE '%' || $2 || '%' OR email_tmp ILIKE '%' || $2 || '%')
+	ORDER BY id
+	LIMIT $3 OFFSET $4`,
+	stmtCredentialUpdate: `UPDATE credential SET password = $3, token = $4, token_expires_at = $5, email = $6,
+	email_tmp = $7, email_verified = $8, verification_code = $9, verification_code_attempts = $10,
+	status = $11, status_reason = $12, status_until = $13, active_organization_id = NULLIF($14::integer, 0),
+	updated_at = $15
+	WHERE tenant_id = $1 AND id = $2`,
+	stmtCredentialDelete: `DELETE FROM credential WHERE tenant_id = $1 AND id = $2`,
+	stmtCredentialPendingDeletion: `SELECT ` + credentialColumns + ` FROM credential
+	WHERE status = 'pending_deletion' AND status_until < $1
+	ORDER BY status_until
+	LIMIT $2`,
+
+	stmtAuditAppend: `INSERT INTO audit_log (tenant_id, credential_id, event, ip, user_agent, reason, actor, created_at)
+	VALUES ($1, NULLIF($2::integer, 0), $3, $4, $5, $6, $7, $8)
+	RETURNING id`,
+	stmtAuditFind: `SELECT ` + auditColumns + ` FROM audit_log
+	WHERE tenant_id = $1
+	AND ($2::integer = 0 OR credential_id = $2)
+	AND ($3::timestamptz IS NULL OR created_at >= $3)
+	AND ($4::timestamptz IS NULL OR created_at < $4)
+	ORDER BY created_at DESC, id DESC
+	LIMIT $5`,
+	stmtAuditAnonymize: `UPDATE audit_log SET
+	credential_id = CASE WHEN credential_id = $1 THEN NULL ELSE credential_id END,
+	ip = CASE WHEN credential_id = $1 THEN '' ELSE ip END,
+	user_agent = CASE WHEN credential_id = $1 THEN '' ELSE user_agent END,
+	actor = CASE WHEN actor = $2 THEN $3 ELSE actor END
+	WHERE credential_id = $1 OR actor = $2`,
+
+	stmtRoleAll: roleSelect + `
+	WHERE r.tenant_id = $1
+	GROUP BY r.id
+	ORDER BY r.name`,
+	stmtRoleByCredential: roleSelect + `
+	JOIN credential_role cr ON cr.role_id = r.id
+	WHERE cr.tenant_id = $1 AND cr.credential_id = $2
+	GROUP BY r.id
+	ORDER BY r.name`,
+	stmtRoleIDByName: `SELECT id FROM role WHERE tenant_id = $1 AND name = $2`,
+	stmtRoleCreate: `INSERT INTO role (tenant_id, name, description, created_at)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id`,
+	stmtRoleDelete:       `DELETE FROM role WHERE tenant_id = $1 AND name = $2`,
+	stmtPermissionCreate: `INSERT INTO permission (role_id, action, resource) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`,
+	stmtRoleAssign: `INSERT INTO credential_role (tenant_id, credential_id, role_id, created_at)
+	VALUES ($1, $2, $3, now())
+	ON CONFLICT DO NOTHING`,
+	stmtRoleUnassign: `DELETE FROM credential_role
+	WHERE tenant_id = $1 AND credential_id = $2 AND role_id = (SELECT id FROM role WHERE tenant_id = $1 AND name = $3)`,
+
+	stmtTenantByID:   `SELECT ` + tenantColumns + ` FROM tenant WHERE id = $1`,
+	stmtTenantByHost: `SELECT ` + tenantColumns + ` FROM tenant WHERE hosts @> ARRAY[$1::varchar]`,
+	stmtTenantAll:    `SELECT ` + tenantColumns + ` FROM tenant ORDER BY id`,
+	stmtTenantSave: `INSERT INTO tenant (id, name, hosts, settings, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (id) DO UPDATE SET name = $2, hosts = $3, settings = $4, updated_at = $6
+	RETURNING created_at`,
+
+	stmtOrgCreate: `INSERT INTO organization (tenant_id, name, created_at, updated_at)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id`,
+	stmtMembershipCreate: `INSERT INTO membership (organization_id, credential_id, role, created_at)
+	VALUES ($1, $2, $3, $4)`,
+	stmtOrgByCredential: membershipSelect + `
+	WHERE o.tenant_id = $1 AND m.credential_id = $2
+	ORDER BY o.id`,
+	stmtOrgMember: membershipSelect + `
+	WHERE o.tenant_id = $1 AND m.organization_id = $2 AND m.credential_id = $3`,
+	stmtInvitationCreate: `INSERT INTO invitation (organization_id, email, role, invited_by, expires_at, created_at)
+	SELECT id, $3, $4, $5, $6, $7 FROM organization WHERE tenant_id = $1 AND id = $2
+	RETURNING id`,
+	stmtInvitationByID: `SELECT ` + invitationColumns + ` FROM invitation i
+	JOIN organization o ON o.id = i.organization_id
+	WHERE o.tenant_id = $1 AND i.id = $2`,
+	stmtInvitationAccept: `UPDATE invitation i SET accepted_at = $3
+	FROM organization o
+	WHERE o.id = i.organization_id AND o.tenant_id = $1 AND i.id = $2 AND i.accepted_at IS NULL
+	RETURNING i.organization_id, i.role`,
+	stmtInvitationDelete: `DELETE FROM invitation i
+	USING organization o
+	WHERE o.id = i.organization_id AND o.tenant_id = $1 AND i.email = $2`,
+	stmtMembershipSave: `INSERT INTO membership (organization_id, credential_id, role, created_at)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT DO NOTHING`,
+
+	stmtAPIKeyCreate: `INSERT INTO api_key (credential_id, name, prefix, hash, scopes, expires_at, created_at)
+	SELECT id, $3, $4, $5, $6, $7, $8 FROM credential WHERE tenant_id = $1 AND id = $2
+	RETURNING id`,
+	stmtAPIKeyByPrefix: `SELECT ` + apiKeyColumns + ` FROM api_key k
+	JOIN credential c ON c.id = k.credential_id
+	WHERE c.tenant_id = $1 AND k.prefix = $2`,
+	stmtAPIKeyByCredential: `SELECT ` + apiKeyColumns + ` FROM api_key k
+	JOIN credential c ON c.id = k.credential_id
+	WHERE c.tenant_id = $1 AND k.credential_id = $2
+	ORDER BY k.id`,
+	stmtAPIKeyTouch: `UPDATE api_key SET last_used_at = $2 WHERE id = $1`,
+	stmtAPIKeyDelete: `DELETE FROM api_key k
+	USING credential c
+	WHERE c.id = k.credential_id AND c.tenant_id = $1 AND k.credential_id = $2 AND k.id = $3`,
+
+	stmtProfileByCredential: `SELECT c.id, COALESCE(p.display_name, ''), COALESCE(p.locale, ''), COALESCE(p.timezone, ''),
+	COALESCE(p.public_metadata, '{}'), COALESCE(p.private_metadata, '{}'), p.updated_at
+	FROM credential c
+	LEFT JOIN profile p ON p.credential_id = c.id
+	WHERE c.tenant_id = $1 AND c.id = $2`,
+	stmtProfileSave: `INSERT INTO profile (credential_id, display_name, locale, timezone, public_metadata, private_metadata,
+	updated_at)
+	SELECT id, $3, $4, $5, $6, $7, $8 FROM credential WHERE tenant_id = $1 AND id = $2
+	ON CONFLICT (credential_id) DO UPDATE SET display_name = EXCLUDED.display_name, locale = EXCLUDED.locale,
+	timezone = EXCLUDED.timezone, public_metadata = EXCLUDED.public_metadata,
+	private_metadata = EXCLUDED.private_metadata, updated_at = EXCLUDED.updated_at`,
+}
@@ -0,0 +1,187 @@
+package pg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	auth "github.com/kl09/auth-go"
+)
+
+// TenantRepository is a repository for tenants.
+type TenantRepository struct {
+	*Client
+}
+
+// NewTenantRepository creates a new TenantRepository.
+func NewTenantRepository(c *Client) *TenantRepository {
+	return &TenantRepository{
+		c,
+	}
+}
+
+// tenantSettings is the JSON representation of auth.TenantSettings.
+type tenantSettings struct {
+	PasswordPolicy       passwordPolicy          `json:"password_policy"`
+	TokenTTLSeconds      int64                   `json:"token_ttl_seconds,omitempty"`
+	InvitationTTLSeconds int64                   `json:"invitation_ttl_seconds,omitempty"`
+	MailTemplates        map[string]mailTemplate `json:"mail_templates,omitempty"`
+	ProfileSchema        map[string]string       `json:"profile_schema,omitempty"`
+}
+
+type passwordPolicy struct {
+	MinLength     int  `json:"min_length,omitempty"`
+	MaxLength     int  `json:"max_length,omitempty"`
+	RequireUpper  bool `json:"require_upper,omitempty"`
+	RequireLower  bool `json:"require_lower,omitempty"`
+	RequireDigit  bool `json:"require_digit,omitempty"`
+	RequireSymbol bool `json:"require_symbol,omitempty"`
+}
+
+type mailTemplate struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// ByID returns a Tenant by id.
+func (r *TenantRepository) ByID(ctx context.Context, id string) (auth.Tenant, error) {
+	return r.tenant(ctx, stmtTenantByID, id)
+}
+
+// ByHost returns a Tenant by one of its hosts.
+func (r *TenantRepository) ByHost(ctx context.Context, host string) (auth.Tenant, error) {
+	return r.tenant(ctx, stmtTenantByHost, host)
+}
+
+// Tenants returns all Tenants ordered by id.
+func (r *TenantRepository) Tenants(ctx context.Context) ([]auth.Tenant, error) {
+	ctx, done := r.startQuery(ctx, stmtTenantAll)
+
+	tenants, err := r.tenants(ctx)
+	done(err)
+
+	return tenants, tenantError(err)
+}
+
+func (r *TenantRepository) tenants(ctx context.Context) ([]auth.Tenant, error) {
+	rows, err := r.pool.Query(ctx, stmtTenantAll)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tenants := make([]auth.Tenant, 0)
+
+	for rows.Next() {
+		t, err := scanTenant(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		tenants = append(tenants, t)
+	}
+
+	return tenants, rows.Err()
+}
+
+// Save creates or updates a Tenant.
+func (r *TenantRepository) Save(ctx context.Context, t *auth.Tenant) error {
+	s := tenantSettings{
+		PasswordPolicy:       passwordPolicy(t.Settings.PasswordPolicy),
+		TokenTTLSeconds:      int64(t.Settings.TokenTTL / time.Second),
+		InvitationTTLSeconds: int64(t.Settings.InvitationTTL / time.Second),
+		ProfileSchema:        t.Settings.ProfileSchema,
+	}
+
+	if len(t.Settings.MailTemplates) > 0 {
+		s.MailTemplates = make(map[string]mailTemplate, len(t.Settings.MailTemplates))
+		for name, tmpl := range t.Settings.MailTemplates {
+			s.MailTemplates[name] = mailTemplate(tmpl)
+		}
+	}
+
+	settings, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	hosts := t.Hosts
+	if hosts == nil {
+		hosts = []string{}
+	}
+
+	ctx, done := r.startQuery(ctx, stmtTenantSave)
+
+	err = r.pool.QueryRow(ctx, stmtTenantSave, t.ID, t.Name, hosts, settings, t.CreatedAt, t.UpdatedAt).
+		Scan(&t.CreatedAt)
+	done(err)
+
+	return tenantError(err)
+}
+
+func (r *TenantRepository) tenant(ctx context.Context, stmt string, arg interface{}) (auth.Tenant, error) {
+	ctx, done := r.startQuery(ctx, stmt)
+
+	t, err := scanTenant(r.pool.QueryRow(ctx, stmt, arg))
+	done(err)
+
+	if err != nil {
+		return auth.Tenant{}, tenantError(err)
+	}
+
+	return t, nil
+}
+
+// scanTenant scans tenantColumns of a row.
+func scanTenant(row pgx.Row) (auth.Tenant, error) {
+	var (
+		t        auth.Tenant
+		settings []byte
+		s        tenantSettings
+	)
+
+	err := row.Scan(&t.ID, &t.Name, &t.Hosts, &settings, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return auth.Tenant{}, err
+	}
+
+	err = json.Unmarshal(settings, &s)
+	if err != nil {
+		return auth.Tenant{}, err
+	}
+
+	t.Settings = auth.TenantSettings{
+		PasswordPolicy: auth.PasswordPolicy(s.PasswordPolicy),
+		TokenTTL:       time.Duration(s.TokenTTLSeconds) * time.Second,
+		InvitationTTL:  time.Duration(s.InvitationTTLSeconds) * time.Second,
+	}
+
+	if len(s.ProfileSchema) > 0 {
+		t.Settings.ProfileSchema = s.ProfileSchema
+	}
+
+	if len(s.MailTemplates) > 0 {
+		t.Settings.MailTemplates = make(map[string]auth.MailTemplate, len(s.MailTemplates))
+		for name, tmpl := range s.MailTemplates {
+			t.Settings.MailTemplates[name] = auth.MailTemplate(tmpl)
+		}
+	}
+
+	return t, nil
+}
+
+// tenantError converts Postgres errors into auth errors.
+func tenantError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return auth.NewError(auth.ErrTenantNotFound, "Tenant not found")
+	}
+
+	return queryError(err)
+}
@@ -0,0 +1,79 @@
+package pg_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/pg"
+)
+
+func TestTenantRepository(t *testing.T) {
+	c := setUp(t)
+	defer c.Close()
+
+	r := pg.NewTenantRepository(c)
+	ctx := context.Background()
+
+	now := time.Date(2020, time.April, 15, 0, 0, 0, 0, time.UTC)
+	shop := auth.Tenant{
+		ID:    "shop",
+		Name:  "Shop",
+		Hosts: []string{"shop.example.org", "shop.example.com"},
+		Settings: auth.TenantSettings{
+			PasswordPolicy: auth.PasswordPolicy{MinLength: 8, RequireDigit: true},
+			TokenTTL:       time.Hour,
+			MailTemplates: map[string]auth.MailTemplate{
+				"verify_email": {Subject: "Verify", Body: "Code: {{.Code}}"},
+			},
+			ProfileSchema: auth.ProfileSchema{"plan": auth.MetadataString},
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	require.Nil(t, r.Save(ctx, &shop))
+
+	found, err := r.ByID(ctx, "shop")
+	require.Nil(t, err)
+
+	if diff := cmp.Diff(shop, found); diff != "" {
+		t.Fatal(diff)
+	}
+
+	found, err = r.ByHost(ctx, "shop.example.com")
+	require.Nil(t, err)
+
+	if diff := cmp.Diff(shop, found); diff != "" {
+		t.Fatal(diff)
+	}
+
+	_, err = r.ByHost(ctx, "unknown.example.com")
+	assert.Equal(t, auth.NewError(auth.ErrTenantNotFound, "Tenant not found"), err)
+
+	_, err = r.ByID(ctx, "unknown")
+	assert.Equal(t, auth.NewError(auth.ErrTenantNotFound, "Tenant not found"), err)
+
+	// Save keeps the creation time of an existing tenant.
+	shop.Hosts = []string{"shop.example.org"}
+	shop.Settings = auth.TenantSettings{}
+	shop.CreatedAt = now.Add(time.Hour)
+	shop.UpdatedAt = now.Add(time.Hour)
+	require.Nil(t, r.Save(ctx, &shop))
+	assert.Equal(t, now, shop.CreatedAt.UTC())
+
+	tenants, err := r.Tenants(ctx)
+	require.Nil(t, err)
+	require.Len(t, tenants, 2)
+
+	assert.Equal(t, auth.DefaultTenantID, tenants[0].ID)
+
+	shop.CreatedAt = now
+	if diff := cmp.Diff(shop, tenants[1]); diff != "" {
+		t.Fatal(diff)
+	}
+}
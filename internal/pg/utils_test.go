@@ -1,11 +1,13 @@
 package pg_test
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
 	"os"
 	"testing"
 
+	"github.com/jackc/pgx/v5"
+
 	"github.com/kl09/auth-go/internal/pg"
 )
 
@@ -25,7 +27,7 @@ var (
 		PostgresUser, PostgresPassword, PostgresHost, PostgresPort, PostgresDBTest, PostgresConnectTimeout)
 )
 
-func setUp(t *testing.T) *pg.Client {
+func setUp(t testing.TB) *pg.Client {
 	t.Helper()
 
 	clearSQLDb(t)
@@ -35,27 +37,29 @@ func setUp(t *testing.T) *pg.Client {
 		t.Fatal(err)
 	}
 	// Create schema
-	if err := client.Schema(); err != nil {
+	if _, err := pg.NewMigrator(client).Up(context.Background()); err != nil {
 		t.Fatal(err)
 	}
 
 	return client
 }
 
-func clearSQLDb(t *testing.T) {
+func clearSQLDb(t testing.TB) {
 	var err error
 
-	pool, err := sql.Open("postgres", PostgresSys)
+	ctx := context.Background()
+
+	conn, err := pgx.Connect(ctx, PostgresSys)
 	if err != nil {
 		t.Fatal("can't connect to db")
 	}
-	defer func() { _ = pool.Close() }()
+	defer func() { _ = conn.Close(ctx) }()
 
-	_, err = pool.Exec("DROP DATABASE IF EXISTS " + PostgresDBTest)
+	_, err = conn.Exec(ctx, "DROP DATABASE IF EXISTS "+PostgresDBTest)
 	if err != nil {
 		t.Fatal(err)
 	}
-	_, err = pool.Exec("CREATE DATABASE " + PostgresDBTest)
+	_, err = conn.Exec(ctx, "CREATE DATABASE "+PostgresDBTest)
 	if err != nil {
 		t.Fatal(err)
 	}
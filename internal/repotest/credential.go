@@ -0,0 +1,509 @@
+// Package repotest is a conformance suite of repositories, every implementation runs it in its tests,
+// so all of them behave the same way.
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	auth "github.com/kl09/auth-go"
+)
+
+// OtherTenantID is a tenant the suite uses besides the default one.
+const OtherTenantID = "repotest"
+
+// NewCredentialRepository returns an empty repository for a test,
+// the default tenant and the OtherTenantID one must exist in its storage.
+type NewCredentialRepository func(t *testing.T) auth.CredentialRepository
+
+// now is truncated to microseconds, the precision of timestamps in Postgres.
+var now = time.Date(2020, time.April, 15, 10, 11, 12, 123456000, time.UTC)
+
+// TestCredentialRepository runs the conformance suite of auth.CredentialRepository,
+// every case gets a new repository.
+func TestCredentialRepository(t *testing.T, newRepo NewCredentialRepository) {
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, r auth.CredentialRepository)
+	}{
+		{name: "not found", fn: testNotFound},
+		{name: "create and get", fn: testCreateAndGet},
+		{name: "unique", fn: testUnique},
+		{name: "timestamps", fn: testTimestamps},
+		{name: "update", fn: testUpdate},
+		{name: "delete", fn: testDelete},
+		{name: "search", fn: testSearch},
+		{name: "tenant isolation", fn: testTenantIsolation},
+		{name: "pending deletion", fn: testPendingDeletion},
+		{name: "concurrent creates", fn: testConcurrentCreates},
+		{name: "timeout", fn: testTimeout},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.fn(t, newRepo(t))
+		})
+	}
+}
+
+func testNotFound(t *testing.T, r auth.CredentialRepository) {
+	ctx := context.Background()
+	require.Nil(t, r.Create(ctx, newCredential(1)))
+
+	errs := map[string]error{}
+
+	_, errs["by token"] = r.ByToken(ctx, "unknown")
+	_, errs["by id"] = r.ByID(ctx, 100)
+	_, errs["by email"] = r.ByEmail(ctx, "unknown@example.org")
+	errs["update"] = r.Update(ctx, &auth.Credential{ID: 100, Email: "unknown@example.org", Token: "unknown"})
+	errs["delete"] = r.Delete(ctx, 100)
+
+	for name, err := range errs {
+		assert.Equal(t, auth.ErrCredNotFound, auth.ErrorCode(err), name)
+	}
+}
+
+func testCreateAndGet(t *testing.T, r auth.CredentialRepository) {
+	ctx := context.Background()
+
+	cred := auth.Credential{
+		Password:                 "hash",
+		Token:                    "token",
+		TokenExpiresAt:           now.Add(time.Hour),
+		Email:                    "example@example.org",
+		EmailTmp:                 "new@example.org",
+		EmailVerified:            true,
+		VerificationCode:         "code",
+		VerificationCodeAttempts: 2,
+		StatusReason:             "reason",
+		CreatedAt:                now,
+		UpdatedAt:                now,
+	}
+	require.Nil(t, r.Create(ctx, &cred))
+	require.NotZero(t, cred.ID)
+	assert.Equal(t, auth.DefaultTenantID, cred.TenantID)
+
+	// An empty status is active.
+	want := cred
+	want.Status = auth.StatusActive
+
+	got, err := r.ByID(ctx, cred.ID)
+	require.Nil(t, err)
+	assertCredential(t, want, got)
+
+	got, err = r.ByToken(ctx, "token")
+	require.Nil(t, err)
+	assertCredential(t, want, got)
+
+	got, err = r.ByEmail(ctx, "example@example.org")
+	require.Nil(t, err)
+	assertCredential(t, want, got)
+
+	// A pending email change isn't the email.
+	_, err = r.ByEmail(ctx, "new@example.org")
+	assert.Equal(t, auth.ErrCredNotFound, auth.ErrorCode(err))
+}
+
+func testUnique(t *testing.T, r auth.CredentialRepository) {
+	ctx := context.Background()
+	other := auth.ContextWithTenant(ctx, auth.Tenant{ID: OtherTenantID})
+
+	first := newCredential(1)
+	first.EmailTmp = "new@example.org"
+	require.Nil(t, r.Create(ctx, first))
+	require.Nil(t, r.Create(ctx, newCredential(2)))
+
+	cases := []struct {
+		name     string
+		ctx      context.Context
+		cred     func(c *auth.Credential)
+		wantCode string
+	}{
+		{
+			name:     "email",
+			ctx:      ctx,
+			cred:     func(c *auth.Credential) { c.Email = first.Email },
+			wantCode: auth.ErrEmailExists,
+		},
+		{
+			name:     "email change",
+			ctx:      ctx,
+			cred:     func(c *auth.Credential) { c.EmailTmp = first.EmailTmp },
+			wantCode: auth.ErrEmailExists,
+		},
+		{
+			name:     "token",
+			ctx:      ctx,
+			cred:     func(c *auth.Credential) { c.Token = first.Token },
+			wantCode: auth.ErrInternal,
+		},
+		{
+			name:     "token of another tenant",
+			ctx:      other,
+			cred:     func(c *auth.Credential) { c.Token = first.Token },
+			wantCode: auth.ErrInternal,
+		},
+		{
+			name: "email of another tenant",
+			ctx:  other,
+			cred: func(c *auth.Credential) {
+				c.Email = first.Email
+				c.EmailTmp = first.EmailTmp
+			},
+		},
+		{
+			name: "no email change",
+			ctx:  ctx,
+			cred: func(c *auth.Credential) {},
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newCredential(10 + i)
+			tc.cred(c)
+
+			err := r.Create(tc.ctx, c)
+			if tc.wantCode == "" {
+				require.Nil(t, err)
+				return
+			}
+
+			require.NotNil(t, err)
+			assert.Equal(t, tc.wantCode, auth.ErrorCode(err))
+		})
+	}
+
+	// A Credential doesn't conflict with itself, but does with others on update.
+	second, err := r.ByID(ctx, 2)
+	require.Nil(t, err)
+
+	second.EmailVerified = true
+	require.Nil(t, r.Update(ctx, &second))
+
+	second.Email = first.Email
+	assert.Equal(t, auth.ErrEmailExists, auth.ErrorCode(r.Update(ctx, &second)))
+}
+
+func testTimestamps(t *testing.T, r auth.CredentialRepository) {
+	ctx := context.Background()
+
+	local := time.FixedZone("UTC+3", 3*60*60)
+
+	cred := newCredential(1)
+	cred.CreatedAt = now.In(local)
+	cred.UpdatedAt = now.In(local)
+	cred.TokenExpiresAt = now.Add(time.Hour).In(local)
+	cred.Status = auth.StatusSuspended
+	cred.StatusUntil = now.Add(2 * time.Hour)
+	require.Nil(t, r.Create(ctx, cred))
+
+	got, err := r.ByID(ctx, cred.ID)
+	require.Nil(t, err)
+	assert.True(t, now.Equal(got.CreatedAt), got.CreatedAt)
+	assert.True(t, now.Equal(got.UpdatedAt), got.UpdatedAt)
+	assert.True(t, now.Add(time.Hour).Equal(got.TokenExpiresAt), got.TokenExpiresAt)
+	assert.True(t, now.Add(2*time.Hour).Equal(got.StatusUntil), got.StatusUntil)
+
+	// Zero times stay zero.
+	got.TokenExpiresAt = time.Time{}
+	got.StatusUntil = time.Time{}
+	got.Status = auth.StatusActive
+	got.CreatedAt = now.Add(time.Hour)
+	got.UpdatedAt = now.Add(time.Hour)
+	require.Nil(t, r.Update(ctx, &got))
+
+	got, err = r.ByID(ctx, cred.ID)
+	require.Nil(t, err)
+	assert.True(t, got.TokenExpiresAt.IsZero(), got.TokenExpiresAt)
+	assert.True(t, got.StatusUntil.IsZero(), got.StatusUntil)
+	// The creation time isn't updated.
+	assert.True(t, now.Equal(got.CreatedAt), got.CreatedAt)
+	assert.True(t, now.Add(time.Hour).Equal(got.UpdatedAt), got.UpdatedAt)
+}
+
+func testUpdate(t *testing.T, r auth.CredentialRepository) {
+	ctx := context.Background()
+
+	cred := newCredential(1)
+	require.Nil(t, r.Create(ctx, cred))
+	require.Nil(t, r.Create(ctx, newCredential(2)))
+
+	updated := *cred
+	updated.Password = "new_hash"
+	updated.Token = "new_token"
+	updated.Email = "changed@example.org"
+	updated.EmailVerified = true
+	updated.Status = auth.StatusDisabled
+	updated.StatusReason = "fraud"
+	updated.UpdatedAt = now.Add(time.Hour)
+	require.Nil(t, r.Update(ctx, &updated))
+
+	got, err := r.ByID(ctx, cred.ID)
+	require.Nil(t, err)
+	assertCredential(t, updated, got)
+
+	_, err = r.ByToken(ctx, cred.Token)
+	assert.Equal(t, auth.ErrCredNotFound, auth.ErrorCode(err))
+
+	// Others aren't changed.
+	got, err = r.ByID(ctx, 2)
+	require.Nil(t, err)
+	assert.Equal(t, newCredential(2).Email, got.Email)
+}
+
+func testDelete(t *testing.T, r auth.CredentialRepository) {
+	ctx := context.Background()
+
+	cred := newCredential(1)
+	require.Nil(t, r.Create(ctx, cred))
+	require.Nil(t, r.Create(ctx, newCredential(2)))
+
+	require.Nil(t, r.Delete(ctx, cred.ID))
+
+	_, err := r.ByID(ctx, cred.ID)
+	assert.Equal(t, auth.ErrCredNotFound, auth.ErrorCode(err))
+	assert.Equal(t, auth.ErrCredNotFound, auth.ErrorCode(r.Delete(ctx, cred.ID)))
+
+	// The email and the token are free after the deletion.
+	require.Nil(t, r.Create(ctx, newCredential(1)))
+
+	_, err = r.ByID(ctx, 2)
+	require.Nil(t, err)
+}
+
+func testSearch(t *testing.T, r auth.CredentialRepository) {
+	ctx := context.Background()
+
+	var ids []int
+
+	for i := 1; i <= 5; i++ {
+		c := newCredential(i)
+		require.Nil(t, r.Create(ctx, c))
+
+		ids = append(ids, c.ID)
+	}
+
+	c := newCredential(6)
+	c.Email = "other@example.com"
+	c.EmailTmp = "New@Example.org"
+	require.Nil(t, r.Create(ctx, c))
+
+	ids = append(ids, c.ID)
+
+	other := newCredential(1)
+	other.Token = "other_tenant"
+	require.Nil(t, r.Create(auth.ContextWithTenant(ctx, auth.Tenant{ID: OtherTenantID}), other))
+
+	cases := []struct {
+		name    string
+		filter  auth.CredentialFilter
+		wantIDs []int
+	}{
+		{
+			name:    "all of the tenant",
+			filter:  auth.CredentialFilter{},
+			wantIDs: ids,
+		},
+		{
+			name:    "email case insensitive with offset",
+			filter:  auth.CredentialFilter{Email: "USER", Limit: 2, Offset: 1},
+			wantIDs: ids[1:3],
+		},
+		{
+			name:    "email change",
+			filter:  auth.CredentialFilter{Email: "new@"},
+			wantIDs: ids[5:],
+		},
+		{
+			name:    "wildcards are literal",
+			filter:  auth.CredentialFilter{Email: "user_%"},
+			wantIDs: []int{},
+		},
+		{
+			name:    "offset after the end",
+			filter:  auth.CredentialFilter{Offset: 10},
+			wantIDs: []int{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			creds, err := r.Search(ctx, tc.filter)
+			require.Nil(t, err)
+
+			got := make([]int, 0, len(creds))
+			for _, c := range creds {
+				got = append(got, c.ID)
+			}
+
+			assert.Equal(t, tc.wantIDs, got)
+		})
+	}
+}
+
+func testTenantIsolation(t *testing.T, r auth.CredentialRepository) {
+	ctx := context.Background()
+	other := auth.ContextWithTenant(ctx, auth.Tenant{ID: OtherTenantID})
+
+	cred := newCredential(1)
+	require.Nil(t, r.Create(ctx, cred))
+
+	_, err := r.ByID(other, cred.ID)
+	assert.Equal(t, auth.ErrCredNotFound, auth.ErrorCode(err))
+
+	_, err = r.ByToken(other, cred.Token)
+	assert.Equal(t, auth.ErrCredNotFound, auth.ErrorCode(err))
+
+	_, err = r.ByEmail(other, cred.Email)
+	assert.Equal(t, auth.ErrCredNotFound, auth.ErrorCode(err))
+
+	updated := *cred
+	updated.Password = "new_hash"
+	assert.Equal(t, auth.ErrCredNotFound, auth.ErrorCode(r.Update(other, &updated)))
+	assert.Equal(t, auth.ErrCredNotFound, auth.ErrorCode(r.Delete(other, cred.ID)))
+
+	got, err := r.ByID(ctx, cred.ID)
+	require.Nil(t, err)
+	assert.Equal(t, "hash", got.Password)
+
+	otherCred := newCredential(2)
+	require.Nil(t, r.Create(other, otherCred))
+	assert.Equal(t, OtherTenantID, otherCred.TenantID)
+
+	got, err = r.ByEmail(other, otherCred.Email)
+	require.Nil(t, err)
+	assert.Equal(t, OtherTenantID, got.TenantID)
+}
+
+func testPendingDeletion(t *testing.T, r auth.CredentialRepository) {
+	ctx := context.Background()
+
+	cases := []struct {
+		tenantID string
+		status   string
+		until    time.Time
+	}{
+		{tenantID: auth.DefaultTenantID, status: auth.StatusPendingDeletion, until: now.Add(-time.Hour)},
+		{tenantID: auth.DefaultTenantID, status: auth.StatusPendingDeletion, until: now.Add(time.Hour)},
+		{tenantID: auth.DefaultTenantID, status: auth.StatusSuspended, until: now.Add(-time.Hour)},
+		{tenantID: OtherTenantID, status: auth.StatusPendingDeletion, until: now.Add(-2 * time.Hour)},
+	}
+
+	ids := make([]int, len(cases))
+
+	for i, tc := range cases {
+		c := newCredential(i)
+		c.Status = tc.status
+		c.StatusUntil = tc.until
+		require.Nil(t, r.Create(auth.ContextWithTenant(ctx, auth.Tenant{ID: tc.tenantID}), c))
+
+		ids[i] = c.ID
+	}
+
+	creds, err := r.PendingDeletion(ctx, now, 10)
+	require.Nil(t, err)
+	require.Len(t, creds, 2)
+	assert.Equal(t, ids[3], creds[0].ID)
+	assert.Equal(t, OtherTenantID, creds[0].TenantID)
+	assert.Equal(t, ids[0], creds[1].ID)
+
+	creds, err = r.PendingDeletion(ctx, now, 1)
+	require.Nil(t, err)
+	require.Len(t, creds, 1)
+	assert.Equal(t, ids[3], creds[0].ID)
+}
+
+func testConcurrentCreates(t *testing.T, r auth.CredentialRepository) {
+	ctx := context.Background()
+
+	const n = 20
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		ids  = make(map[int]bool)
+		errs []error
+	)
+
+	// Every email is created twice concurrently, only one of the creates succeeds.
+	for i := 0; i < 2*n; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			c := newCredential(i / 2)
+			c.Token = fmt.Sprintf("token%d", i)
+
+			err := r.Create(ctx, c)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+
+			ids[c.ID] = true
+		}(i)
+	}
+
+	wg.Wait()
+
+	assert.Len(t, ids, n)
+	require.Len(t, errs, n)
+
+	for _, err := range errs {
+		assert.Equal(t, auth.ErrEmailExists, auth.ErrorCode(err))
+	}
+
+	creds, err := r.Search(ctx, auth.CredentialFilter{Limit: 2 * n})
+	require.Nil(t, err)
+	assert.Len(t, creds, n)
+}
+
+func testTimeout(t *testing.T, r auth.CredentialRepository) {
+	ctx, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+
+	_, err := r.ByToken(ctx, "token")
+	assert.Equal(t, auth.ErrTimeout, auth.ErrorCode(err))
+
+	assert.Equal(t, auth.ErrTimeout, auth.ErrorCode(r.Create(ctx, newCredential(1))))
+}
+
+// newCredential returns a Credential with the email and the token unique by i.
+func newCredential(i int) *auth.Credential {
+	return &auth.Credential{
+		Password:  "hash",
+		Token:     fmt.Sprintf("token%d", i),
+		Email:     fmt.Sprintf("user%d@example.org", i),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// assertCredential compares Credentials, times are compared by the instant as storages may change the location.
+func assertCredential(t *testing.T, want, got auth.Credential) {
+	t.Helper()
+
+	times := func(c *auth.Credential) []*time.Time {
+		return []*time.Time{&c.TokenExpiresAt, &c.StatusUntil, &c.CreatedAt, &c.UpdatedAt}
+	}
+
+	wantTimes, gotTimes := times(&want), times(&got)
+	for i := range wantTimes {
+		assert.True(t, wantTimes[i].Equal(*gotTimes[i]), "want %s, got %s", wantTimes[i], gotTimes[i])
+		*wantTimes[i], *gotTimes[i] = time.Time{}, time.Time{}
+	}
+
+	assert.Equal(t, want, got)
+}
@@ -0,0 +1,153 @@
+// Package sqlite implements repositories on top of an embedded SQLite database for single node deployments
+// and integration tests that can't run Postgres. It uses a pure Go driver, so it doesn't require cgo.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/rs/zerolog"
+	sqlitedrv "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/logging"
+)
+
+// pragmas are applied to every connection: foreign keys are off by default in SQLite,
+// the busy timeout makes writers of other processes wait instead of failing.
+const pragmas = "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+
+// Client is a connection to a SQLite database.
+type Client struct {
+	db               *sql.DB
+	logger           zerolog.Logger
+	statementTimeout time.Duration
+}
+
+// NewClient returns a new Client for DB connection.
+func NewClient(options ...ConfigOption) *Client {
+	c := Client{
+		logger: zerolog.New(io.Discard),
+	}
+
+	for _, opt := range options {
+		opt(&c)
+	}
+
+	return &c
+}
+
+// ConfigOption configures the client.
+type ConfigOption func(*Client)
+
+// WithLogger configures a logger to debug interactions with SQLite.
+func WithLogger(l zerolog.Logger) ConfigOption {
+	return func(c *Client) {
+		c.logger = l
+	}
+}
+
+// WithStatementTimeout configures a default timeout of a single query.
+// A deadline of the query context takes precedence if it is earlier.
+func WithStatementTimeout(t time.Duration) ConfigOption {
+	return func(c *Client) {
+		c.statementTimeout = t
+	}
+}
+
+// Open opens the SQLite database file at path creating it if it doesn't exist.
+func (c *Client) Open(path string) error {
+	c.logger.Debug().Str("path", path).Msg("opening db")
+
+	db, err := sql.Open("sqlite", "file:"+path+"?"+pragmas)
+	if err != nil {
+		c.logger.Err(err).Msg("sql open failed")
+		return err
+	}
+
+	// SQLite serializes writers anyway, a single connection avoids busy errors within the process
+	// and keeps in-memory databases from being opened once per connection.
+	db.SetMaxOpenConns(1)
+
+	if err = db.Ping(); err != nil {
+		c.logger.Err(err).Msg("sql ping failed")
+		_ = db.Close()
+
+		return err
+	}
+
+	c.db = db
+
+	c.logger.Debug().Msg("opened db")
+
+	return nil
+}
+
+// Close closes the database.
+func (c *Client) Close() error {
+	c.logger.Debug().Msg("db closed")
+
+	return c.db.Close()
+}
+
+// Ping checks that the database can be used.
+func (c *Client) Ping(ctx context.Context) error {
+	return c.db.PingContext(ctx)
+}
+
+// startQuery limits ctx by the default statement timeout.
+// The returned function must be called with the query error once the query is done.
+func (c *Client) startQuery(ctx context.Context, stmt string) (context.Context, func(err error)) {
+	cancel := func() {}
+	if c.statementTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.statementTimeout)
+	}
+
+	return ctx, func(err error) {
+		cancel()
+
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			logging.FromContext(ctx, &c.logger).Error().Err(err).Str("stmt", stmt).Msg("query failed")
+		}
+	}
+}
+
+// queryError converts errors common for all queries into auth errors.
+func queryError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return auth.WrapError(err, auth.ErrTimeout, "Query timed out")
+	}
+
+	var sqliteErr *sqlitedrv.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_INTERRUPT {
+		return auth.WrapError(err, auth.ErrTimeout, "Query timed out")
+	}
+
+	return err
+}
+
+// nullTime stores the zero time as NULL.
+func nullTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+
+	return t.UnixMicro()
+}
+
+// fromNullTime reads a time stored by nullTime.
+func fromNullTime(v sql.NullInt64) time.Time {
+	if !v.Valid {
+		return time.Time{}
+	}
+
+	return time.UnixMicro(v.Int64).UTC()
+}
@@ -0,0 +1,271 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	sqlitedrv "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	auth "github.com/kl09/auth-go"
+)
+
+const defaultSearchLimit = 100
+
+// likeEscaper escapes wildcards of a LIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// CredentialRepository is a repository for credentials.
+type CredentialRepository struct {
+	*Client
+}
+
+// NewCredentialRepository creates a new CredentialRepository.
+func NewCredentialRepository(c *Client) *CredentialRepository {
+	return &CredentialRepository{
+		c,
+	}
+}
+
+// ByToken returns a Credential by token.
+func (c *CredentialRepository) ByToken(ctx context.Context, token string) (auth.Credential, error) {
+	return c.credential(ctx, stmtCredentialByToken, tenantID(ctx), token)
+}
+
+// ByID returns a Credential by id.
+func (c *CredentialRepository) ByID(ctx context.Context, id int) (auth.Credential, error) {
+	return c.credential(ctx, stmtCredentialByID, tenantID(ctx), id)
+}
+
+// ByEmail returns a Credential by email.
+func (c *CredentialRepository) ByEmail(ctx context.Context, email string) (auth.Credential, error) {
+	return c.credential(ctx, stmtCredentialByEmail, tenantID(ctx), email)
+}
+
+// Create creates a new Credential in the tenant of the context.
+func (c *CredentialRepository) Create(ctx context.Context, cred *auth.Credential) error {
+	ctx, done := c.startQuery(ctx, stmtCredentialCreate)
+
+	tenant := tenantID(ctx)
+
+	res, err := c.db.ExecContext(ctx, statements[stmtCredentialCreate],
+		tenant,
+		cred.Password,
+		cred.Token,
+		nullTime(cred.TokenExpiresAt),
+		cred.Email,
+		cred.EmailTmp,
+		cred.EmailVerified,
+		cred.VerificationCode,
+		cred.VerificationCodeAttempts,
+		status(cred.Status),
+		cred.StatusReason,
+		nullTime(cred.StatusUntil),
+		cred.ActiveOrganizationID,
+		cred.CreatedAt.UnixMicro(),
+		cred.UpdatedAt.UnixMicro(),
+	)
+
+	var id int64
+	if err == nil {
+		id, err = res.LastInsertId()
+	}
+	done(err)
+
+	if err != nil {
+		return credentialError(err)
+	}
+
+	cred.ID = int(id)
+	cred.TenantID = tenant
+
+	return nil
+}
+
+// Search returns Credentials matching the filter ordered by id.
+func (c *CredentialRepository) Search(ctx context.Context, f auth.CredentialFilter) ([]auth.Credential, error) {
+	if f.Limit <= 0 {
+		f.Limit = defaultSearchLimit
+	}
+
+	return c.credentials(ctx, stmtCredentialSearch, tenantID(ctx), likeEscaper.Replace(f.Email), f.Limit, f.Offset)
+}
+
+// PendingDeletion returns Credentials of all tenants pending deletion with StatusUntil before the time
+// ordered by StatusUntil.
+func (c *CredentialRepository) PendingDeletion(ctx context.Context, before time.Time, limit int) ([]auth.Credential, error) {
+	return c.credentials(ctx, stmtCredentialPendingDeletion, before.UnixMicro(), limit)
+}
+
+// Update updates a Credential by id.
+func (c *CredentialRepository) Update(ctx context.Context, cred *auth.Credential) error {
+	return c.exec(ctx, stmtCredentialUpdate,
+		tenantID(ctx),
+		cred.ID,
+		cred.Password,
+		cred.Token,
+		nullTime(cred.TokenExpiresAt),
+		cred.Email,
+		cred.EmailTmp,
+		cred.EmailVerified,
+		cred.VerificationCode,
+		cred.VerificationCodeAttempts,
+		status(cred.Status),
+		cred.StatusReason,
+		nullTime(cred.StatusUntil),
+		cred.ActiveOrganizationID,
+		cred.UpdatedAt.UnixMicro(),
+	)
+}
+
+// Delete deletes a Credential by id.
+func (c *CredentialRepository) Delete(ctx context.Context, id int) error {
+	return c.exec(ctx, stmtCredentialDelete, tenantID(ctx), id)
+}
+
+// exec runs a statement changing a single row, no changed rows mean the Credential is not found.
+func (c *CredentialRepository) exec(ctx context.Context, stmt string, args ...any) error {
+	ctx, done := c.startQuery(ctx, stmt)
+
+	res, err := c.db.ExecContext(ctx, statements[stmt], args...)
+
+	var n int64
+	if err == nil {
+		n, err = res.RowsAffected()
+	}
+	done(err)
+
+	if err == nil && n == 0 {
+		err = sql.ErrNoRows
+	}
+
+	return credentialError(err)
+}
+
+func (c *CredentialRepository) credential(ctx context.Context, stmt string, args ...any) (auth.Credential, error) {
+	ctx, done := c.startQuery(ctx, stmt)
+
+	cred, err := scanCredential(c.db.QueryRowContext(ctx, statements[stmt], args...))
+	done(err)
+
+	if err != nil {
+		return auth.Credential{}, credentialError(err)
+	}
+
+	return cred, nil
+}
+
+// credentials queries credentialColumns of rows.
+func (c *CredentialRepository) credentials(ctx context.Context, stmt string, args ...any) ([]auth.Credential, error) {
+	ctx, done := c.startQuery(ctx, stmt)
+
+	creds, err := c.queryCredentials(ctx, stmt, args...)
+	done(err)
+
+	return creds, credentialError(err)
+}
+
+func (c *CredentialRepository) queryCredentials(ctx context.Context, stmt string, args ...any) ([]auth.Credential, error) {
+	rows, err := c.db.QueryContext(ctx, statements[stmt], args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	creds := make([]auth.Credential, 0)
+
+	for rows.Next() {
+		cred, err := scanCredential(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		creds = append(creds, cred)
+	}
+
+	return creds, rows.Err()
+}
+
+// row is a single row of *sql.Row or *sql.Rows.
+type row interface {
+	Scan(dest ...any) error
+}
+
+// scanCredential scans credentialColumns of a row.
+func scanCredential(r row) (auth.Credential, error) {
+	var (
+		cred           auth.Credential
+		tokenExpiresAt sql.NullInt64
+		until          sql.NullInt64
+		activeOrgID    sql.NullInt64
+		createdAt      int64
+		updatedAt      int64
+	)
+
+	err := r.Scan(
+		&cred.ID,
+		&cred.TenantID,
+		&cred.Password,
+		&cred.Token,
+		&tokenExpiresAt,
+		&cred.Email,
+		&cred.EmailTmp,
+		&cred.EmailVerified,
+		&cred.VerificationCode,
+		&cred.VerificationCodeAttempts,
+		&cred.Status,
+		&cred.StatusReason,
+		&until,
+		&activeOrgID,
+		&createdAt,
+		&updatedAt,
+	)
+	if err != nil {
+		return auth.Credential{}, err
+	}
+
+	cred.TokenExpiresAt = fromNullTime(tokenExpiresAt)
+	cred.StatusUntil = fromNullTime(until)
+	cred.ActiveOrganizationID = int(activeOrgID.Int64)
+	cred.CreatedAt = time.UnixMicro(createdAt).UTC()
+	cred.UpdatedAt = time.UnixMicro(updatedAt).UTC()
+
+	return cred, nil
+}
+
+// tenantID returns the tenant of the context all queries are scoped by.
+func tenantID(ctx context.Context) string {
+	return auth.TenantFromContext(ctx).ID
+}
+
+// status defaults an empty status to active.
+func status(s string) string {
+	if s == "" {
+		return auth.StatusActive
+	}
+
+	return s
+}
+
+// credentialError converts SQLite errors into auth errors.
+func credentialError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return auth.NewError(auth.ErrCredNotFound, "Credential not found")
+	}
+
+	// SQLite reports the columns of a violated constraint instead of its name.
+	var sqliteErr *sqlitedrv.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE &&
+		strings.Contains(sqliteErr.Error(), "credential.tenant_id, credential.email") {
+		return auth.WrapError(err, auth.ErrEmailExists, "User with this email already exists.")
+	}
+
+	return queryError(err)
+}
@@ -0,0 +1,35 @@
+package sqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	auth "github.com/kl09/auth-go"
+	"github.com/kl09/auth-go/internal/repotest"
+	"github.com/kl09/auth-go/internal/sqlite"
+)
+
+func TestCredentialRepository(t *testing.T) {
+	repotest.TestCredentialRepository(t, func(t *testing.T) auth.CredentialRepository {
+		return sqlite.NewCredentialRepository(setUp(t))
+	})
+}
+
+// setUp opens a migrated database in a temporary directory.
+func setUp(t *testing.T) *sqlite.Client {
+	t.Helper()
+
+	c := sqlite.NewClient()
+	if err := c.Open(filepath.Join(t.TempDir(), "auth.db")); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = c.Close() })
+
+	if _, err := sqlite.NewMigrator(c).Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
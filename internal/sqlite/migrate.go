@@ -0,0 +1,159 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+const createMigrationsTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations
+(
+	version INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	applied_at INTEGER NOT NULL
+);
+`
+
+// Migration is a numbered schema change.
+// There are no rollbacks, a SQLite database is a single file that is backed up by copying it.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+}
+
+// Migrations returns all migrations embedded into the binary ordered by version.
+func Migrations() ([]Migration, error) {
+	return parseMigrations(migrationFiles, "migrations")
+}
+
+// parseMigrations reads migrations named as NNNN_name.sql from dir.
+func parseMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(entries))
+	versions := make(map[int]bool)
+
+	for _, e := range entries {
+		name := e.Name()
+
+		parts := strings.SplitN(strings.TrimSuffix(name, ".sql"), "_", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("migration %s: name must be NNNN_name.sql", name)
+		}
+
+		version, err := strconv.Atoi(parts[0])
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: bad version", name)
+		}
+
+		if versions[version] {
+			return nil, fmt.Errorf("migration %d: duplicate version", version)
+		}
+
+		versions[version] = true
+
+		b, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, Migration{Version: version, Name: parts[1], Up: string(b)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Migrator applies schema migrations.
+type Migrator struct {
+	client *Client
+}
+
+// NewMigrator creates a new Migrator.
+func NewMigrator(c *Client) *Migrator {
+	return &Migrator{
+		client: c,
+	}
+}
+
+// Up applies all pending migrations and returns the applied ones.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = m.client.db.ExecContext(ctx, createMigrationsTable); err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+
+	for _, mig := range migrations {
+		ok, err := m.apply(ctx, mig)
+		if err != nil {
+			return applied, fmt.Errorf("migration %d up: %w", mig.Version, err)
+		}
+
+		if ok {
+			applied = append(applied, mig)
+		}
+	}
+
+	return applied, nil
+}
+
+// apply applies the migration in a transaction unless it has been applied already.
+func (m *Migrator) apply(ctx context.Context, mig Migration) (bool, error) {
+	tx, err := m.client.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var version int
+
+	err = tx.QueryRowContext(ctx, "SELECT version FROM schema_migrations WHERE version = ?", mig.Version).Scan(&version)
+	if err == nil {
+		return false, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+
+	m.client.logger.Info().Int("version", mig.Version).Str("name", mig.Name).Msg("applying migration")
+
+	if _, err = tx.ExecContext(ctx, mig.Up); err != nil {
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+		mig.Version, mig.Name, time.Now().UnixMicro(),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
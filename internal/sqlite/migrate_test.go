@@ -0,0 +1,32 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kl09/auth-go/internal/sqlite"
+)
+
+func TestMigrations(t *testing.T) {
+	migrations, err := sqlite.Migrations()
+	require.Nil(t, err)
+	require.NotEmpty(t, migrations)
+
+	for i, m := range migrations {
+		assert.Equal(t, i+1, m.Version)
+		assert.NotEmpty(t, m.Name)
+		assert.NotEmpty(t, m.Up)
+	}
+}
+
+func TestMigrator_Up(t *testing.T) {
+	c := setUp(t)
+
+	// setUp has already applied everything.
+	applied, err := sqlite.NewMigrator(c).Up(context.Background())
+	require.Nil(t, err)
+	assert.Empty(t, applied)
+}
@@ -0,0 +1,28 @@
+-- Times are unix microseconds in UTC, the precision of Postgres timestamps.
+-- There are no tenant and organization tables, so their ids aren't foreign keys.
+CREATE TABLE credential
+(
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	tenant_id TEXT NOT NULL DEFAULT 'default',
+	password TEXT NOT NULL,
+	token TEXT NOT NULL,
+	token_expires_at INTEGER,
+	email TEXT NOT NULL DEFAULT '',
+	email_tmp TEXT NOT NULL DEFAULT '',
+	email_verified INTEGER NOT NULL,
+	verification_code TEXT NOT NULL DEFAULT '',
+	verification_code_attempts INTEGER NOT NULL DEFAULT 0,
+	status TEXT NOT NULL DEFAULT 'active',
+	status_reason TEXT NOT NULL DEFAULT '',
+	status_until INTEGER,
+	active_organization_id INTEGER,
+	created_at INTEGER NOT NULL,
+	updated_at INTEGER NOT NULL,
+	UNIQUE (tenant_id, email),
+	UNIQUE (token)
+);
+
+-- Empty email_tmp means there is no pending email change, so it must not be unique.
+CREATE UNIQUE INDEX credential_tenant_id_email_tmp_key ON credential (tenant_id, email_tmp) WHERE email_tmp <> '';
+
+CREATE INDEX credential_pending_deletion_idx ON credential (status_until) WHERE status = 'pending_deletion';
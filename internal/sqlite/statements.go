@@ -0,0 +1,44 @@
+package sqlite
+
+// Names of statements used in logs.
+const (
+	stmtCredentialByToken = "credential_by_token"
+	stmtCredentialByID    = "credential_by_id"
+	stmtCredentialByEmail = "credential_by_email"
+	stmtCredentialCreate  = "credential_create"
+	stmtCredentialSearch  = "credential_search"
+	stmtCredentialUpdate  = "credential_update"
+	stmtCredentialDelete  = "credential_delete"
+
+	stmtCredentialPendingDeletion = "credential_pending_deletion"
+)
+
+const credentialColumns = `id, tenant_id, password, token, token_expires_at, email, email_tmp, email_verified,
+	verification_code, verification_code_attempts, status, status_reason, status_until, active_organization_id,
+	created_at, updated_at`
+
+var statements = map[string]string{
+	stmtCredentialByToken: `SELECT ` + credentialColumns + ` FROM credential WHERE tenant_id = ? AND token = ?`,
+	stmtCredentialByID:    `SELECT ` + credentialColumns + ` FROM credential WHERE tenant_id = ? AND id = ?`,
+	stmtCredentialByEmail: `SELECT ` + credentialColumns + ` FROM credential WHERE tenant_id = ? AND email = ?`,
+	stmtCredentialCreate: `INSERT INTO credential (tenant_id, password, token, token_expires_at, email, email_tmp,
+	email_verified, verification_code, verification_code_attempts, status, status_reason, status_until,
+	active_organization_id, created_at, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, 0), ?, ?)`,
+	// LIKE is case-insensitive for ASCII only, unlike ILIKE of Postgres.
+	stmtCredentialSearch: `SELECT ` + credentialColumns + ` FROM credential
+	WHERE tenant_id = ?1
+	AND (?2 = '' OR email LIKE '%' || ?2 || '%' ESCAPE '\' OR email_tmp LIKE '%' || ?2 || '%' ESCAPE '\')
+	ORDER BY id
+	LIMIT ?3 OFFSET ?4`,
+	stmtCredentialUpdate: `UPDATE credential SET password = ?3, token = ?4, token_expires_at = ?5, email = ?6,
+	email_tmp = ?7, email_verified = ?8, verification_code = ?9, verification_code_attempts = ?10,
+	status = ?11, status_reason = ?12, status_until = ?13, active_organization_id = NULLIF(?14, 0),
+	updated_at = ?15
+	WHERE tenant_id = ?1 AND id = ?2`,
+	stmtCredentialDelete: `DELETE FROM credential WHERE tenant_id = ? AND id = ?`,
+	stmtCredentialPendingDeletion: `SELECT ` + credentialColumns + ` FROM credential
+	WHERE status = 'pending_deletion' AND status_until < ?
+	ORDER BY status_until
+	LIMIT ?`,
+}
@@ -0,0 +1,74 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/kl09/auth-go/internal/tracing"
+)
+
+func TestMiddleware(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+
+	var handlerSpan trace.SpanContext
+
+	e := echo.New()
+	e.Use(tracing.Middleware(tp))
+	e.GET("/v1/users-by-token/:token", func(c echo.Context) error {
+		handlerSpan = trace.SpanContextFromContext(c.Request().Context())
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/users-by-token/12345", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	spans := exp.GetSpans()
+	require.Len(t, spans, 1)
+
+	s := spans[0]
+	require.Equal(t, "GET /v1/users-by-token/:token", s.Name)
+	require.Equal(t, trace.SpanKindServer, s.SpanKind)
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", s.SpanContext.TraceID().String())
+	require.Equal(t, "00f067aa0ba902b7", s.Parent.SpanID().String())
+	require.Equal(t, s.SpanContext.SpanID(), handlerSpan.SpanID())
+	require.Contains(t, s.Attributes, attribute.Int("http.response.status_code", http.StatusOK))
+	require.Contains(t, s.Attributes, attribute.String("http.route", "/v1/users-by-token/:token"))
+
+	for _, a := range s.Attributes {
+		require.NotContains(t, a.Value.Emit(), "12345", "the token leaked to %s", a.Key)
+	}
+}
+
+func TestMiddleware_Unmatched(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+
+	e := echo.New()
+	e.Use(tracing.Middleware(tp))
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/bad_url", nil))
+
+	spans := exp.GetSpans()
+	require.Len(t, spans, 1)
+	require.Equal(t, "GET unmatched", spans[0].Name)
+	require.Contains(t, spans[0].Attributes, attribute.Int("http.response.status_code", http.StatusNotFound))
+}
+
+func TestNewProvider(t *testing.T) {
+	_, err := tracing.NewProvider(context.Background(), "unknown", "", "auth-go")
+	require.NotNil(t, err)
+}
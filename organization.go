@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+//go:generate moq -pkg mock -out internal/mock/organization.go . OrganizationRepository
+
+// Roles of members of an Organization.
+const (
+	// OrgRoleOwner manages the organization and its members.
+	OrgRoleOwner = "owner"
+	// OrgRoleAdmin invites new members.
+	OrgRoleAdmin = "admin"
+	// OrgRoleMember is a regular member.
+	OrgRoleMember = "member"
+)
+
+// ValidOrgRole checks if the role is one of the known roles of members.
+func ValidOrgRole(role string) bool {
+	switch role {
+	case OrgRoleOwner, OrgRoleAdmin, OrgRoleMember:
+		return true
+	}
+
+	return false
+}
+
+// Organization is a group of credentials, e.g. a customer company.
+type Organization struct {
+	ID        int
+	TenantID  string
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Membership is a Credential being a member of an Organization with a role.
+type Membership struct {
+	Organization Organization
+	CredentialID int
+	Role         string
+	CreatedAt    time.Time
+}
+
+// Invitation invites an email to join an Organization with a role.
+type Invitation struct {
+	ID             int
+	OrganizationID int
+	Email          string
+	Role           string
+	// InvitedBy is the id of the inviting Credential.
+	InvitedBy int
+	ExpiresAt time.Time
+	// AcceptedAt is zero until the invitation is accepted.
+	AcceptedAt time.Time
+	CreatedAt  time.Time
+}
+
+// OrganizationRepository is a storage for organizations, memberships and invitations.
+// All methods are scoped by the tenant of the context, see TenantFromContext.
+type OrganizationRepository interface {
+	// Create creates a new Organization with the Credential as the owner.
+	Create(ctx context.Context, o *Organization, ownerID int) error
+	// ByCredential retrieves memberships of a Credential ordered by organization id.
+	ByCredential(ctx context.Context, credID int) ([]Membership, error)
+	// Member retrieves a membership of a Credential in an Organization.
+	Member(ctx context.Context, orgID, credID int) (Membership, error)
+	// CreateInvitation creates a new Invitation.
+	CreateInvitation(ctx context.Context, i *Invitation) error
+	// Invitation retrieves an Invitation by id.
+	Invitation(ctx context.Context, id int) (Invitation, error)
+	// AcceptInvitation marks a pending Invitation as accepted and makes the Credential a member.
+	AcceptInvitation(ctx context.Context, id, credID int, at time.Time) (Membership, error)
+	// DeleteInvitations deletes invitations addressed to the email, pending and accepted ones.
+	DeleteInvitations(ctx context.Context, email string) error
+}
+
+// OrganizationService represents a service for organizations.
+type OrganizationService interface {
+	// Create creates a new Organization owned by the Credential.
+	Create(ctx context.Context, credID int, o *Organization) error
+	// Organizations retrieves memberships of the Credential.
+	Organizations(ctx context.Context, credID int) ([]Membership, error)
+	// Invite invites the email to the Organization on behalf of the Credential, returns the invitation token.
+	Invite(ctx context.Context, credID int, i *Invitation) (string, error)
+	// AcceptInvitation makes the invited Credential a member of the Organization,
+	// the Credential is created with the password if the email isn't registered yet.
+	AcceptInvitation(ctx context.Context, token, plainPassword string) (Credential, Membership, error)
+	// Switch makes the Organization active for the Credential, 0 resets the active Organization.
+	Switch(ctx context.Context, credID, orgID int) (Credential, error)
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+//go:generate moq -pkg mock -out internal/mock/profile.go . ProfileRepository
+
+// MaxMetadataSize is a max size of the JSON encoding of each part of the metadata of a Profile.
+const MaxMetadataSize = 8 << 10
+
+// Types of metadata values of a ProfileSchema.
+const (
+	MetadataString  = "string"
+	MetadataNumber  = "number"
+	MetadataBoolean = "boolean"
+	MetadataObject  = "object"
+	MetadataArray   = "array"
+)
+
+// ProfileSchema maps metadata keys to the types of their values, see MetadataString.
+// Any keys are allowed if the schema is empty.
+type ProfileSchema map[string]string
+
+// Profile is a user's profile shared between services instead of keeping their own copies.
+type Profile struct {
+	CredentialID int
+	DisplayName  string
+	// Locale is a BCP 47 language tag, e.g. "en-US".
+	Locale string
+	// Timezone is an IANA time zone, e.g. "Europe/Berlin".
+	Timezone string
+	// PublicMetadata is visible to services the profile is shared with, e.g. in the /v1/auth response.
+	PublicMetadata map[string]any
+	// PrivateMetadata is visible only to the user.
+	PrivateMetadata map[string]any
+	// UpdatedAt is zero if the profile was never updated.
+	UpdatedAt time.Time
+}
+
+// ProfileUpdate is a partial update of a Profile, nil fields are kept.
+// Metadata is merged by keys, keys with nil values are deleted.
+type ProfileUpdate struct {
+	DisplayName     *string
+	Locale          *string
+	Timezone        *string
+	PublicMetadata  map[string]any
+	PrivateMetadata map[string]any
+}
+
+// ProfileRepository is a storage for profiles.
+// All methods are scoped by the tenant of the context, see TenantFromContext.
+type ProfileRepository interface {
+	// ByCredential retrieves the Profile of a Credential, an empty Profile if it was never saved.
+	ByCredential(ctx context.Context, credID int) (Profile, error)
+	// Save creates or updates the Profile of a Credential.
+	Save(ctx context.Context, p *Profile) error
+}
+
+// ProfileService represents a service for profiles.
+type ProfileService interface {
+	// Profile retrieves the Profile of a Credential.
+	Profile(ctx context.Context, credID int) (Profile, error)
+	// UpdateProfile validates and applies the update to the Profile of a Credential.
+	UpdateProfile(ctx context.Context, credID int, u ProfileUpdate) (Profile, error)
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+//go:generate moq -pkg mock -out internal/mock/role.go . RoleRepository
+
+// Wildcard matches any action or resource of a Permission.
+const Wildcard = "*"
+
+// Permission allows an action on a resource.
+type Permission struct {
+	Action   string
+	Resource string
+}
+
+// Allows checks if the Permission allows the action on the resource.
+func (p Permission) Allows(action, resource string) bool {
+	return (p.Action == Wildcard || p.Action == action) &&
+		(p.Resource == Wildcard || p.Resource == resource)
+}
+
+// Role is a named set of permissions assigned to credentials.
+type Role struct {
+	ID          int
+	Name        string
+	Description string
+	Permissions []Permission
+	CreatedAt   time.Time
+}
+
+// EffectivePermissions returns unique permissions of the roles sorted by resource and action.
+func EffectivePermissions(roles []Role) []Permission {
+	seen := make(map[Permission]bool)
+	perms := make([]Permission, 0)
+
+	for _, r := range roles {
+		for _, p := range r.Permissions {
+			if seen[p] {
+				continue
+			}
+
+			seen[p] = true
+			perms = append(perms, p)
+		}
+	}
+
+	sort.Slice(perms, func(i, j int) bool {
+		if perms[i].Resource != perms[j].Resource {
+			return perms[i].Resource < perms[j].Resource
+		}
+
+		return perms[i].Action < perms[j].Action
+	})
+
+	return perms
+}
+
+// Allowed checks if one of the permissions allows the action on the resource.
+func Allowed(perms []Permission, action, resource string) bool {
+	for _, p := range perms {
+		if p.Allows(action, resource) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RoleRepository is a storage for roles and their assignments to credentials.
+// Roles and their assignments are scoped by the tenant of the context, see TenantFromContext.
+type RoleRepository interface {
+	// Roles retrieves all Roles ordered by name.
+	Roles(ctx context.Context) ([]Role, error)
+	// ByCredential retrieves Roles assigned to a Credential ordered by name.
+	ByCredential(ctx context.Context, credID int) ([]Role, error)
+	// Create creates a new Role with its permissions.
+	Create(ctx context.Context, r *Role) error
+	// Delete deletes a Role by name with all its assignments.
+	Delete(ctx context.Context, name string) error
+	// Assign assigns a Role to a Credential, assigning it twice is not an error.
+	Assign(ctx context.Context, credID int, name string) error
+	// Unassign removes a Role from a Credential, removing a missing assignment is not an error.
+	Unassign(ctx context.Context, credID int, name string) error
+}
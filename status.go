@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"time"
+)
+
+// Statuses of a Credential.
+const (
+	// StatusActive allows to use the Credential.
+	StatusActive = "active"
+	// StatusSuspended blocks the Credential until StatusUntil, or forever if it is zero.
+	StatusSuspended = "suspended"
+	// StatusDisabled blocks the Credential until it is enabled again.
+	StatusDisabled = "disabled"
+	// StatusPendingDeletion blocks the Credential which is going to be deleted.
+	// It is purged after StatusUntil, or kept until deleted by an administrator if it is zero.
+	StatusPendingDeletion = "pending_deletion"
+)
+
+// ValidStatus checks if status is one of the known statuses.
+func ValidStatus(status string) bool {
+	switch status {
+	case StatusActive, StatusSuspended, StatusDisabled, StatusPendingDeletion:
+		return true
+	}
+
+	return false
+}
+
+// StatusAt returns the status of the Credential at the time,
+// an expired suspension is lifted.
+func (c Credential) StatusAt(now time.Time) string {
+	if c.Status == "" {
+		return StatusActive
+	}
+
+	if c.Status == StatusSuspended && !c.StatusUntil.IsZero() && !now.Before(c.StatusUntil) {
+		return StatusActive
+	}
+
+	return c.Status
+}
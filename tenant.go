@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"unicode"
+)
+
+//go:generate moq -pkg mock -out internal/mock/tenant.go . TenantRepository
+
+// DefaultTenantID is the tenant of requests which don't specify one.
+const DefaultTenantID = "default"
+
+// DefaultInvitationTTL is a lifetime of an invitation of a tenant without the InvitationTTL setting.
+const DefaultInvitationTTL = 7 * 24 * time.Hour
+
+// Tenant is a separate user base with its own settings.
+type Tenant struct {
+	ID   string
+	Name string
+	// Hosts are used to resolve the tenant of a request.
+	Hosts     []string
+	Settings  TenantSettings
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TenantSettings are settings of a Tenant.
+type TenantSettings struct {
+	PasswordPolicy PasswordPolicy
+	// TokenTTL is a lifetime of a credential token, tokens don't expire if it is zero.
+	TokenTTL time.Duration
+	// InvitationTTL is a lifetime of an invitation to an organization, DefaultInvitationTTL is used if it is zero.
+	InvitationTTL time.Duration
+	// MailTemplates are templates of emails sent to users by name, e.g. "verification".
+	MailTemplates map[string]MailTemplate
+	// ProfileSchema restricts keys and types of the metadata of profiles.
+	ProfileSchema ProfileSchema
+}
+
+// MailTemplate is a template of an email.
+type MailTemplate struct {
+	Subject string
+	Body    string
+}
+
+// PasswordPolicy describes requirements to passwords, zero fields are ignored.
+type PasswordPolicy struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+// Validate checks the password against the policy.
+func (p PasswordPolicy) Validate(pwd string) error {
+	var upper, lower, digit, symbol bool
+
+	for _, r := range pwd {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	length := len([]rune(pwd))
+
+	switch {
+	case p.MinLength > 0 && length < p.MinLength:
+		return NewError(ErrPasswordPolicy, fmt.Sprintf("Password must be at least %d characters long.", p.MinLength))
+	case p.MaxLength > 0 && length > p.MaxLength:
+		return NewError(ErrPasswordPolicy, fmt.Sprintf("Password must be at most %d characters long.", p.MaxLength))
+	case p.RequireUpper && !upper:
+		return NewError(ErrPasswordPolicy, "Password must contain an uppercase letter.")
+	case p.RequireLower && !lower:
+		return NewError(ErrPasswordPolicy, "Password must contain a lowercase letter.")
+	case p.RequireDigit && !digit:
+		return NewError(ErrPasswordPolicy, "Password must contain a digit.")
+	case p.RequireSymbol && !symbol:
+		return NewError(ErrPasswordPolicy, "Password must contain a symbol.")
+	}
+
+	return nil
+}
+
+// TenantRepository is a storage for tenants.
+type TenantRepository interface {
+	// ByID retrieves a Tenant by id.
+	ByID(ctx context.Context, id string) (Tenant, error)
+	// ByHost retrieves a Tenant by one of its hosts.
+	ByHost(ctx context.Context, host string) (Tenant, error)
+	// Tenants retrieves all Tenants ordered by id.
+	Tenants(ctx context.Context) ([]Tenant, error)
+	// Save creates or updates a Tenant.
+	Save(ctx context.Context, t *Tenant) error
+}
+
+type tenantKey struct{}
+
+// ContextWithTenant returns a copy of ctx with the tenant, repositories are scoped by it.
+func ContextWithTenant(ctx context.Context, t Tenant) context.Context {
+	return context.WithValue(ctx, tenantKey{}, t)
+}
+
+// TenantFromContext returns the tenant of ctx or the default one with default settings.
+func TenantFromContext(ctx context.Context) Tenant {
+	if t, ok := ctx.Value(tenantKey{}).(Tenant); ok {
+		return t
+	}
+
+	return Tenant{ID: DefaultTenantID}
+}